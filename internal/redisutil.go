@@ -18,6 +18,43 @@ type RedisConfig struct {
 	DialTimeout  time.Duration // 连接超时时间，默认为 5 秒
 	ReadTimeout  time.Duration // 读取超时时间，默认为 3 秒
 	WriteTimeout time.Duration // 写入超时时间，默认为 3 秒
+
+	// Sentinel 高可用配置，设置 SentinelMasterName 后通过 Sentinel 发现主节点，忽略 Addr
+	SentinelMasterName string   // Sentinel 监控的主节点名称
+	SentinelAddrs      []string // Sentinel 地址列表，格式：host:port
+	SentinelPassword   string   // Sentinel 密码，为空表示无密码（与 Redis 密码相互独立）
+
+	ReadFromReplicas bool // Sentinel 模式下是否将只读命令路由到从节点，无可用从节点时退回主节点
+
+	// Redis Cluster 配置，设置后（且未配置 Sentinel）以集群模式连接，忽略 Addr 以及上面的连接参数；
+	// 集群只有 0 号数据库，DB 必须为 0；只读命令的路由由 ReadOnly/RouteByLatency/RouteRandomly 控制
+	Cluster *ClusterConfig
+}
+
+// UseSentinel 是否通过 Sentinel 连接
+func (c *RedisConfig) UseSentinel() bool {
+	return c.SentinelMasterName != ""
+}
+
+// UseCluster 是否以 Redis Cluster 模式连接，同时配置时 Sentinel 优先
+func (c *RedisConfig) UseCluster() bool {
+	return !c.UseSentinel() && c.Cluster != nil
+}
+
+// FailoverOptions 将配置转换为 Sentinel 故障转移客户端的选项
+func (c *RedisConfig) FailoverOptions() *redis.FailoverOptions {
+	return &redis.FailoverOptions{
+		MasterName:       c.SentinelMasterName,
+		SentinelAddrs:    c.SentinelAddrs,
+		SentinelPassword: c.SentinelPassword,
+		Password:         c.Password,
+		DB:               c.DB,
+		PoolSize:         c.PoolSize,
+		MinIdleConns:     c.MinIdleConns,
+		DialTimeout:      c.DialTimeout,
+		ReadTimeout:      c.ReadTimeout,
+		WriteTimeout:     c.WriteTimeout,
+	}
 }
 
 // DefaultRedisConfig 返回默认的 Redis 配置
@@ -34,6 +71,52 @@ func DefaultRedisConfig() *RedisConfig {
 	}
 }
 
+// ClusterConfig Redis Cluster 连接配置结构体
+type ClusterConfig struct {
+	Addrs          []string      // 种子节点地址列表，格式：host:port，客户端会自动发现其余节点
+	Password       string        // Redis 密码，为空表示无密码
+	ReadOnly       bool          // 是否允许将只读命令路由到从节点
+	RouteByLatency bool          // 只读命令路由到延迟最低的节点（隐含 ReadOnly）
+	RouteRandomly  bool          // 只读命令随机路由到主节点或从节点（隐含 ReadOnly）
+	MaxRedirects   int           // MOVED/ASK 重定向的最大次数，默认为 3
+	PoolSize       int           // 每个节点的连接池大小，默认为 10
+	MinIdleConns   int           // 每个节点的最小空闲连接数，默认为 5
+	DialTimeout    time.Duration // 连接超时时间，默认为 5 秒
+	ReadTimeout    time.Duration // 读取超时时间，默认为 3 秒
+	WriteTimeout   time.Duration // 写入超时时间，默认为 3 秒
+}
+
+// DefaultClusterConfig 返回默认的 Redis Cluster 配置
+func DefaultClusterConfig() *ClusterConfig {
+	return &ClusterConfig{
+		Addrs:        []string{"localhost:7000", "localhost:7001", "localhost:7002"},
+		Password:     "",
+		MaxRedirects: 3,
+		PoolSize:     10,
+		MinIdleConns: 5,
+		DialTimeout:  5 * time.Second,
+		ReadTimeout:  3 * time.Second,
+		WriteTimeout: 3 * time.Second,
+	}
+}
+
+// ClusterOptions 将配置转换为集群客户端的选项
+func (c *ClusterConfig) ClusterOptions() *redis.ClusterOptions {
+	return &redis.ClusterOptions{
+		Addrs:          c.Addrs,
+		Password:       c.Password,
+		ReadOnly:       c.ReadOnly,
+		RouteByLatency: c.RouteByLatency,
+		RouteRandomly:  c.RouteRandomly,
+		MaxRedirects:   c.MaxRedirects,
+		PoolSize:       c.PoolSize,
+		MinIdleConns:   c.MinIdleConns,
+		DialTimeout:    c.DialTimeout,
+		ReadTimeout:    c.ReadTimeout,
+		WriteTimeout:   c.WriteTimeout,
+	}
+}
+
 // RedisOperator Redis 操作接口，支持依赖注入和测试 mock
 type RedisOperator interface {
 	// 连接管理
@@ -111,6 +194,9 @@ func NewRedisClientWithConfig(config *RedisConfig) *redis.Client {
 	if config == nil {
 		config = DefaultRedisConfig()
 	}
+	if config.UseSentinel() {
+		return redis.NewFailoverClient(config.FailoverOptions())
+	}
 
 	rdb := redis.NewClient(&redis.Options{
 		Addr:         config.Addr,
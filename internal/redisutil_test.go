@@ -34,6 +34,88 @@ func TestDefaultRedisConfig(t *testing.T) {
 	}
 }
 
+// TestDefaultClusterConfig 测试默认集群配置生成
+func TestDefaultClusterConfig(t *testing.T) {
+	config := DefaultClusterConfig()
+
+	if len(config.Addrs) == 0 {
+		t.Error("期望默认配置包含种子节点地址")
+	}
+
+	if config.ReadOnly || config.RouteByLatency || config.RouteRandomly {
+		t.Error("期望默认只读命令路由到主节点")
+	}
+
+	if config.MaxRedirects != 3 {
+		t.Errorf("期望最大重定向次数为 3，实际为 %d", config.MaxRedirects)
+	}
+
+	if config.PoolSize != 10 {
+		t.Errorf("期望连接池大小为 10，实际为 %d", config.PoolSize)
+	}
+}
+
+// TestClusterConfig_ClusterOptions 测试集群配置转换
+func TestClusterConfig_ClusterOptions(t *testing.T) {
+	config := DefaultClusterConfig()
+	config.Password = "secret"
+	config.RouteByLatency = true
+	config.RouteRandomly = true
+	config.MaxRedirects = 5
+
+	opts := config.ClusterOptions()
+	if len(opts.Addrs) != len(config.Addrs) || opts.Password != "secret" {
+		t.Errorf("节点地址或密码未正确传递: %+v", opts)
+	}
+	if !opts.RouteByLatency || !opts.RouteRandomly || opts.MaxRedirects != 5 {
+		t.Errorf("路由参数未正确传递: %+v", opts)
+	}
+	if opts.PoolSize != config.PoolSize || opts.ReadTimeout != config.ReadTimeout {
+		t.Errorf("连接参数未正确传递: %+v", opts)
+	}
+
+	redisConfig := DefaultRedisConfig()
+	if redisConfig.UseCluster() {
+		t.Error("期望默认配置不使用集群")
+	}
+	redisConfig.Cluster = config
+	if !redisConfig.UseCluster() {
+		t.Error("期望设置 Cluster 后使用集群")
+	}
+	redisConfig.SentinelMasterName = "mymaster"
+	if redisConfig.UseCluster() {
+		t.Error("期望同时配置时 Sentinel 优先")
+	}
+}
+
+// TestRedisConfig_FailoverOptions 测试 Sentinel 配置转换
+func TestRedisConfig_FailoverOptions(t *testing.T) {
+	config := DefaultRedisConfig()
+	if config.UseSentinel() {
+		t.Error("期望默认配置不使用 Sentinel")
+	}
+
+	config.SentinelMasterName = "mymaster"
+	config.SentinelAddrs = []string{"localhost:26379", "localhost:26380"}
+	config.SentinelPassword = "sentinel-secret"
+	config.Password = "redis-secret"
+	config.DB = 2
+	if !config.UseSentinel() {
+		t.Error("期望设置主节点名称后使用 Sentinel")
+	}
+
+	opts := config.FailoverOptions()
+	if opts.MasterName != "mymaster" || len(opts.SentinelAddrs) != 2 {
+		t.Errorf("Sentinel 地址或主节点名称不正确: %+v", opts)
+	}
+	if opts.SentinelPassword != "sentinel-secret" || opts.Password != "redis-secret" {
+		t.Error("期望 Sentinel 密码与 Redis 密码分别传递")
+	}
+	if opts.DB != 2 || opts.PoolSize != config.PoolSize || opts.ReadTimeout != config.ReadTimeout {
+		t.Errorf("连接参数未正确传递: %+v", opts)
+	}
+}
+
 // TestNewRedisClient 测试基础 Redis 客户端创建
 func TestNewRedisClient(t *testing.T) {
 	// 测试正常创建客户端
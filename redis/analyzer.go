@@ -0,0 +1,462 @@
+package redis
+
+// 大键与热键分析
+//
+// 场景说明：
+//   元素数量巨大的集合会让 HGETALL/SMEMBERS/DEL 这类命令耗时数百毫秒，集中访问的热键会让单个节点的 CPU 打满，
+//   两者都会表现为偶发的延迟尖刺。KeyAnalyzer 用 SCAN 遍历键空间，每批键通过 Pipeline 查询类型、MEMORY USAGE
+//   与元素数量（STRLEN/HLEN/LLEN/SCARD/ZCARD/XLEN），开启 HotKeys 时额外查询 OBJECT FREQ，
+//   由 KeyspaceReportBuilder 汇总出按内存、按元素数量的 Top-N 以及按键前缀聚合的统计。
+//   报告构建器只依赖 KeyStat，也可以用于离线分析 RDB 文件等其他数据来源。
+//
+// 注意：
+//   - MEMORY USAGE 对集合类型只采样部分元素估算（SAMPLES，服务端默认 5），结果是近似值；
+//   - OBJECT FREQ 只在 maxmemory-policy 为 allkeys-lfu/volatile-lfu 时可用，否则服务端返回错误；
+//     频率是对数计数器且会随时间衰减，只能用于相对比较；
+//   - 分析会给服务端带来额外负载，建议在从节点上执行并设置 MaxKeysPerSecond 限速。
+
+import (
+	"container/heap"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// KeyStat 单个键的统计信息
+type KeyStat struct {
+	Key      string `json:"key"`
+	Type     string `json:"type"`
+	Memory   int64  `json:"memory"`         // 占用内存字节数（MEMORY USAGE 估算值），未知时为 0
+	Elements int64  `json:"elements"`       // 元素数量，string 类型为字节长度
+	Freq     int64  `json:"freq,omitempty"` // LFU 访问频率（OBJECT FREQ），仅热键模式
+}
+
+// TypeStat 按类型汇总的统计
+type TypeStat struct {
+	Keys     int64 `json:"keys"`
+	Memory   int64 `json:"memory"`
+	Elements int64 `json:"elements"`
+}
+
+// PrefixStat 按键前缀汇总的统计
+type PrefixStat struct {
+	Prefix   string `json:"prefix"`
+	Keys     int64  `json:"keys"`
+	Memory   int64  `json:"memory"`
+	Elements int64  `json:"elements"`
+}
+
+// KeyspaceReport 键空间分析报告
+type KeyspaceReport struct {
+	GeneratedAt       time.Time            `json:"generated_at"`
+	Keys              int64                `json:"keys"`         // 分析的键数量
+	TotalMemory       int64                `json:"total_memory"` // 所有键的内存之和
+	Types             map[string]*TypeStat `json:"types"`
+	TopByMemory       []KeyStat            `json:"top_by_memory"`
+	TopByElements     map[string][]KeyStat `json:"top_by_elements"` // 按类型分组，string 类型按字节长度排序
+	TopPrefixes       []PrefixStat         `json:"top_prefixes"`    // 按内存降序
+	HotKeys           []KeyStat            `json:"hot_keys,omitempty"`
+	MissingKeys       int64                `json:"missing_keys"` // 扫描后、分析前被删除或过期的键
+	Duration          time.Duration        `json:"duration"`
+	PrefixSeparator   string               `json:"prefix_separator"`
+	PrefixDepth       int                  `json:"prefix_depth"`
+	PrefixesTruncated bool                 `json:"prefixes_truncated,omitempty"` // 前缀数量超过上限，部分键未计入前缀统计
+}
+
+// JSON 以缩进格式输出报告
+func (r *KeyspaceReport) JSON() ([]byte, error) {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("序列化分析报告失败: %w", err)
+	}
+	return data, nil
+}
+
+// =============================================================================
+// 报告构建
+// =============================================================================
+
+// ReportOptions 报告构建配置
+type ReportOptions struct {
+	TopN            int    // 每个排行榜保留的数量，默认为 20
+	PrefixSeparator string // 键前缀分隔符，默认为 ":"
+	PrefixDepth     int    // 前缀包含的段数，默认为 1，如 cache:user:1 在深度 2 时的前缀为 cache:user
+	MaxPrefixes     int    // 最多统计的前缀数量，防止键名不规范时内存无限增长，默认为 10000
+}
+
+// KeyspaceReportBuilder 逐个接收 KeyStat 并维护 Top-N 与聚合统计，只占用 O(TopN + 前缀数) 的内存
+type KeyspaceReportBuilder struct {
+	opts       ReportOptions
+	report     *KeyspaceReport
+	byMemory   *keyStatHeap
+	byElements map[string]*keyStatHeap
+	byFreq     *keyStatHeap
+	prefixes   map[string]*PrefixStat
+}
+
+// NewKeyspaceReportBuilder 创建报告构建器
+// 参数：
+//   - opts: 构建配置，为 nil 时使用默认配置
+//
+// 返回：
+//   - *KeyspaceReportBuilder: 构建器实例
+func NewKeyspaceReportBuilder(opts *ReportOptions) *KeyspaceReportBuilder {
+	o := ReportOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.TopN <= 0 {
+		o.TopN = 20
+	}
+	if o.PrefixSeparator == "" {
+		o.PrefixSeparator = ":"
+	}
+	if o.PrefixDepth <= 0 {
+		o.PrefixDepth = 1
+	}
+	if o.MaxPrefixes <= 0 {
+		o.MaxPrefixes = 10000
+	}
+	return &KeyspaceReportBuilder{
+		opts: o,
+		report: &KeyspaceReport{
+			Types:           make(map[string]*TypeStat),
+			PrefixSeparator: o.PrefixSeparator,
+			PrefixDepth:     o.PrefixDepth,
+		},
+		byMemory:   newKeyStatHeap(o.TopN, func(s KeyStat) int64 { return s.Memory }),
+		byElements: make(map[string]*keyStatHeap),
+		byFreq:     newKeyStatHeap(o.TopN, func(s KeyStat) int64 { return s.Freq }),
+		prefixes:   make(map[string]*PrefixStat),
+	}
+}
+
+// Add 加入一个键的统计
+func (b *KeyspaceReportBuilder) Add(stat KeyStat) {
+	r := b.report
+	r.Keys++
+	r.TotalMemory += stat.Memory
+
+	ts, ok := r.Types[stat.Type]
+	if !ok {
+		ts = &TypeStat{}
+		r.Types[stat.Type] = ts
+	}
+	ts.Keys++
+	ts.Memory += stat.Memory
+	ts.Elements += stat.Elements
+
+	b.byMemory.offer(stat)
+	elems, ok := b.byElements[stat.Type]
+	if !ok {
+		elems = newKeyStatHeap(b.opts.TopN, func(s KeyStat) int64 { return s.Elements })
+		b.byElements[stat.Type] = elems
+	}
+	elems.offer(stat)
+	if stat.Freq > 0 {
+		b.byFreq.offer(stat)
+	}
+
+	prefix := KeyPrefix(stat.Key, b.opts.PrefixSeparator, b.opts.PrefixDepth)
+	ps, ok := b.prefixes[prefix]
+	if !ok {
+		if len(b.prefixes) >= b.opts.MaxPrefixes {
+			r.PrefixesTruncated = true
+			return
+		}
+		ps = &PrefixStat{Prefix: prefix}
+		b.prefixes[prefix] = ps
+	}
+	ps.Keys++
+	ps.Memory += stat.Memory
+	ps.Elements += stat.Elements
+}
+
+// AddMissing 记录一个在分析前已被删除的键
+func (b *KeyspaceReportBuilder) AddMissing() {
+	b.report.MissingKeys++
+}
+
+// Report 生成报告，可以在构建过程中多次调用获取阶段性结果
+func (b *KeyspaceReportBuilder) Report() *KeyspaceReport {
+	r := *b.report
+	r.GeneratedAt = time.Now()
+	r.Types = make(map[string]*TypeStat, len(b.report.Types))
+	for t, ts := range b.report.Types {
+		copied := *ts
+		r.Types[t] = &copied
+	}
+	r.TopByMemory = b.byMemory.sorted()
+	r.TopByElements = make(map[string][]KeyStat, len(b.byElements))
+	for t, h := range b.byElements {
+		r.TopByElements[t] = h.sorted()
+	}
+	r.HotKeys = b.byFreq.sorted()
+
+	prefixes := make([]PrefixStat, 0, len(b.prefixes))
+	for _, ps := range b.prefixes {
+		prefixes = append(prefixes, *ps)
+	}
+	sort.Slice(prefixes, func(i, j int) bool {
+		if prefixes[i].Memory != prefixes[j].Memory {
+			return prefixes[i].Memory > prefixes[j].Memory
+		}
+		return prefixes[i].Prefix < prefixes[j].Prefix
+	})
+	r.TopPrefixes = prefixes[:min(len(prefixes), b.opts.TopN)]
+	return &r
+}
+
+// KeyPrefix 返回键的前 depth 段，段数不足、depth 不大于 0 或分隔符为空时返回整个键
+func KeyPrefix(key, separator string, depth int) string {
+	if depth <= 0 || separator == "" {
+		return key
+	}
+	idx := 0
+	for range depth {
+		next := strings.Index(key[idx:], separator)
+		if next < 0 {
+			return key
+		}
+		idx += next + len(separator)
+	}
+	return key[:idx-len(separator)]
+}
+
+// keyStatHeap 保留指标最大的 n 个键的小顶堆
+type keyStatHeap struct {
+	n      int
+	metric func(KeyStat) int64
+	items  []KeyStat
+}
+
+func newKeyStatHeap(n int, metric func(KeyStat) int64) *keyStatHeap {
+	return &keyStatHeap{n: n, metric: metric}
+}
+
+func (h *keyStatHeap) Len() int { return len(h.items) }
+func (h *keyStatHeap) Less(i, j int) bool {
+	mi, mj := h.metric(h.items[i]), h.metric(h.items[j])
+	if mi != mj {
+		return mi < mj
+	}
+	return h.items[i].Key > h.items[j].Key
+}
+func (h *keyStatHeap) Swap(i, j int)      { h.items[i], h.items[j] = h.items[j], h.items[i] }
+func (h *keyStatHeap) Push(x interface{}) { h.items = append(h.items, x.(KeyStat)) }
+func (h *keyStatHeap) Pop() interface{} {
+	last := h.items[len(h.items)-1]
+	h.items = h.items[:len(h.items)-1]
+	return last
+}
+
+// offer 加入候选键，堆满时替换掉指标最小的键
+func (h *keyStatHeap) offer(stat KeyStat) {
+	if len(h.items) < h.n {
+		heap.Push(h, stat)
+		return
+	}
+	top := h.items[0]
+	if h.metric(stat) > h.metric(top) || (h.metric(stat) == h.metric(top) && stat.Key < top.Key) {
+		h.items[0] = stat
+		heap.Fix(h, 0)
+	}
+}
+
+// sorted 返回按指标降序排列的副本
+func (h *keyStatHeap) sorted() []KeyStat {
+	items := make([]KeyStat, len(h.items))
+	copy(items, h.items)
+	sort.Slice(items, func(i, j int) bool {
+		mi, mj := h.metric(items[i]), h.metric(items[j])
+		if mi != mj {
+			return mi > mj
+		}
+		return items[i].Key < items[j].Key
+	})
+	return items
+}
+
+// =============================================================================
+// 在线分析
+// =============================================================================
+
+// AnalyzerOptions 键空间分析配置
+type AnalyzerOptions struct {
+	Match            string // 只分析匹配的键，为空时分析全部键
+	Type             string // 只分析指定类型的键
+	BatchSize        int    // 每批查询的键数量，默认为 100
+	MemorySamples    int    // MEMORY USAGE 的 SAMPLES 参数，0 使用服务端默认值（5），-1 表示统计全部元素
+	HotKeys          bool   // 是否查询 OBJECT FREQ 统计热键，需要 LFU 淘汰策略
+	MaxKeysPerSecond int    // 每秒最多分析的键数量，0 表示不限速
+	Report           *ReportOptions
+	OnProgress       func(analyzed int64)
+}
+
+// AnalyzeKeyspace 扫描键空间并生成大键/热键报告
+// 参数：
+//   - ctx: 上下文，取消时返回已分析部分的报告与 ctx.Err()
+//   - opts: 分析配置，为 nil 时使用默认配置
+//
+// 返回：
+//   - *KeyspaceReport: 分析报告
+//   - error: 扫描或查询失败时返回错误，此时报告只包含已分析的部分
+func (r *RedisManager) AnalyzeKeyspace(ctx context.Context, opts *AnalyzerOptions) (*KeyspaceReport, error) {
+	o := AnalyzerOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.BatchSize <= 0 {
+		o.BatchSize = 100
+	}
+
+	builder := NewKeyspaceReportBuilder(o.Report)
+	start := time.Now()
+	var analyzed int64
+	finish := func(err error) (*KeyspaceReport, error) {
+		report := builder.Report()
+		report.Duration = time.Since(start)
+		return report, err
+	}
+
+	batch := make([]string, 0, o.BatchSize)
+	flush := func() error {
+		stats, err := r.analyzeBatch(ctx, batch, o)
+		if err != nil {
+			return err
+		}
+		for _, stat := range stats {
+			if stat == nil {
+				builder.AddMissing()
+				continue
+			}
+			builder.Add(*stat)
+		}
+		analyzed += int64(len(batch))
+		batch = batch[:0]
+		if o.OnProgress != nil {
+			o.OnProgress(analyzed)
+		}
+		return throttle(ctx, start, analyzed, o.MaxKeysPerSecond)
+	}
+
+	for key, err := range r.Scan(ctx, &ScanOptions{Match: o.Match, Type: o.Type, Count: int64(o.BatchSize)}) {
+		if err != nil {
+			return finish(err)
+		}
+		batch = append(batch, key)
+		if len(batch) == o.BatchSize {
+			if err := flush(); err != nil {
+				return finish(err)
+			}
+		}
+	}
+	if len(batch) > 0 {
+		if err := flush(); err != nil {
+			return finish(err)
+		}
+	}
+	return finish(nil)
+}
+
+// analyzeBatch 查询一批键的统计信息，已不存在的键对应位置为 nil
+// 第一轮 Pipeline 查询类型、内存与访问频率，第二轮按类型查询元素数量
+func (r *RedisManager) analyzeBatch(ctx context.Context, keys []string, o AnalyzerOptions) ([]*KeyStat, error) {
+	var samples []int
+	if o.MemorySamples != 0 {
+		samples = []int{max(o.MemorySamples, 0)}
+	}
+
+	types := make([]*redis.StatusCmd, len(keys))
+	mems := make([]*redis.IntCmd, len(keys))
+	freqs := make([]*redis.IntCmd, len(keys))
+	// Pipeline 的错误记录在各条命令上，下面逐条检查
+	_, _ = r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, key := range keys {
+			types[i] = pipe.Type(ctx, key)
+			mems[i] = pipe.MemoryUsage(ctx, key, samples...)
+			if o.HotKeys {
+				freqs[i] = pipe.ObjectFreq(ctx, key)
+			}
+		}
+		return nil
+	})
+
+	stats := make([]*KeyStat, len(keys))
+	for i, key := range keys {
+		keyType, err := types[i].Result()
+		if err != nil {
+			return nil, fmt.Errorf("查询键 %s 的类型失败: %w", key, err)
+		}
+		if keyType == "none" {
+			continue
+		}
+		stat := &KeyStat{Key: key, Type: keyType}
+		// 键在两条命令之间被删除时 MEMORY USAGE 返回 nil
+		if mem, err := mems[i].Result(); err == nil {
+			stat.Memory = mem
+		} else if !errors.Is(err, redis.Nil) {
+			return nil, fmt.Errorf("查询键 %s 的内存占用失败: %w", key, err)
+		}
+		if o.HotKeys {
+			freq, err := freqs[i].Result()
+			if err != nil && !errors.Is(err, redis.Nil) {
+				return nil, fmt.Errorf("查询键 %s 的访问频率失败（需要 LFU 淘汰策略）: %w", key, err)
+			}
+			stat.Freq = freq
+		}
+		stats[i] = stat
+	}
+
+	counts := make([]*redis.IntCmd, len(keys))
+	_, _ = r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, stat := range stats {
+			if stat != nil {
+				counts[i] = elementCount(ctx, pipe, stat.Key, stat.Type)
+			}
+		}
+		return nil
+	})
+	for i, cmd := range counts {
+		if cmd == nil {
+			continue
+		}
+		// 键在两轮查询之间被删除或改为其他类型时只保留内存信息
+		n, err := cmd.Result()
+		if err != nil && !isWrongType(err) {
+			return nil, fmt.Errorf("查询键 %s 的元素数量失败: %w", stats[i].Key, err)
+		}
+		stats[i].Elements = n
+	}
+	return stats, nil
+}
+
+// elementCount 按类型追加查询元素数量的命令，未知类型返回 nil
+func elementCount(ctx context.Context, pipe redis.Pipeliner, key, keyType string) *redis.IntCmd {
+	switch keyType {
+	case "string":
+		return pipe.StrLen(ctx, key)
+	case "hash":
+		return pipe.HLen(ctx, key)
+	case "list":
+		return pipe.LLen(ctx, key)
+	case "set":
+		return pipe.SCard(ctx, key)
+	case "zset":
+		return pipe.ZCard(ctx, key)
+	case "stream":
+		return pipe.XLen(ctx, key)
+	}
+	return nil
+}
+
+// isWrongType 判断是否为类型不匹配错误
+func isWrongType(err error) bool {
+	return strings.HasPrefix(err.Error(), "WRONGTYPE")
+}
@@ -0,0 +1,747 @@
+package redis
+
+// 自动批处理客户端
+//
+// 场景说明：
+//   HTTP 处理函数通常在大量 goroutine 中各自调用 Get/Set，每次调用都是一次独立的网络往返。
+//   AutoBatchClient 实现 internal.RedisOperator 接口，将并发的单键调用在一个很短的时间窗口内
+//   （或达到批大小上限时）合并为一个 Pipeline 发送，再把结果分发回各个调用方。
+//   在高并发场景下可显著减少网络往返次数与系统调用，提高吞吐量；代价是单次调用最多增加 MaxDelay 的延迟。
+//
+// 注意：
+//   管理器开启 ReadFromReplicas 时，只读命令与 RedisManager 一样发往从节点：同一批次中的读写命令
+//   分成两个 Pipeline，分别发往从节点与主节点，因此刚写入的数据不一定能立即读到。
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/yann0917/redis-usage/internal"
+)
+
+// 编译期检查 AutoBatchClient 实现了 RedisOperator 接口
+var _ internal.RedisOperator = (*AutoBatchClient)(nil)
+
+// ErrAutoBatchClosed 客户端已关闭
+var ErrAutoBatchClosed = errors.New("自动批处理客户端已关闭")
+
+// AutoBatchOptions 自动批处理配置
+type AutoBatchOptions struct {
+	MaxBatchSize int           // 单个批次的最大命令数，达到后立即发送，默认为 100
+	MaxDelay     time.Duration // 有批次正在执行时，收到首个命令后的最长等待时间，默认为 500 微秒
+	QueueSize    int           // 待发送队列容量，默认为 MaxBatchSize 的 10 倍
+}
+
+// AutoBatchStats 自动批处理运行指标
+type AutoBatchStats struct {
+	Batches         int64         // 已发送的批次数
+	Commands        int64         // 已发送的命令数
+	AvgBatchSize    float64       // 平均批大小
+	MaxBatchSize    int64         // 最大批大小
+	AvgFlushLatency time.Duration // 平均每批 Pipeline 执行耗时
+	MaxFlushLatency time.Duration // 最大每批 Pipeline 执行耗时
+}
+
+// batchRequest 等待合并发送的单个调用
+type batchRequest struct {
+	ctx  context.Context
+	op   BatchOperation
+	read bool // 只读命令，读写分离时发往从节点
+	done chan BatchResult
+}
+
+// AutoBatchClient 自动批处理客户端
+type AutoBatchClient struct {
+	manager *RedisManager
+	opts    AutoBatchOptions
+
+	queue   chan *batchRequest
+	closing chan struct{}
+
+	mu      sync.RWMutex // 保护 closed，Close 时等待所有入队操作完成
+	closed  bool
+	loopWG  sync.WaitGroup
+	flushWG sync.WaitGroup
+
+	inflight atomic.Int64 // 正在执行的批次数
+
+	batches      atomic.Int64
+	commands     atomic.Int64
+	maxBatch     atomic.Int64
+	totalLatency atomic.Int64
+	maxLatency   atomic.Int64
+}
+
+// NewAutoBatchClient 创建自动批处理客户端
+// 参数：
+//   - manager: Redis 管理器，客户端关闭时一并关闭
+//   - opts: 批处理配置，为 nil 时使用默认配置
+//
+// 返回：
+//   - *AutoBatchClient: 自动批处理客户端实例
+func NewAutoBatchClient(manager *RedisManager, opts *AutoBatchOptions) *AutoBatchClient {
+	o := AutoBatchOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.MaxBatchSize <= 0 {
+		o.MaxBatchSize = 100
+	}
+	if o.MaxDelay <= 0 {
+		o.MaxDelay = 500 * time.Microsecond
+	}
+	if o.QueueSize <= 0 {
+		o.QueueSize = o.MaxBatchSize * 10
+	}
+
+	c := &AutoBatchClient{
+		manager: manager,
+		opts:    o,
+		queue:   make(chan *batchRequest, o.QueueSize),
+		closing: make(chan struct{}),
+	}
+	c.loopWG.Add(1)
+	go c.loop()
+	return c
+}
+
+// Stats 返回当前运行指标快照
+func (c *AutoBatchClient) Stats() AutoBatchStats {
+	stats := AutoBatchStats{
+		Batches:         c.batches.Load(),
+		Commands:        c.commands.Load(),
+		MaxBatchSize:    c.maxBatch.Load(),
+		MaxFlushLatency: time.Duration(c.maxLatency.Load()),
+	}
+	if stats.Batches > 0 {
+		stats.AvgBatchSize = float64(stats.Commands) / float64(stats.Batches)
+		stats.AvgFlushLatency = time.Duration(c.totalLatency.Load() / stats.Batches)
+	}
+	return stats
+}
+
+// loop 收集请求并按窗口或批大小触发发送
+func (c *AutoBatchClient) loop() {
+	defer c.loopWG.Done()
+
+	for {
+		var first *batchRequest
+		select {
+		case first = <-c.queue:
+		case <-c.closing:
+			c.drain()
+			return
+		}
+
+		batch := []*batchRequest{first}
+		timer := time.NewTimer(c.opts.MaxDelay)
+	collect:
+		for len(batch) < c.opts.MaxBatchSize {
+			// 先非阻塞地取走已排队的请求
+			select {
+			case req := <-c.queue:
+				batch = append(batch, req)
+				continue
+			default:
+			}
+
+			// 队列已空且没有进行中的批次时立即发送，避免空等时间窗口（类似 Nagle 算法）
+			if c.inflight.Load() == 0 {
+				break collect
+			}
+
+			select {
+			case req := <-c.queue:
+				batch = append(batch, req)
+			case <-timer.C:
+				break collect
+			case <-c.closing:
+				break collect
+			}
+		}
+		timer.Stop()
+
+		// 每个批次在独立 goroutine 中发送，避免慢批次阻塞后续收集
+		c.flushWG.Add(1)
+		c.inflight.Add(1)
+		go c.flush(batch)
+	}
+}
+
+// drain 关闭时发送队列中剩余的请求
+func (c *AutoBatchClient) drain() {
+	for {
+		batch := make([]*batchRequest, 0, c.opts.MaxBatchSize)
+	fill:
+		for len(batch) < c.opts.MaxBatchSize {
+			select {
+			case req := <-c.queue:
+				batch = append(batch, req)
+			default:
+				break fill
+			}
+		}
+		if len(batch) == 0 {
+			return
+		}
+		c.flushWG.Add(1)
+		c.inflight.Add(1)
+		c.flush(batch)
+	}
+}
+
+// flush 将一个批次作为 Pipeline 发送，并把结果分发给调用方
+func (c *AutoBatchClient) flush(batch []*batchRequest) {
+	defer c.flushWG.Done()
+	defer c.inflight.Add(-1)
+
+	// 跳过调用方已放弃等待的请求
+	pending := batch[:0]
+	for _, req := range batch {
+		if err := req.ctx.Err(); err != nil {
+			req.done <- BatchResult{Err: err}
+			continue
+		}
+		pending = append(pending, req)
+	}
+	if len(pending) == 0 {
+		return
+	}
+
+	// 读写分离时只读命令单独组成一个 Pipeline 发往从节点，其余模式整个批次使用同一个 Pipeline
+	split := c.manager.replica != nil
+	var writes, reads []*batchRequest
+	for _, req := range pending {
+		if split && req.read {
+			reads = append(reads, req)
+		} else {
+			writes = append(writes, req)
+		}
+	}
+
+	// 批次内的请求来自不同调用方，Pipeline 使用独立上下文，超时由客户端读写超时控制
+	start := time.Now()
+	c.exec(c.manager.client, writes)
+	c.exec(c.manager.reader(), reads)
+	c.record(len(pending), time.Since(start))
+}
+
+// exec 在指定客户端上通过一个 Pipeline 执行请求，并把结果分发给调用方
+func (c *AutoBatchClient) exec(client redis.UniversalClient, reqs []*batchRequest) {
+	if len(reqs) == 0 {
+		return
+	}
+	ops := make([]BatchOperation, len(reqs))
+	for i, req := range reqs {
+		ops[i] = req.op
+	}
+	results := make([]BatchResult, len(reqs))
+	execPipeline(context.Background(), client, ops, results, 0)
+	for i, req := range reqs {
+		req.done <- results[i]
+	}
+}
+
+// record 更新运行指标
+func (c *AutoBatchClient) record(size int, latency time.Duration) {
+	c.batches.Add(1)
+	c.commands.Add(int64(size))
+	c.totalLatency.Add(int64(latency))
+	storeMax(&c.maxBatch, int64(size))
+	storeMax(&c.maxLatency, int64(latency))
+}
+
+// storeMax 原子地更新最大值
+func storeMax(v *atomic.Int64, n int64) {
+	for {
+		cur := v.Load()
+		if n <= cur || v.CompareAndSwap(cur, n) {
+			return
+		}
+	}
+}
+
+// do 提交一个操作并等待其所在批次执行完成，read 表示只读命令
+func (c *AutoBatchClient) do(ctx context.Context, read bool, op BatchOperation) (redis.Cmder, error) {
+	req := &batchRequest{ctx: ctx, op: op, read: read, done: make(chan BatchResult, 1)}
+
+	c.mu.RLock()
+	if c.closed {
+		c.mu.RUnlock()
+		return nil, ErrAutoBatchClosed
+	}
+	select {
+	case c.queue <- req:
+	case <-ctx.Done():
+		c.mu.RUnlock()
+		return nil, ctx.Err()
+	}
+	c.mu.RUnlock()
+
+	select {
+	case res := <-req.done:
+		return res.Cmd, res.Err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// spansSlots 判断集群模式下多键命令的键是否分布在多个槽位，此时单条命令会返回 CROSSSLOT
+func (c *AutoBatchClient) spansSlots(keys []string) bool {
+	return len(keys) > 1 && c.manager.clusterClient() != nil && len(GroupKeysBySlot(keys)) > 1
+}
+
+// autoBatchDo 提交写命令并返回具体类型的命令对象
+func autoBatchDo[T redis.Cmder](ctx context.Context, c *AutoBatchClient, op func(ctx context.Context, pipe redis.Pipeliner) T) (T, error) {
+	return autoBatchSubmit(ctx, c, false, op)
+}
+
+// autoBatchRead 提交只读命令并返回具体类型的命令对象，读写分离时发往从节点
+func autoBatchRead[T redis.Cmder](ctx context.Context, c *AutoBatchClient, op func(ctx context.Context, pipe redis.Pipeliner) T) (T, error) {
+	return autoBatchSubmit(ctx, c, true, op)
+}
+
+// autoBatchSubmit 提交操作并返回具体类型的命令对象
+func autoBatchSubmit[T redis.Cmder](ctx context.Context, c *AutoBatchClient, read bool, op func(ctx context.Context, pipe redis.Pipeliner) T) (T, error) {
+	cmd, err := c.do(ctx, read, func(ctx context.Context, pipe redis.Pipeliner) redis.Cmder {
+		return op(ctx, pipe)
+	})
+	typed, _ := cmd.(T)
+	return typed, err
+}
+
+// =============================================================================
+// 连接管理方法（不参与批处理）
+// =============================================================================
+
+// Ping 测试 Redis 连接是否正常
+func (c *AutoBatchClient) Ping(ctx context.Context) error {
+	return c.manager.Ping(ctx)
+}
+
+// Close 发送队列中剩余的请求，停止批处理并关闭底层 Redis 管理器
+func (c *AutoBatchClient) Close() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	close(c.closing)
+	c.mu.Unlock()
+
+	c.loopWG.Wait()
+	c.flushWG.Wait()
+	return c.manager.Close()
+}
+
+// Info 获取 Redis 服务器信息
+func (c *AutoBatchClient) Info(ctx context.Context) (map[string]string, error) {
+	return c.manager.Info(ctx)
+}
+
+// FlushDB 清空当前数据库的所有数据（谨慎使用！）
+func (c *AutoBatchClient) FlushDB(ctx context.Context) error {
+	return c.manager.FlushDB(ctx)
+}
+
+// =============================================================================
+// 字符串操作方法
+// =============================================================================
+
+// Set 设置字符串键值对，支持过期时间
+func (c *AutoBatchClient) Set(ctx context.Context, key, value string, expiration time.Duration) error {
+	_, err := autoBatchDo(ctx, c, func(ctx context.Context, pipe redis.Pipeliner) *redis.StatusCmd {
+		return pipe.Set(ctx, key, value, expiration)
+	})
+	if err != nil {
+		return fmt.Errorf("设置键 %s 失败: %w", key, err)
+	}
+	return nil
+}
+
+// SetNX 仅在键不存在时设置字符串键值对
+func (c *AutoBatchClient) SetNX(ctx context.Context, key, value string, expiration time.Duration) (bool, error) {
+	cmd, err := autoBatchDo(ctx, c, func(ctx context.Context, pipe redis.Pipeliner) *redis.BoolCmd {
+		return pipe.SetNX(ctx, key, value, expiration)
+	})
+	if err != nil {
+		return false, fmt.Errorf("SetNX 键 %s 失败: %w", key, err)
+	}
+	return cmd.Val(), nil
+}
+
+// SetXX 仅在键存在时设置字符串键值对
+func (c *AutoBatchClient) SetXX(ctx context.Context, key, value string, expiration time.Duration) (bool, error) {
+	cmd, err := autoBatchDo(ctx, c, func(ctx context.Context, pipe redis.Pipeliner) *redis.BoolCmd {
+		return pipe.SetXX(ctx, key, value, expiration)
+	})
+	if err != nil {
+		return false, fmt.Errorf("SetXX 键 %s 失败: %w", key, err)
+	}
+	return cmd.Val(), nil
+}
+
+// Get 获取字符串值
+func (c *AutoBatchClient) Get(ctx context.Context, key string) (string, error) {
+	cmd, err := autoBatchRead(ctx, c, func(ctx context.Context, pipe redis.Pipeliner) *redis.StringCmd {
+		return pipe.Get(ctx, key)
+	})
+	if err != nil {
+		if err == redis.Nil {
+			return "", fmt.Errorf("键 %s 不存在", key)
+		}
+		return "", fmt.Errorf("获取键 %s 失败: %w", key, err)
+	}
+	return cmd.Val(), nil
+}
+
+// Incr 原子性地增加键的整数值
+func (c *AutoBatchClient) Incr(ctx context.Context, key string) (int64, error) {
+	cmd, err := autoBatchDo(ctx, c, func(ctx context.Context, pipe redis.Pipeliner) *redis.IntCmd {
+		return pipe.Incr(ctx, key)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("增加键 %s 失败: %w", key, err)
+	}
+	return cmd.Val(), nil
+}
+
+// =============================================================================
+// 哈希操作方法
+// =============================================================================
+
+// HSet 设置哈希字段的值
+func (c *AutoBatchClient) HSet(ctx context.Context, key, field, value string) error {
+	_, err := autoBatchDo(ctx, c, func(ctx context.Context, pipe redis.Pipeliner) *redis.IntCmd {
+		return pipe.HSet(ctx, key, field, value)
+	})
+	if err != nil {
+		return fmt.Errorf("设置哈希 %s 字段 %s 失败: %w", key, field, err)
+	}
+	return nil
+}
+
+// HGet 获取哈希字段的值
+func (c *AutoBatchClient) HGet(ctx context.Context, key, field string) (string, error) {
+	cmd, err := autoBatchRead(ctx, c, func(ctx context.Context, pipe redis.Pipeliner) *redis.StringCmd {
+		return pipe.HGet(ctx, key, field)
+	})
+	if err != nil {
+		if err == redis.Nil {
+			return "", fmt.Errorf("哈希 %s 字段 %s 不存在", key, field)
+		}
+		return "", fmt.Errorf("获取哈希 %s 字段 %s 失败: %w", key, field, err)
+	}
+	return cmd.Val(), nil
+}
+
+// HMSet 批量设置哈希字段
+func (c *AutoBatchClient) HMSet(ctx context.Context, key string, fields map[string]interface{}) error {
+	_, err := autoBatchDo(ctx, c, func(ctx context.Context, pipe redis.Pipeliner) *redis.BoolCmd {
+		return pipe.HMSet(ctx, key, fields)
+	})
+	if err != nil {
+		return fmt.Errorf("批量设置哈希 %s 失败: %w", key, err)
+	}
+	return nil
+}
+
+// HMGet 批量获取哈希字段的值
+func (c *AutoBatchClient) HMGet(ctx context.Context, key string, fields ...string) ([]interface{}, error) {
+	cmd, err := autoBatchRead(ctx, c, func(ctx context.Context, pipe redis.Pipeliner) *redis.SliceCmd {
+		return pipe.HMGet(ctx, key, fields...)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("批量获取哈希 %s 字段失败: %w", key, err)
+	}
+	return cmd.Val(), nil
+}
+
+// HGetAll 获取哈希的所有字段和值
+func (c *AutoBatchClient) HGetAll(ctx context.Context, key string) (map[string]string, error) {
+	cmd, err := autoBatchRead(ctx, c, func(ctx context.Context, pipe redis.Pipeliner) *redis.MapStringStringCmd {
+		return pipe.HGetAll(ctx, key)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("获取哈希 %s 所有字段失败: %w", key, err)
+	}
+	return cmd.Val(), nil
+}
+
+// HDel 删除哈希字段
+func (c *AutoBatchClient) HDel(ctx context.Context, key string, fields ...string) error {
+	_, err := autoBatchDo(ctx, c, func(ctx context.Context, pipe redis.Pipeliner) *redis.IntCmd {
+		return pipe.HDel(ctx, key, fields...)
+	})
+	if err != nil {
+		return fmt.Errorf("删除哈希 %s 字段失败: %w", key, err)
+	}
+	return nil
+}
+
+// =============================================================================
+// 列表操作方法
+// =============================================================================
+
+// LPush 从列表左侧推入元素
+func (c *AutoBatchClient) LPush(ctx context.Context, key string, values ...interface{}) error {
+	_, err := autoBatchDo(ctx, c, func(ctx context.Context, pipe redis.Pipeliner) *redis.IntCmd {
+		return pipe.LPush(ctx, key, values...)
+	})
+	if err != nil {
+		return fmt.Errorf("左推入列表 %s 失败: %w", key, err)
+	}
+	return nil
+}
+
+// RPush 从列表右侧推入元素
+func (c *AutoBatchClient) RPush(ctx context.Context, key string, values ...interface{}) error {
+	_, err := autoBatchDo(ctx, c, func(ctx context.Context, pipe redis.Pipeliner) *redis.IntCmd {
+		return pipe.RPush(ctx, key, values...)
+	})
+	if err != nil {
+		return fmt.Errorf("右推入列表 %s 失败: %w", key, err)
+	}
+	return nil
+}
+
+// LPop 从列表左侧弹出元素
+func (c *AutoBatchClient) LPop(ctx context.Context, key string) (string, error) {
+	cmd, err := autoBatchDo(ctx, c, func(ctx context.Context, pipe redis.Pipeliner) *redis.StringCmd {
+		return pipe.LPop(ctx, key)
+	})
+	if err != nil {
+		if err == redis.Nil {
+			return "", fmt.Errorf("列表 %s 为空", key)
+		}
+		return "", fmt.Errorf("左弹出列表 %s 失败: %w", key, err)
+	}
+	return cmd.Val(), nil
+}
+
+// RPop 从列表右侧弹出元素
+func (c *AutoBatchClient) RPop(ctx context.Context, key string) (string, error) {
+	cmd, err := autoBatchDo(ctx, c, func(ctx context.Context, pipe redis.Pipeliner) *redis.StringCmd {
+		return pipe.RPop(ctx, key)
+	})
+	if err != nil {
+		if err == redis.Nil {
+			return "", fmt.Errorf("列表 %s 为空", key)
+		}
+		return "", fmt.Errorf("右弹出列表 %s 失败: %w", key, err)
+	}
+	return cmd.Val(), nil
+}
+
+// LRange 获取列表指定范围的元素
+func (c *AutoBatchClient) LRange(ctx context.Context, key string, start, stop int64) ([]string, error) {
+	cmd, err := autoBatchRead(ctx, c, func(ctx context.Context, pipe redis.Pipeliner) *redis.StringSliceCmd {
+		return pipe.LRange(ctx, key, start, stop)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("获取列表 %s 范围失败: %w", key, err)
+	}
+	return cmd.Val(), nil
+}
+
+// LLen 获取列表长度
+func (c *AutoBatchClient) LLen(ctx context.Context, key string) (int64, error) {
+	cmd, err := autoBatchRead(ctx, c, func(ctx context.Context, pipe redis.Pipeliner) *redis.IntCmd {
+		return pipe.LLen(ctx, key)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("获取列表 %s 长度失败: %w", key, err)
+	}
+	return cmd.Val(), nil
+}
+
+// =============================================================================
+// 集合操作方法
+// =============================================================================
+
+// SAdd 向集合添加成员
+func (c *AutoBatchClient) SAdd(ctx context.Context, key string, members ...interface{}) error {
+	_, err := autoBatchDo(ctx, c, func(ctx context.Context, pipe redis.Pipeliner) *redis.IntCmd {
+		return pipe.SAdd(ctx, key, members...)
+	})
+	if err != nil {
+		return fmt.Errorf("向集合 %s 添加成员失败: %w", key, err)
+	}
+	return nil
+}
+
+// SRem 从集合移除成员
+func (c *AutoBatchClient) SRem(ctx context.Context, key string, members ...interface{}) error {
+	_, err := autoBatchDo(ctx, c, func(ctx context.Context, pipe redis.Pipeliner) *redis.IntCmd {
+		return pipe.SRem(ctx, key, members...)
+	})
+	if err != nil {
+		return fmt.Errorf("从集合 %s 移除成员失败: %w", key, err)
+	}
+	return nil
+}
+
+// SIsMember 检查成员是否在集合中
+func (c *AutoBatchClient) SIsMember(ctx context.Context, key string, member interface{}) (bool, error) {
+	cmd, err := autoBatchRead(ctx, c, func(ctx context.Context, pipe redis.Pipeliner) *redis.BoolCmd {
+		return pipe.SIsMember(ctx, key, member)
+	})
+	if err != nil {
+		return false, fmt.Errorf("检查集合 %s 成员失败: %w", key, err)
+	}
+	return cmd.Val(), nil
+}
+
+// SMembers 获取集合的所有成员
+func (c *AutoBatchClient) SMembers(ctx context.Context, key string) ([]string, error) {
+	cmd, err := autoBatchRead(ctx, c, func(ctx context.Context, pipe redis.Pipeliner) *redis.StringSliceCmd {
+		return pipe.SMembers(ctx, key)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("获取集合 %s 成员失败: %w", key, err)
+	}
+	return cmd.Val(), nil
+}
+
+// SCard 获取集合成员数量
+func (c *AutoBatchClient) SCard(ctx context.Context, key string) (int64, error) {
+	cmd, err := autoBatchRead(ctx, c, func(ctx context.Context, pipe redis.Pipeliner) *redis.IntCmd {
+		return pipe.SCard(ctx, key)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("获取集合 %s 成员数量失败: %w", key, err)
+	}
+	return cmd.Val(), nil
+}
+
+// =============================================================================
+// 有序集合操作方法
+// =============================================================================
+
+// ZAdd 向有序集合添加成员
+func (c *AutoBatchClient) ZAdd(ctx context.Context, key string, members ...redis.Z) error {
+	_, err := autoBatchDo(ctx, c, func(ctx context.Context, pipe redis.Pipeliner) *redis.IntCmd {
+		return pipe.ZAdd(ctx, key, members...)
+	})
+	if err != nil {
+		return fmt.Errorf("向有序集合 %s 添加成员失败: %w", key, err)
+	}
+	return nil
+}
+
+// ZRem 从有序集合移除成员
+func (c *AutoBatchClient) ZRem(ctx context.Context, key string, members ...interface{}) error {
+	_, err := autoBatchDo(ctx, c, func(ctx context.Context, pipe redis.Pipeliner) *redis.IntCmd {
+		return pipe.ZRem(ctx, key, members...)
+	})
+	if err != nil {
+		return fmt.Errorf("从有序集合 %s 移除成员失败: %w", key, err)
+	}
+	return nil
+}
+
+// ZRange 按排名范围获取有序集合成员
+func (c *AutoBatchClient) ZRange(ctx context.Context, key string, start, stop int64) ([]string, error) {
+	cmd, err := autoBatchRead(ctx, c, func(ctx context.Context, pipe redis.Pipeliner) *redis.StringSliceCmd {
+		return pipe.ZRange(ctx, key, start, stop)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("获取有序集合 %s 排名范围失败: %w", key, err)
+	}
+	return cmd.Val(), nil
+}
+
+// ZRangeByScore 按分数范围获取有序集合成员
+func (c *AutoBatchClient) ZRangeByScore(ctx context.Context, key string, min, max string) ([]string, error) {
+	cmd, err := autoBatchRead(ctx, c, func(ctx context.Context, pipe redis.Pipeliner) *redis.StringSliceCmd {
+		return pipe.ZRangeByScore(ctx, key, &redis.ZRangeBy{Min: min, Max: max})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("获取有序集合 %s 分数范围失败: %w", key, err)
+	}
+	return cmd.Val(), nil
+}
+
+// ZCard 获取有序集合成员数量
+func (c *AutoBatchClient) ZCard(ctx context.Context, key string) (int64, error) {
+	cmd, err := autoBatchRead(ctx, c, func(ctx context.Context, pipe redis.Pipeliner) *redis.IntCmd {
+		return pipe.ZCard(ctx, key)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("获取有序集合 %s 成员数量失败: %w", key, err)
+	}
+	return cmd.Val(), nil
+}
+
+// =============================================================================
+// 键操作方法
+// =============================================================================
+
+// Del 删除一个或多个键
+// 集群模式下键分布在多个槽位时不参与批处理，交由管理器按槽位拆分执行
+func (c *AutoBatchClient) Del(ctx context.Context, keys ...string) error {
+	if c.spansSlots(keys) {
+		return c.manager.Del(ctx, keys...)
+	}
+	_, err := autoBatchDo(ctx, c, func(ctx context.Context, pipe redis.Pipeliner) *redis.IntCmd {
+		return pipe.Del(ctx, keys...)
+	})
+	if err != nil {
+		return fmt.Errorf("删除键失败: %w", err)
+	}
+	return nil
+}
+
+// Exists 检查键是否存在
+// 集群模式下键分布在多个槽位时不参与批处理，交由管理器按槽位拆分执行
+func (c *AutoBatchClient) Exists(ctx context.Context, keys ...string) (int64, error) {
+	if c.spansSlots(keys) {
+		return c.manager.Exists(ctx, keys...)
+	}
+	cmd, err := autoBatchRead(ctx, c, func(ctx context.Context, pipe redis.Pipeliner) *redis.IntCmd {
+		return pipe.Exists(ctx, keys...)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("检查键存在性失败: %w", err)
+	}
+	return cmd.Val(), nil
+}
+
+// Expire 设置键的过期时间
+func (c *AutoBatchClient) Expire(ctx context.Context, key string, expiration time.Duration) error {
+	_, err := autoBatchDo(ctx, c, func(ctx context.Context, pipe redis.Pipeliner) *redis.BoolCmd {
+		return pipe.Expire(ctx, key, expiration)
+	})
+	if err != nil {
+		return fmt.Errorf("设置键 %s 过期时间失败: %w", key, err)
+	}
+	return nil
+}
+
+// TTL 获取键的剩余生存时间
+func (c *AutoBatchClient) TTL(ctx context.Context, key string) (time.Duration, error) {
+	cmd, err := autoBatchRead(ctx, c, func(ctx context.Context, pipe redis.Pipeliner) *redis.DurationCmd {
+		return pipe.TTL(ctx, key)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("获取键 %s TTL 失败: %w", key, err)
+	}
+	return cmd.Val(), nil
+}
+
+// Type 获取键的数据类型
+func (c *AutoBatchClient) Type(ctx context.Context, key string) (string, error) {
+	cmd, err := autoBatchRead(ctx, c, func(ctx context.Context, pipe redis.Pipeliner) *redis.StatusCmd {
+		return pipe.Type(ctx, key)
+	})
+	if err != nil {
+		return "", fmt.Errorf("获取键 %s 类型失败: %w", key, err)
+	}
+	return cmd.Val(), nil
+}
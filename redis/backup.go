@@ -0,0 +1,582 @@
+package redis
+
+// 基于 DUMP/RESTORE 的逻辑备份与恢复
+//
+// 场景说明：
+//   RDB/AOF 是整个实例的物理备份，无法只备份某个业务前缀的键，也无法跨版本、跨集群拓扑恢复到另一个实例。
+//   ExportKeys 用 SCAN 遍历匹配的键，每批通过 Pipeline 执行 DUMP 与 PTTL，把键名、剩余过期时间和序列化值
+//   逐条写入备份流；ImportKeys 逐条读取并通过 Pipeline 执行 RESTORE。读写两端都是流式的，
+//   导出数 GB 的数据也只占用一批键的内存。
+//
+//   备份文件格式（整数均为大端序）：
+//
+//     文件头：  "RDSBAKUP" | 版本 uint16 | 导出时间 int64（Unix 毫秒）
+//     记录帧：  类型 byte | 长度 uint32 | 内容 | CRC32-C uint32（覆盖类型、长度与内容）
+//     键记录：  类型 'K'，内容为 uvarint 键长 | 键 | varint 剩余毫秒（0 表示不过期）| uvarint 值长 | DUMP 值
+//     结束记录：类型 'E'，内容为 uvarint 键数量
+//
+//   每条记录独立校验，读取时能定位到损坏的记录；缺少结束记录说明文件被截断（如导出中途失败或磁盘写满）。
+//
+// 注意：
+//   - DUMP 的序列化格式带有 RDB 版本号，只能恢复到相同或更高版本的 Redis；
+//   - DUMP 与 PTTL 不是原子执行的，导出期间被修改的键以各自执行时的状态为准，备份不是一致性快照；
+//   - 记录的是导出时的剩余过期时间，恢复时默认从恢复时刻重新计时，设置 AdjustTTL 可扣除导出后经过的时间；
+//   - 不设置 Replace 时目标已存在的键会被跳过（BUSYKEY），不会覆盖。
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	backupMagic          = "RDSBAKUP"
+	BackupFormatVersion  = 1
+	backupRecordKey      = 'K'
+	backupRecordEnd      = 'E'
+	maxBackupRecordBytes = 1 << 30 // Redis 单个值最大 512MB，留出余量
+)
+
+var (
+	// ErrBackupFormat 文件不是备份文件或版本不受支持
+	ErrBackupFormat = errors.New("备份文件格式不正确")
+	// ErrBackupChecksum 记录校验失败，文件已损坏
+	ErrBackupChecksum = errors.New("备份记录校验失败")
+	// ErrBackupTruncated 文件缺少结束记录，可能被截断
+	ErrBackupTruncated = errors.New("备份文件不完整")
+)
+
+var backupCRCTable = crc32.MakeTable(crc32.Castagnoli)
+
+// BackupRecord 一个键的备份
+type BackupRecord struct {
+	Key   string
+	TTL   time.Duration // 导出时的剩余过期时间，0 表示不过期
+	Value []byte        // DUMP 序列化值
+}
+
+// =============================================================================
+// 备份流读写
+// =============================================================================
+
+// BackupWriter 向备份流写入记录
+type BackupWriter struct {
+	w      *bufio.Writer
+	count  uint64
+	buf    []byte
+	closed bool
+}
+
+// NewBackupWriter 创建备份写入器并写入文件头
+// 参数：
+//   - w: 目标流
+//   - createdAt: 导出时间，恢复时用于扣除经过的时间
+//
+// 返回：
+//   - *BackupWriter: 写入器，写完后必须调用 Close 写入结束记录
+//   - error: 写入文件头失败时返回错误
+func NewBackupWriter(w io.Writer, createdAt time.Time) (*BackupWriter, error) {
+	bw := &BackupWriter{w: bufio.NewWriterSize(w, 64*1024)}
+	header := make([]byte, 0, len(backupMagic)+10)
+	header = append(header, backupMagic...)
+	header = binary.BigEndian.AppendUint16(header, BackupFormatVersion)
+	header = binary.BigEndian.AppendUint64(header, uint64(createdAt.UnixMilli()))
+	if _, err := bw.w.Write(header); err != nil {
+		return nil, fmt.Errorf("写入备份文件头失败: %w", err)
+	}
+	return bw, nil
+}
+
+// Write 写入一条键记录
+func (bw *BackupWriter) Write(rec BackupRecord) error {
+	if bw.closed {
+		return errors.New("备份写入器已关闭")
+	}
+	payload := bw.buf[:0]
+	payload = binary.AppendUvarint(payload, uint64(len(rec.Key)))
+	payload = append(payload, rec.Key...)
+	payload = binary.AppendVarint(payload, max(rec.TTL.Milliseconds(), 0))
+	payload = binary.AppendUvarint(payload, uint64(len(rec.Value)))
+	payload = append(payload, rec.Value...)
+	bw.buf = payload
+
+	if err := bw.writeFrame(backupRecordKey, payload); err != nil {
+		return fmt.Errorf("写入键 %s 失败: %w", rec.Key, err)
+	}
+	bw.count++
+	return nil
+}
+
+// Count 返回已写入的键数量
+func (bw *BackupWriter) Count() uint64 {
+	return bw.count
+}
+
+// Close 写入结束记录并刷新缓冲区，不关闭底层流
+func (bw *BackupWriter) Close() error {
+	if bw.closed {
+		return nil
+	}
+	bw.closed = true
+	if err := bw.writeFrame(backupRecordEnd, binary.AppendUvarint(nil, bw.count)); err != nil {
+		return fmt.Errorf("写入结束记录失败: %w", err)
+	}
+	if err := bw.w.Flush(); err != nil {
+		return fmt.Errorf("刷新备份数据失败: %w", err)
+	}
+	return nil
+}
+
+// writeFrame 写入一个记录帧
+func (bw *BackupWriter) writeFrame(kind byte, payload []byte) error {
+	var head [5]byte
+	head[0] = kind
+	binary.BigEndian.PutUint32(head[1:], uint32(len(payload)))
+	crc := crc32.Update(crc32.Update(0, backupCRCTable, head[:]), backupCRCTable, payload)
+
+	if _, err := bw.w.Write(head[:]); err != nil {
+		return err
+	}
+	if _, err := bw.w.Write(payload); err != nil {
+		return err
+	}
+	_, err := bw.w.Write(binary.BigEndian.AppendUint32(nil, crc))
+	return err
+}
+
+// BackupReader 从备份流读取记录
+type BackupReader struct {
+	r         *bufio.Reader
+	createdAt time.Time
+	count     uint64
+	done      bool
+}
+
+// NewBackupReader 创建备份读取器并校验文件头
+// 参数：
+//   - r: 来源流
+//
+// 返回：
+//   - *BackupReader: 读取器
+//   - error: 文件头不正确时返回 ErrBackupFormat
+func NewBackupReader(r io.Reader) (*BackupReader, error) {
+	br := &BackupReader{r: bufio.NewReaderSize(r, 64*1024)}
+	header := make([]byte, len(backupMagic)+10)
+	if _, err := io.ReadFull(br.r, header); err != nil {
+		return nil, fmt.Errorf("%w: 读取文件头失败: %v", ErrBackupFormat, err)
+	}
+	if string(header[:len(backupMagic)]) != backupMagic {
+		return nil, fmt.Errorf("%w: 文件标识不匹配", ErrBackupFormat)
+	}
+	if version := binary.BigEndian.Uint16(header[len(backupMagic):]); version != BackupFormatVersion {
+		return nil, fmt.Errorf("%w: 不支持的版本 %d", ErrBackupFormat, version)
+	}
+	br.createdAt = time.UnixMilli(int64(binary.BigEndian.Uint64(header[len(backupMagic)+2:])))
+	return br, nil
+}
+
+// CreatedAt 返回备份的导出时间
+func (br *BackupReader) CreatedAt() time.Time {
+	return br.createdAt
+}
+
+// Next 读取下一条键记录
+// 返回：
+//   - BackupRecord: 键记录
+//   - error: 读到结束记录时返回 io.EOF；文件被截断返回 ErrBackupTruncated，记录损坏返回 ErrBackupChecksum
+func (br *BackupReader) Next() (BackupRecord, error) {
+	if br.done {
+		return BackupRecord{}, io.EOF
+	}
+	index := br.count + 1
+
+	var head [5]byte
+	if _, err := io.ReadFull(br.r, head[:]); err != nil {
+		return BackupRecord{}, br.readError(index, err)
+	}
+	size := binary.BigEndian.Uint32(head[1:])
+	if size > maxBackupRecordBytes {
+		return BackupRecord{}, fmt.Errorf("%w: 第 %d 条记录长度 %d 超出上限", ErrBackupChecksum, index, size)
+	}
+	frame := make([]byte, int(size)+4)
+	if _, err := io.ReadFull(br.r, frame); err != nil {
+		return BackupRecord{}, br.readError(index, err)
+	}
+	payload := frame[:size]
+	crc := crc32.Update(crc32.Update(0, backupCRCTable, head[:]), backupCRCTable, payload)
+	if crc != binary.BigEndian.Uint32(frame[size:]) {
+		return BackupRecord{}, fmt.Errorf("%w: 第 %d 条记录", ErrBackupChecksum, index)
+	}
+
+	switch head[0] {
+	case backupRecordKey:
+		rec, err := decodeBackupRecord(payload)
+		if err != nil {
+			return BackupRecord{}, fmt.Errorf("%w: 第 %d 条记录: %v", ErrBackupFormat, index, err)
+		}
+		br.count++
+		return rec, nil
+	case backupRecordEnd:
+		total, n := binary.Uvarint(payload)
+		if n <= 0 || total != br.count {
+			return BackupRecord{}, fmt.Errorf("%w: 结束记录中的键数量与实际读取的 %d 个不一致", ErrBackupFormat, br.count)
+		}
+		br.done = true
+		return BackupRecord{}, io.EOF
+	default:
+		return BackupRecord{}, fmt.Errorf("%w: 第 %d 条记录类型 %q 未知", ErrBackupFormat, index, head[0])
+	}
+}
+
+// readError 转换读取记录时的错误，流提前结束视为文件被截断
+func (br *BackupReader) readError(index uint64, err error) error {
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return fmt.Errorf("%w: 读取第 %d 条记录时遇到文件结尾", ErrBackupTruncated, index)
+	}
+	return fmt.Errorf("读取第 %d 条记录失败: %w", index, err)
+}
+
+// decodeBackupRecord 解析键记录的内容
+func decodeBackupRecord(payload []byte) (BackupRecord, error) {
+	readBytes := func(name string) ([]byte, error) {
+		size, n := binary.Uvarint(payload)
+		if n <= 0 || uint64(len(payload)-n) < size {
+			return nil, fmt.Errorf("%s长度不正确", name)
+		}
+		data := payload[n : n+int(size)]
+		payload = payload[n+int(size):]
+		return data, nil
+	}
+
+	key, err := readBytes("键")
+	if err != nil {
+		return BackupRecord{}, err
+	}
+	ttl, n := binary.Varint(payload)
+	if n <= 0 || ttl < 0 {
+		return BackupRecord{}, errors.New("过期时间不正确")
+	}
+	payload = payload[n:]
+	value, err := readBytes("值")
+	if err != nil {
+		return BackupRecord{}, err
+	}
+	if len(payload) != 0 {
+		return BackupRecord{}, errors.New("记录末尾有多余数据")
+	}
+	return BackupRecord{Key: string(key), TTL: time.Duration(ttl) * time.Millisecond, Value: value}, nil
+}
+
+// =============================================================================
+// 导出与恢复
+// =============================================================================
+
+// ExportOptions 导出配置
+type ExportOptions struct {
+	Match            string // 只导出匹配的键，为空时导出全部键
+	Type             string // 只导出指定类型的键
+	BatchSize        int    // 每批（一个 Pipeline）导出的键数量，默认为 100
+	MaxKeysPerSecond int    // 每秒最多导出的键数量，0 表示不限速
+	OnProgress       func(exported int64)
+}
+
+// ExportResult 导出结果
+type ExportResult struct {
+	Exported int64 // 写入备份的键数量
+	Missing  int64 // 扫描后、导出前被删除或过期的键数量
+}
+
+// ExportKeys 将匹配的键导出到备份流
+// 参数：
+//   - ctx: 上下文，取消后在当前批次结束时停止
+//   - w: 目标流；出错时已写入的内容不含结束记录，读取时会报告 ErrBackupTruncated
+//   - opts: 导出配置，为 nil 时导出全部键
+//
+// 返回：
+//   - *ExportResult: 导出结果，出错时包含已完成的部分
+//   - error: 扫描、DUMP 或写入失败时返回错误
+func (r *RedisManager) ExportKeys(ctx context.Context, w io.Writer, opts *ExportOptions) (*ExportResult, error) {
+	o := ExportOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.BatchSize <= 0 {
+		o.BatchSize = 100
+	}
+
+	result := &ExportResult{}
+	bw, err := NewBackupWriter(w, time.Now())
+	if err != nil {
+		return result, err
+	}
+
+	start := time.Now()
+	var scanned int64
+	batch := make([]string, 0, o.BatchSize)
+	flush := func() error {
+		if err := r.exportBatch(ctx, bw, batch, result); err != nil {
+			return err
+		}
+		scanned += int64(len(batch))
+		batch = batch[:0]
+		if o.OnProgress != nil {
+			o.OnProgress(result.Exported)
+		}
+		return throttle(ctx, start, scanned, o.MaxKeysPerSecond)
+	}
+
+	for key, err := range r.Scan(ctx, &ScanOptions{Match: o.Match, Type: o.Type, Count: int64(o.BatchSize)}) {
+		if err != nil {
+			return result, err
+		}
+		batch = append(batch, key)
+		if len(batch) == o.BatchSize {
+			if err := flush(); err != nil {
+				return result, err
+			}
+		}
+	}
+	if len(batch) > 0 {
+		if err := flush(); err != nil {
+			return result, err
+		}
+	}
+	return result, bw.Close()
+}
+
+// exportBatch 获取一批键的 DUMP 值与剩余过期时间并写入备份
+func (r *RedisManager) exportBatch(ctx context.Context, bw *BackupWriter, keys []string, result *ExportResult) error {
+	records, err := r.dumpKeys(ctx, keys)
+	if err != nil {
+		return err
+	}
+	for _, rec := range records {
+		if rec == nil {
+			result.Missing++
+			continue
+		}
+		if err := bw.Write(*rec); err != nil {
+			return err
+		}
+		result.Exported++
+	}
+	return nil
+}
+
+// dumpKeys 通过一个 Pipeline 执行 DUMP 与 PTTL，已不存在的键对应位置为 nil
+func (r *RedisManager) dumpKeys(ctx context.Context, keys []string) ([]*BackupRecord, error) {
+	dumps := make([]*redis.StringCmd, len(keys))
+	ttls := make([]*redis.DurationCmd, len(keys))
+	// Pipeline 的错误记录在各条命令上，下面逐条检查
+	_, _ = r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, key := range keys {
+			dumps[i] = pipe.Dump(ctx, key)
+			ttls[i] = pipe.PTTL(ctx, key)
+		}
+		return nil
+	})
+
+	records := make([]*BackupRecord, len(keys))
+	for i, key := range keys {
+		value, err := dumps[i].Result()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("导出键 %s 失败: %w", key, err)
+		}
+		ttl, err := ttls[i].Result()
+		if err != nil {
+			return nil, fmt.Errorf("查询键 %s 的过期时间失败: %w", key, err)
+		}
+		// PTTL 返回 -1 表示不过期，-2 表示键在 DUMP 之后已被删除或过期
+		if ttl == -2 {
+			continue
+		}
+		records[i] = &BackupRecord{Key: key, TTL: max(ttl, 0), Value: []byte(value)}
+	}
+	return records, nil
+}
+
+// ImportOptions 恢复配置
+type ImportOptions struct {
+	Replace          bool // 覆盖已存在的键（RESTORE REPLACE），否则跳过
+	AdjustTTL        bool // 扣除导出后经过的时间，已过期的键不再恢复；仅对 DUMP 备份生效
+	BatchSize        int  // 每批（一个 Pipeline）恢复的键数量，默认为 100
+	MaxKeysPerSecond int  // 每秒最多恢复的键数量，0 表示不限速
+	OnProgress       func(progress ImportResult)
+}
+
+// ImportResult 恢复结果
+type ImportResult struct {
+	Restored   int64    // 成功恢复的键数量
+	Skipped    int64    // 目标已存在而跳过的键数量
+	Expired    int64    // AdjustTTL 时已过期而跳过的键数量
+	FailedKeys []string // 恢复失败的键
+}
+
+// ImportKeys 从备份流恢复键
+// 参数：
+//   - ctx: 上下文，取消后在当前批次结束时停止
+//   - rd: 来源流
+//   - opts: 恢复配置，为 nil 时使用默认配置
+//
+// 返回：
+//   - *ImportResult: 恢复结果，出错时包含已完成的部分
+//   - error: 备份格式错误、记录损坏或 RESTORE 失败时返回错误
+func (r *RedisManager) ImportKeys(ctx context.Context, rd io.Reader, opts *ImportOptions) (*ImportResult, error) {
+	o := ImportOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.BatchSize <= 0 {
+		o.BatchSize = 100
+	}
+
+	result := &ImportResult{}
+	br, err := NewBackupReader(rd)
+	if err != nil {
+		return result, err
+	}
+	elapsed := time.Duration(0)
+	if o.AdjustTTL {
+		elapsed = max(time.Since(br.CreatedAt()), 0)
+	}
+
+	start := time.Now()
+	var processed int64
+	batch := make([]BackupRecord, 0, o.BatchSize)
+	flush := func() error {
+		if err := r.importBatch(ctx, batch, o.Replace, result); err != nil {
+			return err
+		}
+		processed += int64(len(batch))
+		batch = batch[:0]
+		if o.OnProgress != nil {
+			o.OnProgress(*result)
+		}
+		return throttle(ctx, start, processed, o.MaxKeysPerSecond)
+	}
+
+	for {
+		rec, err := br.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return result, err
+		}
+		if rec.TTL > 0 && elapsed > 0 {
+			if rec.TTL <= elapsed {
+				result.Expired++
+				continue
+			}
+			rec.TTL -= elapsed
+		}
+		batch = append(batch, rec)
+		if len(batch) == o.BatchSize {
+			if err := flush(); err != nil {
+				return result, err
+			}
+		}
+	}
+	if len(batch) > 0 {
+		if err := flush(); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// importBatch 通过一个 Pipeline 恢复一批键
+func (r *RedisManager) importBatch(ctx context.Context, batch []BackupRecord, replace bool, result *ImportResult) error {
+	ops := make([]BatchOperation, len(batch))
+	for i, rec := range batch {
+		ops[i] = func(ctx context.Context, pipe redis.Pipeliner) redis.Cmder {
+			if replace {
+				return pipe.RestoreReplace(ctx, rec.Key, rec.TTL, string(rec.Value))
+			}
+			return pipe.Restore(ctx, rec.Key, rec.TTL, string(rec.Value))
+		}
+	}
+
+	results, _ := r.ExecBatch(ctx, ops, &BatchOptions{ChunkSize: len(ops)})
+	var firstErr error
+	for _, res := range results {
+		switch {
+		case res.Err == nil:
+			result.Restored++
+		case strings.HasPrefix(res.Err.Error(), "BUSYKEY"):
+			result.Skipped++
+		default:
+			result.FailedKeys = append(result.FailedKeys, batch[res.Index].Key)
+			if firstErr == nil {
+				firstErr = fmt.Errorf("恢复键 %s 失败: %w", batch[res.Index].Key, res.Err)
+			}
+		}
+	}
+	return firstErr
+}
+
+// ExportKeysToFile 将匹配的键导出到文件
+// 先写入同目录下的临时文件，成功后再重命名，导出失败不会留下不完整的备份文件
+// 参数：
+//   - ctx: 上下文
+//   - path: 备份文件路径，已存在时被覆盖
+//   - opts: 导出配置
+//
+// 返回：
+//   - *ExportResult: 导出结果
+//   - error: 导出或文件操作失败时返回错误
+func (r *RedisManager) ExportKeysToFile(ctx context.Context, path string, opts *ExportOptions) (*ExportResult, error) {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return &ExportResult{}, fmt.Errorf("创建备份文件失败: %w", err)
+	}
+	defer os.Remove(f.Name())
+
+	result, err := r.ExportKeys(ctx, f, opts)
+	if err != nil {
+		f.Close()
+		return result, err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return result, fmt.Errorf("写入备份文件失败: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return result, fmt.Errorf("关闭备份文件失败: %w", err)
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		return result, fmt.Errorf("保存备份文件失败: %w", err)
+	}
+	return result, nil
+}
+
+// ImportKeysFromFile 从备份文件恢复键
+// 参数：
+//   - ctx: 上下文
+//   - path: 备份文件路径
+//   - opts: 恢复配置
+//
+// 返回：
+//   - *ImportResult: 恢复结果
+//   - error: 打开文件或恢复失败时返回错误
+func (r *RedisManager) ImportKeysFromFile(ctx context.Context, path string, opts *ImportOptions) (*ImportResult, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return &ImportResult{}, fmt.Errorf("打开备份文件失败: %w", err)
+	}
+	defer f.Close()
+	return r.ImportKeys(ctx, f, opts)
+}
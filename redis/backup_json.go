@@ -0,0 +1,696 @@
+package redis
+
+// 基于 JSON Lines 的键空间导出与导入
+//
+// 场景说明：
+//   DUMP 的序列化值与 RDB 版本绑定，无法恢复到更低版本的 Redis，也无法直接阅读或修改。
+//   ExportJSON 用 GET/HSCAN/LRANGE/SSCAN/ZSCAN/XRANGE 等原生读命令读取每个键，每个键输出一行 JSON：
+//
+//     {"key":"user:1","type":"hash","ttl":60000,"value":{"name":"alice","age":"30"}}
+//     {"key":"rank","type":"zset","value":[{"member":"alice","score":99.5}]}
+//     {"key":"events","type":"stream","value":[{"id":"1700000000000-0","values":["action","login"]}]}
+//
+//   value 的结构随类型变化：string 为字符串，hash 为对象，list 为数组（保持顺序），set 为排序后的数组，
+//   zset 为按分数排序的 member/score 数组，stream 为按 ID 排序的消息数组（values 为字段与值交替的数组，保持原有顺序），
+//   空 Stream 导出为 []，导入时同样重建为空 Stream。
+//   ImportJSON 逐行读取并用 SET/HSET/RPUSH/SADD/ZADD/XADD 重建键，适合从类生产数据生成测试数据，
+//   也可以手工编辑导出的文件后再导入。
+//
+// 注意：
+//   - 含有非 UTF-8 数据的键整条记录的字符串以 Base64 编码，并标记 "encoding":"base64"；
+//   - 只保存数据本身，不保存 Stream 的消费者组、集合的内部编码等元数据；
+//   - 集合类型逐个键读取，单个键的全部元素会一次性载入内存；
+//   - 导入时集合分多条命令写入，不是原子操作，中途失败的键可能只写入了部分元素。
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"time"
+	"unicode/utf8"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// emptyStreamGroup 导入空 Stream 时临时创建的消费者组
+const emptyStreamGroup = "json-import"
+
+// jsonChunkSize 读取列表、Stream 与写入集合时每条命令包含的元素数量
+const jsonChunkSize = 1000
+
+// JSONRecord JSON Lines 中的一行，对应一个键
+type JSONRecord struct {
+	Key      string          `json:"key"`
+	Type     string          `json:"type"`
+	TTL      int64           `json:"ttl,omitempty"`      // 剩余过期时间（毫秒），0 表示不过期
+	Encoding string          `json:"encoding,omitempty"` // 为 base64 时记录中的所有字符串均为 Base64 编码
+	Value    json.RawMessage `json:"value"`
+}
+
+// JSONScoredMember 有序集合成员
+type JSONScoredMember struct {
+	Member string    `json:"member"`
+	Score  JSONScore `json:"score"`
+}
+
+// JSONScore 有序集合分数，正负无穷编码为字符串 "inf" 与 "-inf"
+type JSONScore float64
+
+// MarshalJSON 实现 json.Marshaler
+func (s JSONScore) MarshalJSON() ([]byte, error) {
+	switch {
+	case math.IsInf(float64(s), 1):
+		return []byte(`"inf"`), nil
+	case math.IsInf(float64(s), -1):
+		return []byte(`"-inf"`), nil
+	}
+	return json.Marshal(float64(s))
+}
+
+// UnmarshalJSON 实现 json.Unmarshaler
+func (s *JSONScore) UnmarshalJSON(data []byte) error {
+	switch string(data) {
+	case `"inf"`, `"+inf"`:
+		*s = JSONScore(math.Inf(1))
+		return nil
+	case `"-inf"`:
+		*s = JSONScore(math.Inf(-1))
+		return nil
+	}
+	var f float64
+	if err := json.Unmarshal(data, &f); err != nil {
+		return fmt.Errorf("分数 %s 不正确: %w", data, err)
+	}
+	*s = JSONScore(f)
+	return nil
+}
+
+// JSONStreamEntry Stream 消息
+type JSONStreamEntry struct {
+	ID     string   `json:"id"`
+	Values []string `json:"values"` // 字段与值交替排列
+}
+
+// =============================================================================
+// 导出
+// =============================================================================
+
+// ExportJSON 将匹配的键以 JSON Lines 格式导出
+// 参数：
+//   - ctx: 上下文，取消后在当前批次结束时停止
+//   - w: 目标流，每个键写入一行
+//   - opts: 导出配置（与 ExportKeys 相同），为 nil 时导出全部键
+//
+// 返回：
+//   - *ExportResult: 导出结果，出错时包含已完成的部分
+//   - error: 扫描、读取或写入失败时返回错误
+func (r *RedisManager) ExportJSON(ctx context.Context, w io.Writer, opts *ExportOptions) (*ExportResult, error) {
+	o := ExportOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.BatchSize <= 0 {
+		o.BatchSize = 100
+	}
+
+	result := &ExportResult{}
+	bw := bufio.NewWriterSize(w, 64*1024)
+	enc := json.NewEncoder(bw)
+	enc.SetEscapeHTML(false)
+
+	start := time.Now()
+	var scanned int64
+	batch := make([]string, 0, o.BatchSize)
+	flush := func() error {
+		records, err := r.readJSONBatch(ctx, batch)
+		if err != nil {
+			return err
+		}
+		for _, rec := range records {
+			if rec == nil {
+				result.Missing++
+				continue
+			}
+			if err := enc.Encode(rec); err != nil {
+				return fmt.Errorf("写入键 %s 失败: %w", rec.Key, err)
+			}
+			result.Exported++
+		}
+		if err := bw.Flush(); err != nil {
+			return fmt.Errorf("写入导出数据失败: %w", err)
+		}
+		scanned += int64(len(batch))
+		batch = batch[:0]
+		if o.OnProgress != nil {
+			o.OnProgress(result.Exported)
+		}
+		return throttle(ctx, start, scanned, o.MaxKeysPerSecond)
+	}
+
+	for key, err := range r.Scan(ctx, &ScanOptions{Match: o.Match, Type: o.Type, Count: int64(o.BatchSize)}) {
+		if err != nil {
+			return result, err
+		}
+		batch = append(batch, key)
+		if len(batch) == o.BatchSize {
+			if err := flush(); err != nil {
+				return result, err
+			}
+		}
+	}
+	if len(batch) > 0 {
+		if err := flush(); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// readJSONBatch 读取一批键，已不存在的键对应位置为 nil
+// 类型与过期时间通过一个 Pipeline 查询，字符串的值同样批量读取，集合类型逐个键读取
+func (r *RedisManager) readJSONBatch(ctx context.Context, keys []string) ([]*JSONRecord, error) {
+	types := make([]*redis.StatusCmd, len(keys))
+	ttls := make([]*redis.DurationCmd, len(keys))
+	// Pipeline 的错误记录在各条命令上，下面逐条检查
+	_, _ = r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, key := range keys {
+			types[i] = pipe.Type(ctx, key)
+			ttls[i] = pipe.PTTL(ctx, key)
+		}
+		return nil
+	})
+
+	records := make([]*JSONRecord, len(keys))
+	gets := make([]*redis.StringCmd, len(keys))
+	for i, key := range keys {
+		keyType, err := types[i].Result()
+		if err != nil {
+			return nil, fmt.Errorf("查询键 %s 的类型失败: %w", key, err)
+		}
+		ttl, err := ttls[i].Result()
+		if err != nil {
+			return nil, fmt.Errorf("查询键 %s 的过期时间失败: %w", key, err)
+		}
+		if keyType == "none" || ttl == -2 {
+			continue
+		}
+		records[i] = &JSONRecord{Key: key, Type: keyType, TTL: max(ttl, 0).Milliseconds()}
+	}
+
+	_, _ = r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, rec := range records {
+			if rec != nil && rec.Type == "string" {
+				gets[i] = pipe.Get(ctx, rec.Key)
+			}
+		}
+		return nil
+	})
+
+	for i, rec := range records {
+		if rec == nil {
+			continue
+		}
+		var value any
+		var strs []string
+		var err error
+		if gets[i] != nil {
+			var s string
+			s, err = gets[i].Result()
+			value, strs = &s, []string{s}
+		} else {
+			value, strs, err = r.readJSONValue(ctx, rec.Key, rec.Type)
+		}
+		// 键在查询类型之后被删除
+		if errors.Is(err, redis.Nil) || (err == nil && value == nil) {
+			records[i] = nil
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("读取键 %s 失败: %w", rec.Key, err)
+		}
+
+		if err := encodeJSONValue(rec, value, strs); err != nil {
+			return nil, err
+		}
+	}
+	return records, nil
+}
+
+// encodeJSONValue 序列化记录的值，键名或数据中含有非 UTF-8 内容时整条记录改用 Base64 编码
+// 参数：
+//   - rec: 已填写键名、类型与过期时间的记录
+//   - value: readJSONValue 返回的可序列化值，会被原地修改
+//   - strs: 值中的所有字符串
+func encodeJSONValue(rec *JSONRecord, value any, strs []string) error {
+	key := rec.Key
+	if !utf8.ValidString(rec.Key) || !allValidUTF8(strs) {
+		rec.Encoding = "base64"
+		rec.Key = base64.StdEncoding.EncodeToString([]byte(rec.Key))
+		mapJSONStrings(value, func(s string) (string, error) {
+			return base64.StdEncoding.EncodeToString([]byte(s)), nil
+		})
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("序列化键 %s 失败: %w", key, err)
+	}
+	rec.Value = data
+	return nil
+}
+
+// readJSONValue 读取集合类型的值，返回可序列化的值与其中所有字符串（用于判断编码）
+// 键不存在或为空时返回 nil 值
+func (r *RedisManager) readJSONValue(ctx context.Context, key, keyType string) (any, []string, error) {
+	var strs []string
+	switch keyType {
+	case "hash":
+		fields := make(map[string]string)
+		for f, err := range r.HScan(ctx, key, &ScanOptions{Count: jsonChunkSize}) {
+			if err != nil {
+				return nil, nil, err
+			}
+			fields[f.Field] = f.Value
+			strs = append(strs, f.Field, f.Value)
+		}
+		if len(fields) == 0 {
+			return nil, nil, nil
+		}
+		return &fields, strs, nil
+
+	case "list":
+		var items []string
+		for start := int64(0); ; start += jsonChunkSize {
+			page, err := r.client.LRange(ctx, key, start, start+jsonChunkSize-1).Result()
+			if err != nil {
+				return nil, nil, err
+			}
+			items = append(items, page...)
+			if len(page) < jsonChunkSize {
+				break
+			}
+		}
+		if len(items) == 0 {
+			return nil, nil, nil
+		}
+		return &items, items, nil
+
+	case "set":
+		var members []string
+		for m, err := range r.SScan(ctx, key, &ScanOptions{Count: jsonChunkSize}) {
+			if err != nil {
+				return nil, nil, err
+			}
+			members = append(members, m)
+		}
+		if len(members) == 0 {
+			return nil, nil, nil
+		}
+		sort.Strings(members)
+		return &members, members, nil
+
+	case "zset":
+		var members []JSONScoredMember
+		for z, err := range r.ZScan(ctx, key, &ScanOptions{Count: jsonChunkSize}) {
+			if err != nil {
+				return nil, nil, err
+			}
+			member := z.Member.(string)
+			members = append(members, JSONScoredMember{Member: member, Score: JSONScore(z.Score)})
+			strs = append(strs, member)
+		}
+		if len(members) == 0 {
+			return nil, nil, nil
+		}
+		sortScoredMembers(members)
+		return &members, strs, nil
+
+	case "stream":
+		entries, err := r.readStreamEntries(ctx, key)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(entries) == 0 {
+			// 与其他集合类型不同，Stream 可以为空，需要区分空 Stream 与读取期间被删除的键
+			n, err := r.client.Exists(ctx, key).Result()
+			if err != nil || n == 0 {
+				return nil, nil, err
+			}
+			entries = []JSONStreamEntry{}
+		}
+		for _, e := range entries {
+			strs = append(strs, e.Values...)
+		}
+		return &entries, strs, nil
+	}
+	return nil, nil, fmt.Errorf("不支持的类型 %s", keyType)
+}
+
+// readStreamEntries 分页读取 Stream 的全部消息
+// 直接解析 XRANGE 的原始回复以保留字段顺序，go-redis 的 XMessage 使用 map 保存字段
+func (r *RedisManager) readStreamEntries(ctx context.Context, key string) ([]JSONStreamEntry, error) {
+	var entries []JSONStreamEntry
+	start := "-"
+	for {
+		reply, err := r.client.Do(ctx, "XRANGE", key, start, "+", "COUNT", jsonChunkSize).Slice()
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range reply {
+			msg, ok := item.([]interface{})
+			if !ok || len(msg) != 2 {
+				return nil, fmt.Errorf("XRANGE 回复格式不正确: %v", item)
+			}
+			id, _ := msg[0].(string)
+			raw, _ := msg[1].([]interface{})
+			entry := JSONStreamEntry{ID: id, Values: make([]string, len(raw))}
+			for j, v := range raw {
+				entry.Values[j], _ = v.(string)
+			}
+			entries = append(entries, entry)
+		}
+		if len(reply) < jsonChunkSize {
+			return entries, nil
+		}
+		// 排他区间需要 Redis 6.2+
+		start = "(" + entries[len(entries)-1].ID
+	}
+}
+
+// sortScoredMembers 按分数排序，分数相同时按成员排序（与 ZRANGE 的顺序一致）
+func sortScoredMembers(members []JSONScoredMember) {
+	sort.Slice(members, func(i, j int) bool {
+		if members[i].Score != members[j].Score {
+			return members[i].Score < members[j].Score
+		}
+		return members[i].Member < members[j].Member
+	})
+}
+
+// allValidUTF8 判断所有字符串是否都是合法的 UTF-8
+func allValidUTF8(strs []string) bool {
+	for _, s := range strs {
+		if !utf8.ValidString(s) {
+			return false
+		}
+	}
+	return true
+}
+
+// mapJSONStrings 对记录值中的每个数据字符串（不含 Stream 消息 ID）原地应用 fn
+func mapJSONStrings(value any, fn func(string) (string, error)) error {
+	var err error
+	apply := func(s *string) {
+		if err == nil {
+			*s, err = fn(*s)
+		}
+	}
+	switch v := value.(type) {
+	case *string:
+		apply(v)
+	case *[]string:
+		for i := range *v {
+			apply(&(*v)[i])
+		}
+	case *map[string]string:
+		mapped := make(map[string]string, len(*v))
+		for field, val := range *v {
+			apply(&field)
+			apply(&val)
+			mapped[field] = val
+		}
+		*v = mapped
+	case *[]JSONScoredMember:
+		for i := range *v {
+			apply(&(*v)[i].Member)
+		}
+	case *[]JSONStreamEntry:
+		for i := range *v {
+			for j := range (*v)[i].Values {
+				apply(&(*v)[i].Values[j])
+			}
+		}
+	}
+	return err
+}
+
+// =============================================================================
+// 导入
+// =============================================================================
+
+// ImportJSON 从 JSON Lines 流重建键
+// 参数：
+//   - ctx: 上下文，取消后在当前批次结束时停止
+//   - rd: 来源流，空行会被忽略
+//   - opts: 恢复配置（与 ImportKeys 相同），不设置 Replace 时跳过已存在的键；JSON 记录不含导出时间，AdjustTTL 不生效
+//
+// 返回：
+//   - *ImportResult: 导入结果，出错时包含已完成的部分
+//   - error: 记录格式错误或写入失败时返回错误
+func (r *RedisManager) ImportJSON(ctx context.Context, rd io.Reader, opts *ImportOptions) (*ImportResult, error) {
+	o := ImportOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.BatchSize <= 0 {
+		o.BatchSize = 100
+	}
+
+	result := &ImportResult{}
+	scanner := bufio.NewScanner(rd)
+	scanner.Buffer(make([]byte, 64*1024), maxBackupRecordBytes)
+
+	start := time.Now()
+	var processed int64
+	batch := make([]jsonImportItem, 0, o.BatchSize)
+	flush := func() error {
+		if err := r.importJSONBatch(ctx, batch, o.Replace, result); err != nil {
+			return err
+		}
+		processed += int64(len(batch))
+		batch = batch[:0]
+		if o.OnProgress != nil {
+			o.OnProgress(*result)
+		}
+		return throttle(ctx, start, processed, o.MaxKeysPerSecond)
+	}
+
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		item, err := decodeJSONRecord(scanner.Bytes())
+		if err != nil {
+			return result, fmt.Errorf("第 %d 行: %w", line, err)
+		}
+		batch = append(batch, item)
+		if len(batch) == o.BatchSize {
+			if err := flush(); err != nil {
+				return result, err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return result, fmt.Errorf("读取第 %d 行失败: %w", line+1, err)
+	}
+	if len(batch) > 0 {
+		if err := flush(); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// jsonImportItem 解析后的记录与重建它的写命令
+type jsonImportItem struct {
+	key   string
+	ttl   time.Duration
+	write func(ctx context.Context, pipe redis.Pipeliner) // 写入数据（不含删除与过期时间）
+}
+
+// decodeJSONRecord 解析一行记录
+func decodeJSONRecord(line []byte) (jsonImportItem, error) {
+	var rec JSONRecord
+	if err := json.Unmarshal(line, &rec); err != nil {
+		return jsonImportItem{}, fmt.Errorf("解析记录失败: %w", err)
+	}
+	if rec.TTL < 0 {
+		return jsonImportItem{}, fmt.Errorf("键 %s 的过期时间 %d 不正确", rec.Key, rec.TTL)
+	}
+
+	decode := func(s string) (string, error) { return s, nil }
+	switch rec.Encoding {
+	case "":
+	case "base64":
+		decode = func(s string) (string, error) {
+			data, err := base64.StdEncoding.DecodeString(s)
+			return string(data), err
+		}
+		key, err := decode(rec.Key)
+		if err != nil {
+			return jsonImportItem{}, fmt.Errorf("解码键名失败: %w", err)
+		}
+		rec.Key = key
+	default:
+		return jsonImportItem{}, fmt.Errorf("键 %s 的编码 %s 不受支持", rec.Key, rec.Encoding)
+	}
+
+	var value any
+	switch rec.Type {
+	case "string":
+		value = new(string)
+	case "hash":
+		value = new(map[string]string)
+	case "list", "set":
+		value = new([]string)
+	case "zset":
+		value = new([]JSONScoredMember)
+	case "stream":
+		value = new([]JSONStreamEntry)
+	default:
+		return jsonImportItem{}, fmt.Errorf("键 %s 的类型 %s 不受支持", rec.Key, rec.Type)
+	}
+	if err := json.Unmarshal(rec.Value, value); err != nil {
+		return jsonImportItem{}, fmt.Errorf("解析键 %s 的值失败: %w", rec.Key, err)
+	}
+	if err := mapJSONStrings(value, decode); err != nil {
+		return jsonImportItem{}, fmt.Errorf("解码键 %s 的值失败: %w", rec.Key, err)
+	}
+
+	key := rec.Key
+	item := jsonImportItem{key: key, ttl: time.Duration(rec.TTL) * time.Millisecond}
+	switch v := value.(type) {
+	case *string:
+		item.write = func(ctx context.Context, pipe redis.Pipeliner) { pipe.Set(ctx, key, *v, 0) }
+	case *map[string]string:
+		args := make([]interface{}, 0, 2*len(*v))
+		for field, val := range *v {
+			args = append(args, field, val)
+		}
+		item.write = chunkedWrite(args, 2, func(ctx context.Context, pipe redis.Pipeliner, chunk []interface{}) {
+			pipe.HSet(ctx, key, chunk...)
+		})
+	case *[]string:
+		args := make([]interface{}, len(*v))
+		for i, s := range *v {
+			args[i] = s
+		}
+		if rec.Type == "list" {
+			item.write = chunkedWrite(args, 1, func(ctx context.Context, pipe redis.Pipeliner, chunk []interface{}) {
+				pipe.RPush(ctx, key, chunk...)
+			})
+		} else {
+			item.write = chunkedWrite(args, 1, func(ctx context.Context, pipe redis.Pipeliner, chunk []interface{}) {
+				pipe.SAdd(ctx, key, chunk...)
+			})
+		}
+	case *[]JSONScoredMember:
+		args := make([]interface{}, len(*v))
+		for i, m := range *v {
+			args[i] = redis.Z{Member: m.Member, Score: float64(m.Score)}
+		}
+		item.write = chunkedWrite(args, 1, func(ctx context.Context, pipe redis.Pipeliner, chunk []interface{}) {
+			zs := make([]redis.Z, len(chunk))
+			for i, z := range chunk {
+				zs[i] = z.(redis.Z)
+			}
+			pipe.ZAdd(ctx, key, zs...)
+		})
+	case *[]JSONStreamEntry:
+		for _, e := range *v {
+			if len(e.Values) == 0 || len(e.Values)%2 != 0 {
+				return jsonImportItem{}, fmt.Errorf("键 %s 的消息 %s 字段数量不正确", key, e.ID)
+			}
+		}
+		entries := *v
+		item.write = func(ctx context.Context, pipe redis.Pipeliner) {
+			if len(entries) == 0 {
+				// XADD 无法创建空 Stream，借助临时消费者组的 MKSTREAM 创建后再删除该组
+				pipe.XGroupCreateMkStream(ctx, key, emptyStreamGroup, "$")
+				pipe.XGroupDestroy(ctx, key, emptyStreamGroup)
+				return
+			}
+			for _, e := range entries {
+				pipe.XAdd(ctx, &redis.XAddArgs{Stream: key, ID: e.ID, Values: e.Values})
+			}
+		}
+	}
+	return item, nil
+}
+
+// chunkedWrite 将参数按 jsonChunkSize 个元素（每个元素占 stride 个参数）分块写入
+func chunkedWrite(args []interface{}, stride int, write func(ctx context.Context, pipe redis.Pipeliner, chunk []interface{})) func(ctx context.Context, pipe redis.Pipeliner) {
+	return func(ctx context.Context, pipe redis.Pipeliner) {
+		for start := 0; start < len(args); start += jsonChunkSize * stride {
+			write(ctx, pipe, args[start:min(start+jsonChunkSize*stride, len(args))])
+		}
+	}
+}
+
+// importJSONBatch 写入一批记录
+// 不覆盖时先通过一个 Pipeline 检查键是否存在；写入时每个键依次执行 DEL、写命令与 PEXPIRE
+func (r *RedisManager) importJSONBatch(ctx context.Context, batch []jsonImportItem, replace bool, result *ImportResult) error {
+	skip := make([]bool, len(batch))
+	if !replace {
+		exists := make([]*redis.IntCmd, len(batch))
+		_, _ = r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+			for i, item := range batch {
+				exists[i] = pipe.Exists(ctx, item.key)
+			}
+			return nil
+		})
+		for i, cmd := range exists {
+			n, err := cmd.Result()
+			if err != nil {
+				return fmt.Errorf("检查键 %s 是否存在失败: %w", batch[i].key, err)
+			}
+			skip[i] = n > 0
+		}
+	}
+
+	pipe := r.client.Pipeline()
+	cmdRanges := make([][2]int, len(batch))
+	for i, item := range batch {
+		if skip[i] {
+			continue
+		}
+		from := pipe.Len()
+		pipe.Del(ctx, item.key)
+		item.write(ctx, pipe)
+		if item.ttl > 0 {
+			pipe.PExpire(ctx, item.key, item.ttl)
+		}
+		cmdRanges[i] = [2]int{from, pipe.Len()}
+	}
+	cmds, _ := pipe.Exec(ctx)
+
+	var firstErr error
+	for i, item := range batch {
+		if skip[i] {
+			result.Skipped++
+			continue
+		}
+		var err error
+		for _, cmd := range cmds[cmdRanges[i][0]:cmdRanges[i][1]] {
+			if err = cmd.Err(); err != nil {
+				break
+			}
+		}
+		if err != nil {
+			result.FailedKeys = append(result.FailedKeys, item.key)
+			if firstErr == nil {
+				firstErr = fmt.Errorf("写入键 %s 失败: %w", item.key, err)
+			}
+			continue
+		}
+		result.Restored++
+	}
+	return firstErr
+}
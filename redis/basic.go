@@ -11,13 +11,15 @@ import (
 
 // RedisManager Redis 管理器，封装 Redis 操作
 type RedisManager struct {
-	client *redis.Client
-	config *internal.RedisConfig
+	client  redis.UniversalClient
+	replica *redis.Client // Sentinel 读写分离模式下只连接从节点的客户端，其余模式为 nil
+	config  *internal.RedisConfig
 }
 
 // NewRedisManager 创建新的 Redis 管理器实例
 // 参数：
-//   - config: Redis 配置，为 nil 时使用默认配置
+//   - config: Redis 配置，为 nil 时使用默认配置；按配置选择拓扑：设置 SentinelMasterName 时通过 Sentinel
+//     连接当前主节点，设置 Cluster 时以集群模式连接，否则连接 Addr 指定的单机实例
 //
 // 返回：
 //   - *RedisManager: Redis 管理器实例
@@ -27,20 +29,43 @@ func NewRedisManager(config *internal.RedisConfig) (*RedisManager, error) {
 		config = internal.DefaultRedisConfig()
 	}
 
-	client := redis.NewClient(&redis.Options{
-		Addr:         config.Addr,
-		Password:     config.Password,
-		DB:           config.DB,
-		PoolSize:     config.PoolSize,
-		MinIdleConns: config.MinIdleConns,
-		DialTimeout:  config.DialTimeout,
-		ReadTimeout:  config.ReadTimeout,
-		WriteTimeout: config.WriteTimeout,
-	})
+	var client redis.UniversalClient
+	var replica *redis.Client
+	switch {
+	case config.UseSentinel() && len(config.SentinelAddrs) == 0:
+		return nil, fmt.Errorf("Sentinel 地址不能为空")
+	case config.UseSentinel():
+		client = redis.NewFailoverClient(config.FailoverOptions())
+		if config.ReadFromReplicas {
+			// 读写分离：写命令、事务与脚本仍由主节点客户端执行，只读命令使用单独的客户端，
+			// 连接 Sentinel 发现的随机从节点，没有可用从节点时退回主节点
+			opts := config.FailoverOptions()
+			opts.ReplicaOnly = true
+			replica = redis.NewFailoverClient(opts)
+		}
+	case config.UseCluster() && len(config.Cluster.Addrs) == 0:
+		return nil, fmt.Errorf("集群种子节点地址不能为空")
+	case config.UseCluster() && config.DB != 0:
+		return nil, fmt.Errorf("Redis Cluster 只支持 0 号数据库，DB 配置为 %d", config.DB)
+	case config.UseCluster():
+		client = redis.NewClusterClient(config.Cluster.ClusterOptions())
+	default:
+		client = redis.NewClient(&redis.Options{
+			Addr:         config.Addr,
+			Password:     config.Password,
+			DB:           config.DB,
+			PoolSize:     config.PoolSize,
+			MinIdleConns: config.MinIdleConns,
+			DialTimeout:  config.DialTimeout,
+			ReadTimeout:  config.ReadTimeout,
+			WriteTimeout: config.WriteTimeout,
+		})
+	}
 
 	manager := &RedisManager{
-		client: client,
-		config: config,
+		client:  client,
+		replica: replica,
+		config:  config,
 	}
 
 	// 测试连接
@@ -48,7 +73,7 @@ func NewRedisManager(config *internal.RedisConfig) (*RedisManager, error) {
 	defer cancel()
 
 	if err := manager.Ping(ctx); err != nil {
-		client.Close()
+		manager.Close()
 		return nil, fmt.Errorf("Redis 连接测试失败: %w", err)
 	}
 
@@ -57,11 +82,12 @@ func NewRedisManager(config *internal.RedisConfig) (*RedisManager, error) {
 
 // NewRedisManagerWithClient 使用现有的 Redis 客户端创建管理器（用于测试或特殊场景）
 // 参数：
-//   - client: 现有的 Redis 客户端
+//   - client: 现有的 Redis 客户端，可以是单机、Sentinel 或集群客户端；其他实现（如 *redis.Ring 或包装类型）
+//     按单机处理，多键命令不按槽位拆分，RPC 的阻塞命令复用该客户端的连接池
 //
 // 返回：
 //   - *RedisManager: Redis 管理器实例
-func NewRedisManagerWithClient(client *redis.Client) *RedisManager {
+func NewRedisManagerWithClient(client redis.UniversalClient) *RedisManager {
 	return &RedisManager{
 		client: client,
 		config: nil, // 外部客户端不管理配置
@@ -69,10 +95,37 @@ func NewRedisManagerWithClient(client *redis.Client) *RedisManager {
 }
 
 // GetClient 获取底层的 Redis 客户端（用于高级操作）
-func (r *RedisManager) GetClient() *redis.Client {
+// 单机与 Sentinel 模式下为 *redis.Client（读写分离时为连接主节点的客户端），集群模式下为 *redis.ClusterClient
+func (r *RedisManager) GetClient() redis.UniversalClient {
+	return r.client
+}
+
+// reader 返回执行只读命令的客户端：Sentinel 读写分离模式下为从节点客户端，其余模式为 r.client
+// （集群模式的只读路由由 ClusterClient 的 ReadOnly 选项处理）
+func (r *RedisManager) reader() redis.UniversalClient {
+	if r.replica != nil {
+		return r.replica
+	}
 	return r.client
 }
 
+// db 返回客户端当前使用的数据库编号，集群模式下只有 0 号数据库
+func (r *RedisManager) db() int {
+	if client, ok := r.client.(*redis.Client); ok {
+		return client.Options().DB
+	}
+	if r.config != nil {
+		return r.config.DB
+	}
+	return 0
+}
+
+// clusterClient 返回 Redis Cluster 客户端，非集群模式返回 nil
+func (r *RedisManager) clusterClient() *redis.ClusterClient {
+	cluster, _ := r.client.(*redis.ClusterClient)
+	return cluster
+}
+
 // GetConfig 获取 Redis 配置信息
 func (r *RedisManager) GetConfig() *internal.RedisConfig {
 	return r.config
@@ -82,8 +135,11 @@ func (r *RedisManager) GetConfig() *internal.RedisConfig {
 // 连接管理方法
 // =============================================================================
 
-// Ping 测试 Redis 连接是否正常
+// Ping 测试 Redis 连接是否正常，集群模式下检查所有节点（含从节点）
 func (r *RedisManager) Ping(ctx context.Context) error {
+	if cluster := r.clusterClient(); cluster != nil {
+		return pingCluster(ctx, cluster)
+	}
 	_, err := r.client.Ping(ctx).Result()
 	if err != nil {
 		return fmt.Errorf("Redis 连接失败: %w", err)
@@ -96,11 +152,18 @@ func (r *RedisManager) Close() error {
 	if r.client == nil {
 		return nil
 	}
+	if r.replica != nil {
+		r.replica.Close()
+	}
 	return r.client.Close()
 }
 
 // Info 获取 Redis 服务器信息
+// 集群模式下返回以主节点地址为键的 INFO 原始输出，"raw" 为按地址排序拼接后的全部输出
 func (r *RedisManager) Info(ctx context.Context) (map[string]string, error) {
+	if cluster := r.clusterClient(); cluster != nil {
+		return infoCluster(ctx, cluster)
+	}
 	info, err := r.client.Info(ctx).Result()
 	if err != nil {
 		return nil, fmt.Errorf("获取 Redis 信息失败: %w", err)
@@ -112,8 +175,11 @@ func (r *RedisManager) Info(ctx context.Context) (map[string]string, error) {
 	return infoMap, nil
 }
 
-// FlushDB 清空当前数据库的所有数据（谨慎使用！）
+// FlushDB 清空当前数据库的所有数据（谨慎使用！），集群模式下清空所有主节点
 func (r *RedisManager) FlushDB(ctx context.Context) error {
+	if cluster := r.clusterClient(); cluster != nil {
+		return flushCluster(ctx, cluster)
+	}
 	err := r.client.FlushDB(ctx).Err()
 	if err != nil {
 		return fmt.Errorf("清空数据库失败: %w", err)
@@ -189,7 +255,7 @@ func (r *RedisManager) SetXX(ctx context.Context, key, value string, expiration
 //   - string: 键对应的值
 //   - error: 操作失败时返回错误，键不存在时返回 redis.Nil
 func (r *RedisManager) Get(ctx context.Context, key string) (string, error) {
-	val, err := r.client.Get(ctx, key).Result()
+	val, err := r.reader().Get(ctx, key).Result()
 	if err != nil {
 		if err == redis.Nil {
 			return "", fmt.Errorf("键 %s 不存在", key)
@@ -199,6 +265,25 @@ func (r *RedisManager) Get(ctx context.Context, key string) (string, error) {
 	return val, nil
 }
 
+// MGet 批量获取字符串值，集群模式下键可以分布在任意槽位
+// 参数：
+//   - ctx: 上下文，用于控制超时和取消
+//   - keys: 键名列表
+//
+// 返回：
+//   - []interface{}: 与 keys 顺序一致的值，键不存在（或集群模式下所在槽位失败）时对应位置为 nil
+//   - error: 操作失败时返回错误；集群模式下部分槽位失败时返回 *MultiKeyError，可通过 FailedKeys 区分失败与不存在
+func (r *RedisManager) MGet(ctx context.Context, keys ...string) ([]interface{}, error) {
+	if cluster := r.clusterClient(); cluster != nil {
+		return mgetPerSlot(ctx, cluster, keys)
+	}
+	vals, err := r.reader().MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("批量获取键失败: %w", err)
+	}
+	return vals, nil
+}
+
 // Incr 原子性地增加键的整数值
 // 参数：
 //   - ctx: 上下文，用于控制超时和取消
@@ -246,7 +331,7 @@ func (r *RedisManager) HSet(ctx context.Context, key, field, value string) error
 //   - string: 字段对应的值
 //   - error: 操作失败时返回错误
 func (r *RedisManager) HGet(ctx context.Context, key, field string) (string, error) {
-	val, err := r.client.HGet(ctx, key, field).Result()
+	val, err := r.reader().HGet(ctx, key, field).Result()
 	if err != nil {
 		if err == redis.Nil {
 			return "", fmt.Errorf("哈希 %s 字段 %s 不存在", key, field)
@@ -282,7 +367,7 @@ func (r *RedisManager) HMSet(ctx context.Context, key string, fields map[string]
 //   - []interface{}: 字段值列表，顺序与输入字段顺序一致
 //   - error: 操作失败时返回错误
 func (r *RedisManager) HMGet(ctx context.Context, key string, fields ...string) ([]interface{}, error) {
-	vals, err := r.client.HMGet(ctx, key, fields...).Result()
+	vals, err := r.reader().HMGet(ctx, key, fields...).Result()
 	if err != nil {
 		return nil, fmt.Errorf("批量获取哈希 %s 字段失败: %w", key, err)
 	}
@@ -298,7 +383,7 @@ func (r *RedisManager) HMGet(ctx context.Context, key string, fields ...string)
 //   - map[string]string: 包含所有字段和值的映射
 //   - error: 操作失败时返回错误
 func (r *RedisManager) HGetAll(ctx context.Context, key string) (map[string]string, error) {
-	result, err := r.client.HGetAll(ctx, key).Result()
+	result, err := r.reader().HGetAll(ctx, key).Result()
 	if err != nil {
 		return nil, fmt.Errorf("获取哈希 %s 所有字段失败: %w", key, err)
 	}
@@ -406,7 +491,7 @@ func (r *RedisManager) RPop(ctx context.Context, key string) (string, error) {
 //   - []string: 指定范围的元素列表
 //   - error: 操作失败时返回错误
 func (r *RedisManager) LRange(ctx context.Context, key string, start, stop int64) ([]string, error) {
-	vals, err := r.client.LRange(ctx, key, start, stop).Result()
+	vals, err := r.reader().LRange(ctx, key, start, stop).Result()
 	if err != nil {
 		return nil, fmt.Errorf("获取列表 %s 范围失败: %w", key, err)
 	}
@@ -422,7 +507,7 @@ func (r *RedisManager) LRange(ctx context.Context, key string, start, stop int64
 //   - int64: 列表长度
 //   - error: 操作失败时返回错误
 func (r *RedisManager) LLen(ctx context.Context, key string) (int64, error) {
-	length, err := r.client.LLen(ctx, key).Result()
+	length, err := r.reader().LLen(ctx, key).Result()
 	if err != nil {
 		return 0, fmt.Errorf("获取列表 %s 长度失败: %w", key, err)
 	}
@@ -475,7 +560,7 @@ func (r *RedisManager) SRem(ctx context.Context, key string, members ...interfac
 //   - bool: 如果成员在集合中返回 true，否则返回 false
 //   - error: 操作失败时返回错误
 func (r *RedisManager) SIsMember(ctx context.Context, key string, member interface{}) (bool, error) {
-	isMember, err := r.client.SIsMember(ctx, key, member).Result()
+	isMember, err := r.reader().SIsMember(ctx, key, member).Result()
 	if err != nil {
 		return false, fmt.Errorf("检查集合 %s 成员失败: %w", key, err)
 	}
@@ -491,7 +576,7 @@ func (r *RedisManager) SIsMember(ctx context.Context, key string, member interfa
 //   - []string: 集合中所有成员的列表
 //   - error: 操作失败时返回错误
 func (r *RedisManager) SMembers(ctx context.Context, key string) ([]string, error) {
-	members, err := r.client.SMembers(ctx, key).Result()
+	members, err := r.reader().SMembers(ctx, key).Result()
 	if err != nil {
 		return nil, fmt.Errorf("获取集合 %s 成员失败: %w", key, err)
 	}
@@ -507,7 +592,7 @@ func (r *RedisManager) SMembers(ctx context.Context, key string) ([]string, erro
 //   - int64: 集合中成员的数量
 //   - error: 操作失败时返回错误
 func (r *RedisManager) SCard(ctx context.Context, key string) (int64, error) {
-	count, err := r.client.SCard(ctx, key).Result()
+	count, err := r.reader().SCard(ctx, key).Result()
 	if err != nil {
 		return 0, fmt.Errorf("获取集合 %s 成员数量失败: %w", key, err)
 	}
@@ -561,7 +646,7 @@ func (r *RedisManager) ZRem(ctx context.Context, key string, members ...interfac
 //   - []string: 指定排名范围的成员列表
 //   - error: 操作失败时返回错误
 func (r *RedisManager) ZRange(ctx context.Context, key string, start, stop int64) ([]string, error) {
-	members, err := r.client.ZRange(ctx, key, start, stop).Result()
+	members, err := r.reader().ZRange(ctx, key, start, stop).Result()
 	if err != nil {
 		return nil, fmt.Errorf("获取有序集合 %s 排名范围失败: %w", key, err)
 	}
@@ -579,7 +664,7 @@ func (r *RedisManager) ZRange(ctx context.Context, key string, start, stop int64
 //   - []string: 指定分数范围的成员列表
 //   - error: 操作失败时返回错误
 func (r *RedisManager) ZRangeByScore(ctx context.Context, key string, min, max string) ([]string, error) {
-	members, err := r.client.ZRangeByScore(ctx, key, &redis.ZRangeBy{
+	members, err := r.reader().ZRangeByScore(ctx, key, &redis.ZRangeBy{
 		Min: min,
 		Max: max,
 	}).Result()
@@ -598,7 +683,7 @@ func (r *RedisManager) ZRangeByScore(ctx context.Context, key string, min, max s
 //   - int64: 有序集合中成员的数量
 //   - error: 操作失败时返回错误
 func (r *RedisManager) ZCard(ctx context.Context, key string) (int64, error) {
-	count, err := r.client.ZCard(ctx, key).Result()
+	count, err := r.reader().ZCard(ctx, key).Result()
 	if err != nil {
 		return 0, fmt.Errorf("获取有序集合 %s 成员数量失败: %w", key, err)
 	}
@@ -609,31 +694,54 @@ func (r *RedisManager) ZCard(ctx context.Context, key string) (int64, error) {
 // 键操作方法
 // =============================================================================
 
-// Del 删除一个或多个键
+// Del 删除一个或多个键，集群模式下键可以分布在任意槽位
 // 参数：
 //   - ctx: 上下文，用于控制超时和取消
 //   - keys: 要删除的键名列表
 //
 // 返回：
-//   - error: 操作失败时返回错误
+//   - error: 操作失败时返回错误；集群模式下部分槽位失败时返回 *MultiKeyError，其余槽位上的键已被删除
 func (r *RedisManager) Del(ctx context.Context, keys ...string) error {
-	err := r.client.Del(ctx, keys...).Err()
+	_, err := r.DelCount(ctx, keys...)
+	return err
+}
+
+// DelCount 删除一个或多个键并返回实际删除的数量
+// 参数：
+//   - ctx: 上下文，用于控制超时和取消
+//   - keys: 要删除的键名列表
+//
+// 返回：
+//   - int64: 实际删除的键数量，集群模式下为成功槽位上删除的数量
+//   - error: 操作失败时返回错误；集群模式下部分槽位失败时返回 *MultiKeyError
+func (r *RedisManager) DelCount(ctx context.Context, keys ...string) (int64, error) {
+	if cluster := r.clusterClient(); cluster != nil {
+		return countPerSlot(ctx, cluster, "DEL", keys, func(pipe redis.Pipeliner, keys []string) redis.Cmder {
+			return pipe.Del(ctx, keys...)
+		})
+	}
+	count, err := r.client.Del(ctx, keys...).Result()
 	if err != nil {
-		return fmt.Errorf("删除键失败: %w", err)
+		return 0, fmt.Errorf("删除键失败: %w", err)
 	}
-	return nil
+	return count, nil
 }
 
-// Exists 检查键是否存在
+// Exists 检查键是否存在，集群模式下键可以分布在任意槽位
 // 参数：
 //   - ctx: 上下文，用于控制超时和取消
 //   - keys: 要检查的键名列表
 //
 // 返回：
-//   - int64: 存在的键的数量
-//   - error: 操作失败时返回错误
+//   - int64: 存在的键的数量，集群模式下为成功槽位上存在的数量
+//   - error: 操作失败时返回错误；集群模式下部分槽位失败时返回 *MultiKeyError
 func (r *RedisManager) Exists(ctx context.Context, keys ...string) (int64, error) {
-	count, err := r.client.Exists(ctx, keys...).Result()
+	if cluster := r.clusterClient(); cluster != nil {
+		return countPerSlot(ctx, cluster, "EXISTS", keys, func(pipe redis.Pipeliner, keys []string) redis.Cmder {
+			return pipe.Exists(ctx, keys...)
+		})
+	}
+	count, err := r.reader().Exists(ctx, keys...).Result()
 	if err != nil {
 		return 0, fmt.Errorf("检查键存在性失败: %w", err)
 	}
@@ -665,7 +773,7 @@ func (r *RedisManager) Expire(ctx context.Context, key string, expiration time.D
 //   - time.Duration: 剩余生存时间，-1 表示永不过期，-2 表示键不存在
 //   - error: 操作失败时返回错误
 func (r *RedisManager) TTL(ctx context.Context, key string) (time.Duration, error) {
-	ttl, err := r.client.TTL(ctx, key).Result()
+	ttl, err := r.reader().TTL(ctx, key).Result()
 	if err != nil {
 		return 0, fmt.Errorf("获取键 %s TTL 失败: %w", key, err)
 	}
@@ -681,7 +789,7 @@ func (r *RedisManager) TTL(ctx context.Context, key string) (time.Duration, erro
 //   - string: 键的数据类型（string, list, set, zset, hash, none）
 //   - error: 操作失败时返回错误
 func (r *RedisManager) Type(ctx context.Context, key string) (string, error) {
-	keyType, err := r.client.Type(ctx, key).Result()
+	keyType, err := r.reader().Type(ctx, key).Result()
 	if err != nil {
 		return "", fmt.Errorf("获取键 %s 类型失败: %w", key, err)
 	}
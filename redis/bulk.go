@@ -0,0 +1,177 @@
+package redis
+
+// 按模式批量删除与设置过期时间
+//
+// 场景说明：
+//   清理 cache:user:* 这类缓存键时，KEYS + DEL 会长时间阻塞服务端，删除大键时 DEL 还会在主线程同步释放内存。
+//   DeleteByPattern/ExpireByPattern 基于 SCAN 迭代器逐批取出匹配的键，每批通过一个 Pipeline 发送 UNLINK
+//   （后台线程释放内存）或 EXPIRE，并按 MaxKeysPerSecond 限速，避免清理任务挤占正常业务的处理能力。
+//   集群模式下 SCAN 会依次遍历每个主节点，每条命令只涉及一个键，不受跨槽位限制。
+//
+// 注意：
+//   - 先用 DryRun 确认匹配的键数量，再执行真正的操作；
+//   - 遍历期间新写入的匹配键可能不会被处理，需要完全清理时可以在结束后再执行一次；
+//   - 某一批执行失败时立即停止并返回已完成的部分，失败的键记录在结果的 FailedKeys 中，可直接重新执行。
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// PatternOptions 按模式批量操作的配置
+type PatternOptions struct {
+	BatchSize        int    // 每批（一个 Pipeline）处理的键数量，同时作为 SCAN 的 COUNT，默认为 500
+	MaxKeysPerSecond int    // 每秒最多处理的键数量，0 表示不限速；DryRun 时同样限制扫描速度
+	Type             string // 只处理指定类型的键（SCAN TYPE），为空时不限类型
+	DryRun           bool   // 只统计匹配的键数量，不修改数据
+	OnProgress       func(progress PatternProgress)
+}
+
+// PatternProgress 批量操作进度，每批完成后回调
+type PatternProgress struct {
+	Matched  int64         // 已扫描到的匹配键数量
+	Affected int64         // 实际删除或设置过期时间的键数量
+	Elapsed  time.Duration // 已耗时
+	DryRun   bool
+}
+
+// PatternResult 批量操作结果
+type PatternResult struct {
+	Matched    int64    // 匹配的键数量
+	Affected   int64    // 实际删除或设置过期时间的键数量（扫描后被删除或过期的键不计入），DryRun 时为 0
+	FailedKeys []string // 执行失败的键
+	DryRun     bool
+}
+
+// DeleteByPattern 删除匹配模式的所有键（UNLINK）
+// 参数：
+//   - ctx: 上下文，取消后在当前批次结束时停止
+//   - pattern: 键的 glob 模式，如 cache:user:*；不能为空，需要删除全部键时显式传入 *
+//   - opts: 执行配置，为 nil 时使用默认配置
+//
+// 返回：
+//   - *PatternResult: 执行结果，出错时包含已完成的部分
+//   - error: 扫描或执行失败时返回错误
+func (r *RedisManager) DeleteByPattern(ctx context.Context, pattern string, opts *PatternOptions) (*PatternResult, error) {
+	return r.processByPattern(ctx, pattern, opts, "删除", func(key string) BatchOperation {
+		return func(ctx context.Context, pipe redis.Pipeliner) redis.Cmder {
+			return pipe.Unlink(ctx, key)
+		}
+	}, func(cmd redis.Cmder) bool {
+		return cmd.(*redis.IntCmd).Val() > 0
+	})
+}
+
+// ExpireByPattern 为匹配模式的所有键设置过期时间
+// 参数：
+//   - ctx: 上下文，取消后在当前批次结束时停止
+//   - pattern: 键的 glob 模式；不能为空
+//   - expiration: 过期时间，必须大于 0
+//   - opts: 执行配置，为 nil 时使用默认配置
+//
+// 返回：
+//   - *PatternResult: 执行结果，出错时包含已完成的部分
+//   - error: 参数不合法、扫描或执行失败时返回错误
+func (r *RedisManager) ExpireByPattern(ctx context.Context, pattern string, expiration time.Duration, opts *PatternOptions) (*PatternResult, error) {
+	if expiration <= 0 {
+		return &PatternResult{}, errors.New("过期时间必须大于 0")
+	}
+	return r.processByPattern(ctx, pattern, opts, "设置过期时间", func(key string) BatchOperation {
+		return func(ctx context.Context, pipe redis.Pipeliner) redis.Cmder {
+			return pipe.Expire(ctx, key, expiration)
+		}
+	}, func(cmd redis.Cmder) bool {
+		return cmd.(*redis.BoolCmd).Val()
+	})
+}
+
+// processByPattern 扫描匹配的键并逐批执行操作
+func (r *RedisManager) processByPattern(ctx context.Context, pattern string, opts *PatternOptions, action string, build func(key string) BatchOperation, affected func(cmd redis.Cmder) bool) (*PatternResult, error) {
+	o := PatternOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.BatchSize <= 0 {
+		o.BatchSize = DefaultBatchChunkSize
+	}
+	result := &PatternResult{DryRun: o.DryRun}
+	if pattern == "" {
+		return result, errors.New("键模式不能为空")
+	}
+
+	start := time.Now()
+	batch := make([]string, 0, o.BatchSize)
+	flush := func() error {
+		result.Matched += int64(len(batch))
+		if !o.DryRun {
+			ops := make([]BatchOperation, len(batch))
+			for i, key := range batch {
+				ops[i] = build(key)
+			}
+			results, err := r.ExecBatch(ctx, ops, &BatchOptions{ChunkSize: len(ops)})
+			for _, res := range results {
+				if res.Err != nil {
+					result.FailedKeys = append(result.FailedKeys, batch[res.Index])
+				} else if affected(res.Cmd) {
+					result.Affected++
+				}
+			}
+			if err != nil {
+				return fmt.Errorf("%s匹配 %s 的键失败: %w", action, pattern, err)
+			}
+		}
+		batch = batch[:0]
+
+		if o.OnProgress != nil {
+			o.OnProgress(PatternProgress{
+				Matched:  result.Matched,
+				Affected: result.Affected,
+				Elapsed:  time.Since(start),
+				DryRun:   o.DryRun,
+			})
+		}
+		return throttle(ctx, start, result.Matched, o.MaxKeysPerSecond)
+	}
+
+	scanOpts := &ScanOptions{Match: pattern, Count: int64(o.BatchSize), Type: o.Type}
+	for key, err := range r.Scan(ctx, scanOpts) {
+		if err != nil {
+			return result, err
+		}
+		batch = append(batch, key)
+		if len(batch) == o.BatchSize {
+			if err := flush(); err != nil {
+				return result, err
+			}
+		}
+	}
+	if len(batch) > 0 {
+		if err := flush(); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// throttle 按速率限制等待：处理 processed 个键至少需要 processed/rate 秒
+func throttle(ctx context.Context, start time.Time, processed int64, rate int) error {
+	if rate <= 0 {
+		return nil
+	}
+	wait := time.Duration(processed)*time.Second/time.Duration(rate) - time.Since(start)
+	if wait <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
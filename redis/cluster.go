@@ -1 +1,318 @@
 package redis
+
+// Redis Cluster 管理器
+//
+// 场景说明：
+//   生产环境使用 Redis Cluster，开发环境使用单机 Redis。RedisManager 内部持有 redis.UniversalClient，
+//   在 RedisConfig 中设置 Cluster 即可直接以集群模式工作，下面列出的差异由 RedisManager 自动处理。
+//   ClusterManager 嵌入 RedisManager 复用全部操作方法，额外提供类型化的 ClusterClient 与拓扑、槽位迁移等
+//   集群专属功能，两者实现同一个 internal.RedisOperator 接口，业务代码依赖接口即可在不同拓扑间切换。
+//
+// 与单机模式的差异：
+//   - 只有 0 号数据库；FlushDB、Ping 会作用于所有主节点（或所有节点）；
+//   - DEL/EXISTS/MGET 会按槽位拆分后并发执行再按输入顺序合并结果，部分槽位失败时返回 *MultiKeyError；
+//     事务与 Lua 脚本的键仍必须位于同一哈希槽，可以使用 {hash tag} 让相关的键落在同一槽位；
+//   - 开启 ReadOnly/RouteByLatency/RouteRandomly 后只读命令可能读到从节点上稍旧的数据。
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/yann0917/redis-usage/internal"
+)
+
+// 编译期检查：ClusterManager 与 RedisManager 实现同一接口
+var (
+	_ internal.RedisOperator = (*RedisManager)(nil)
+	_ internal.RedisOperator = (*ClusterManager)(nil)
+)
+
+// ClusterManager Redis Cluster 管理器，封装 Redis Cluster 操作
+type ClusterManager struct {
+	*RedisManager
+	cluster *redis.ClusterClient
+	config  *internal.ClusterConfig
+}
+
+// NewClusterManager 创建新的 Redis Cluster 管理器实例
+// 参数：
+//   - config: 集群配置，为 nil 时使用默认配置
+//
+// 返回：
+//   - *ClusterManager: 集群管理器实例
+//   - error: 创建失败时返回错误
+func NewClusterManager(config *internal.ClusterConfig) (*ClusterManager, error) {
+	if config == nil {
+		config = internal.DefaultClusterConfig()
+	}
+	if len(config.Addrs) == 0 {
+		return nil, fmt.Errorf("集群种子节点地址不能为空")
+	}
+
+	client := redis.NewClusterClient(config.ClusterOptions())
+
+	manager := NewClusterManagerWithClient(client)
+	manager.config = config
+
+	// 测试连接
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := manager.Ping(ctx); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("Redis Cluster 连接测试失败: %w", err)
+	}
+
+	return manager, nil
+}
+
+// NewClusterManagerWithClient 使用现有的集群客户端创建管理器（用于测试或特殊场景）
+// 参数：
+//   - client: 现有的集群客户端
+//
+// 返回：
+//   - *ClusterManager: 集群管理器实例
+func NewClusterManagerWithClient(client *redis.ClusterClient) *ClusterManager {
+	return &ClusterManager{
+		RedisManager: &RedisManager{client: client},
+		cluster:      client,
+		config:       nil, // 外部客户端不管理配置
+	}
+}
+
+// GetClient 获取底层的集群客户端（用于高级操作）
+func (c *ClusterManager) GetClient() *redis.ClusterClient {
+	return c.cluster
+}
+
+// GetConfig 获取集群配置信息
+func (c *ClusterManager) GetConfig() *internal.ClusterConfig {
+	return c.config
+}
+
+// =============================================================================
+// 集群连接管理
+// =============================================================================
+
+// pingCluster 测试集群中所有节点（含从节点）的连接是否正常
+func pingCluster(ctx context.Context, cluster *redis.ClusterClient) error {
+	err := cluster.ForEachShard(ctx, func(ctx context.Context, node *redis.Client) error {
+		if err := node.Ping(ctx).Err(); err != nil {
+			return fmt.Errorf("节点 %s: %w", node.Options().Addr, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("Redis Cluster 连接失败: %w", err)
+	}
+	return nil
+}
+
+// infoCluster 获取所有主节点的服务器信息
+// 返回：
+//   - map[string]string: 以节点地址为键的 INFO 原始输出，"raw" 为按地址排序拼接后的全部输出
+//   - error: 任一节点失败时返回错误
+func infoCluster(ctx context.Context, cluster *redis.ClusterClient) (map[string]string, error) {
+	var mu sync.Mutex
+	infoMap := make(map[string]string)
+	err := cluster.ForEachMaster(ctx, func(ctx context.Context, node *redis.Client) error {
+		info, err := node.Info(ctx).Result()
+		if err != nil {
+			return fmt.Errorf("节点 %s: %w", node.Options().Addr, err)
+		}
+		mu.Lock()
+		infoMap[node.Options().Addr] = info
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("获取 Redis 信息失败: %w", err)
+	}
+
+	addrs := make([]string, 0, len(infoMap))
+	for addr := range infoMap {
+		addrs = append(addrs, addr)
+	}
+	sort.Strings(addrs)
+	var raw strings.Builder
+	for _, addr := range addrs {
+		fmt.Fprintf(&raw, "# Node %s\r\n%s\r\n", addr, infoMap[addr])
+	}
+	infoMap["raw"] = raw.String()
+	return infoMap, nil
+}
+
+// flushCluster 清空所有主节点的数据
+func flushCluster(ctx context.Context, cluster *redis.ClusterClient) error {
+	err := cluster.ForEachMaster(ctx, func(ctx context.Context, node *redis.Client) error {
+		if err := node.FlushDB(ctx).Err(); err != nil {
+			return fmt.Errorf("节点 %s: %w", node.Options().Addr, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("清空数据库失败: %w", err)
+	}
+	return nil
+}
+
+// =============================================================================
+// 跨槽位多键操作
+// =============================================================================
+
+// SlotFailure 一个槽位上的多键命令执行失败
+type SlotFailure struct {
+	Slot int      // 槽位
+	Node string   // 执行命令的主节点地址，无法确定时为空
+	Keys []string // 该槽位上受影响的键
+	Err  error    // 失败原因
+}
+
+// MultiKeyError 跨槽位多键操作部分失败，成功槽位上的结果仍然有效
+type MultiKeyError struct {
+	Command  string        // 命令名，如 DEL
+	Total    int           // 键的总数
+	Failures []SlotFailure // 失败的槽位，按槽位升序
+}
+
+func (e *MultiKeyError) Error() string {
+	parts := make([]string, 0, len(e.Failures))
+	for _, f := range e.Failures {
+		node := f.Node
+		if node == "" {
+			node = "未知节点"
+		}
+		parts = append(parts, fmt.Sprintf("槽位 %d（%s，%d 个键）: %v", f.Slot, node, len(f.Keys), f.Err))
+	}
+	return fmt.Sprintf("%s 部分失败，%d/%d 个键未完成: %s", e.Command, len(e.FailedKeys()), e.Total, strings.Join(parts, "; "))
+}
+
+// Unwrap 返回所有失败槽位的原始错误，便于 errors.Is/As 判断
+func (e *MultiKeyError) Unwrap() []error {
+	errs := make([]error, len(e.Failures))
+	for i, f := range e.Failures {
+		errs[i] = f.Err
+	}
+	return errs
+}
+
+// FailedKeys 返回所有未完成的键
+func (e *MultiKeyError) FailedKeys() []string {
+	var keys []string
+	for _, f := range e.Failures {
+		keys = append(keys, f.Keys...)
+	}
+	return keys
+}
+
+// slotBatch 同一槽位的键及其在输入中的下标
+type slotBatch struct {
+	slot    int
+	keys    []string
+	indices []int
+}
+
+// splitBySlot 按槽位拆分键，结果按槽位升序排列
+func splitBySlot(keys []string) []*slotBatch {
+	batches := make(map[int]*slotBatch)
+	for i, key := range keys {
+		slot := KeySlot(key)
+		b, ok := batches[slot]
+		if !ok {
+			b = &slotBatch{slot: slot}
+			batches[slot] = b
+		}
+		b.keys = append(b.keys, key)
+		b.indices = append(b.indices, i)
+	}
+
+	result := make([]*slotBatch, 0, len(batches))
+	for _, b := range batches {
+		result = append(result, b)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].slot < result[j].slot })
+	return result
+}
+
+// execPerSlot 每个槽位执行一条多键命令
+// ClusterClient 的管道会按节点拆分命令、并发地向各节点发送，并自动处理 MOVED/ASK 重定向
+// 返回：
+//   - []redis.Cmder: 与 batches 一一对应的命令
+//   - error: 部分槽位失败时返回 *MultiKeyError
+func execPerSlot(ctx context.Context, cluster *redis.ClusterClient, command string, keys []string, batches []*slotBatch, build func(pipe redis.Pipeliner, keys []string) redis.Cmder) ([]redis.Cmder, error) {
+	cmds := make([]redis.Cmder, len(batches))
+	_, _ = cluster.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, b := range batches {
+			cmds[i] = build(pipe, b.keys)
+		}
+		return nil
+	})
+
+	var failures []SlotFailure
+	for i, cmd := range cmds {
+		if err := cmd.Err(); err != nil && !errors.Is(err, redis.Nil) {
+			failures = append(failures, SlotFailure{
+				Slot: batches[i].slot,
+				Node: masterAddr(ctx, cluster, batches[i].keys[0]),
+				Keys: batches[i].keys,
+				Err:  err,
+			})
+		}
+	}
+	if len(failures) > 0 {
+		return cmds, &MultiKeyError{Command: command, Total: len(keys), Failures: failures}
+	}
+	return cmds, nil
+}
+
+// masterAddr 返回键所在槽位当前主节点的地址，无法确定时返回空字符串
+func masterAddr(ctx context.Context, cluster *redis.ClusterClient, key string) string {
+	node, err := cluster.MasterForKey(ctx, key)
+	if err != nil {
+		return ""
+	}
+	return node.Options().Addr
+}
+
+// countPerSlot 按槽位执行返回整数的多键命令并累加各槽位的结果
+func countPerSlot(ctx context.Context, cluster *redis.ClusterClient, command string, keys []string, build func(pipe redis.Pipeliner, keys []string) redis.Cmder) (int64, error) {
+	if len(keys) == 0 {
+		return 0, nil
+	}
+	cmds, err := execPerSlot(ctx, cluster, command, keys, splitBySlot(keys), build)
+	var total int64
+	for _, cmd := range cmds {
+		if cmd.Err() == nil {
+			total += cmd.(*redis.IntCmd).Val()
+		}
+	}
+	return total, err
+}
+
+// mgetPerSlot 按槽位执行 MGET 并按输入顺序合并结果
+func mgetPerSlot(ctx context.Context, cluster *redis.ClusterClient, keys []string) ([]interface{}, error) {
+	vals := make([]interface{}, len(keys))
+	if len(keys) == 0 {
+		return vals, nil
+	}
+
+	batches := splitBySlot(keys)
+	cmds, err := execPerSlot(ctx, cluster, "MGET", keys, batches, func(pipe redis.Pipeliner, keys []string) redis.Cmder {
+		return pipe.MGet(ctx, keys...)
+	})
+	for i, cmd := range cmds {
+		if cmd.Err() != nil {
+			continue
+		}
+		for j, val := range cmd.(*redis.SliceCmd).Val() {
+			vals[batches[i].indices[j]] = val
+		}
+	}
+	return vals, err
+}
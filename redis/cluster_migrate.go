@@ -0,0 +1,336 @@
+package redis
+
+// Redis Cluster 槽位迁移
+//
+// 场景说明：
+//   扩容或缩容时需要在不停服的情况下把槽位从一个主节点迁移到另一个主节点。SlotMigrator 按 redis-cli --cluster reshard
+//   的标准流程逐个迁移槽位：
+//   1. 目标节点 CLUSTER SETSLOT <slot> IMPORTING <source-id>；
+//   2. 源节点 CLUSTER SETSLOT <slot> MIGRATING <target-id>；
+//   3. 循环 CLUSTER GETKEYSINSLOT 取出一批键，使用 MIGRATE ... KEYS 批量迁移，直到槽位为空；
+//   4. 依次在目标节点、源节点与其余主节点上执行 CLUSTER SETSLOT <slot> NODE <target-id>。
+//   迁移期间客户端访问尚未迁移的键由源节点处理，已迁移的键收到 ASK 重定向，go-redis 会自动跟随。
+//
+// 注意：
+//   - 每批键迁移后触发进度回调，并检查是否被暂停；暂停只发生在批次之间，不会中断进行中的 MIGRATE；
+//   - 迁移失败（如目标节点已存在同名键返回 BUSYKEY）时槽位保持 MIGRATING/IMPORTING 状态，
+//     修复问题后用相同参数重新执行即可继续；已归属目标节点的槽位会被跳过；
+//   - DryRun 模式只统计每个槽位的键数量，不修改集群状态。
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// SlotMigrationOptions 槽位迁移配置
+type SlotMigrationOptions struct {
+	BatchSize  int           // 每批迁移的键数量，默认为 100
+	Timeout    time.Duration // 单次 MIGRATE 的超时时间，默认为 5 秒
+	Replace    bool          // 目标节点存在同名键时是否覆盖（MIGRATE REPLACE）
+	DryRun     bool          // 只统计待迁移的键，不修改集群
+	OnProgress func(progress SlotMigrationProgress)
+}
+
+// SlotMigrationProgress 迁移进度
+type SlotMigrationProgress struct {
+	Slot          int   // 当前槽位
+	SlotKeysMoved int64 // 当前槽位已迁移的键数量（DryRun 时为待迁移数量）
+	SlotDone      bool  // 当前槽位是否已完成
+	SlotsDone     int   // 已完成的槽位数量
+	SlotsTotal    int   // 需要处理的槽位总数
+	KeysMoved     int64 // 累计迁移的键数量
+	DryRun        bool  // 是否为演练模式
+}
+
+// SlotMigrationResult 迁移结果
+type SlotMigrationResult struct {
+	Migrated  []int // 已迁移到目标节点的槽位（DryRun 时为将要迁移的槽位）
+	Skipped   []int // 已归属目标节点而跳过的槽位
+	KeysMoved int64 // 迁移的键数量（DryRun 时为待迁移数量）
+	DryRun    bool
+}
+
+// SlotMigrator 槽位迁移器
+type SlotMigrator struct {
+	manager *ClusterManager
+	opts    SlotMigrationOptions
+
+	mu       sync.Mutex
+	paused   bool
+	resumeCh chan struct{}
+}
+
+// NewSlotMigrator 创建槽位迁移器
+// 参数：
+//   - manager: 集群管理器
+//   - opts: 迁移配置，为 nil 时使用默认配置
+//
+// 返回：
+//   - *SlotMigrator: 迁移器实例
+func NewSlotMigrator(manager *ClusterManager, opts *SlotMigrationOptions) *SlotMigrator {
+	o := SlotMigrationOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.BatchSize <= 0 {
+		o.BatchSize = 100
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = 5 * time.Second
+	}
+	return &SlotMigrator{manager: manager, opts: o}
+}
+
+// Pause 暂停迁移，当前批次完成后生效
+func (m *SlotMigrator) Pause() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.paused {
+		m.paused = true
+		m.resumeCh = make(chan struct{})
+	}
+}
+
+// Resume 恢复被暂停的迁移
+func (m *SlotMigrator) Resume() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.paused {
+		m.paused = false
+		close(m.resumeCh)
+	}
+}
+
+// Paused 返回迁移是否处于暂停状态
+func (m *SlotMigrator) Paused() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.paused
+}
+
+// waitIfPaused 暂停时阻塞直到恢复或 ctx 结束
+func (m *SlotMigrator) waitIfPaused(ctx context.Context) error {
+	m.mu.Lock()
+	if !m.paused {
+		m.mu.Unlock()
+		return nil
+	}
+	resumeCh := m.resumeCh
+	m.mu.Unlock()
+
+	select {
+	case <-resumeCh:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// migrationNode 参与迁移的主节点
+type migrationNode struct {
+	id     string
+	addr   string
+	client *redis.Client
+}
+
+// Migrate 将槽位区间迁移到目标主节点
+// 参数：
+//   - ctx: 上下文，取消后在当前批次结束时停止，槽位保持迁移中状态，可重新执行继续
+//   - slots: 待迁移的槽位区间
+//   - target: 目标主节点的 ID 或地址（host:port）
+//
+// 返回：
+//   - *SlotMigrationResult: 迁移结果，出错时包含已完成的部分
+//   - error: 迁移失败时返回错误
+func (m *SlotMigrator) Migrate(ctx context.Context, slots SlotRange, target string) (*SlotMigrationResult, error) {
+	result := &SlotMigrationResult{DryRun: m.opts.DryRun}
+	if slots.Start < 0 || slots.End >= ClusterSlots || slots.Start > slots.End {
+		return result, fmt.Errorf("槽位区间 %s 无效", slots)
+	}
+
+	masters, err := m.masters(ctx)
+	if err != nil {
+		return result, err
+	}
+	var dst *migrationNode
+	for _, node := range masters {
+		if node.id == target || node.addr == target {
+			dst = node
+		}
+	}
+	if dst == nil {
+		return result, fmt.Errorf("目标主节点 %s 不存在", target)
+	}
+
+	topology, err := m.manager.Topology(ctx)
+	if err != nil {
+		return result, err
+	}
+	owners := slotOwners(topology)
+
+	progress := SlotMigrationProgress{SlotsTotal: slots.Count(), DryRun: m.opts.DryRun}
+	for slot := slots.Start; slot <= slots.End; slot++ {
+		if err := m.waitIfPaused(ctx); err != nil {
+			return result, err
+		}
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		ownerID := owners[slot]
+		if ownerID == dst.id {
+			result.Skipped = append(result.Skipped, slot)
+			progress.SlotsDone++
+			continue
+		}
+		src := masters[ownerID]
+		if src == nil {
+			return result, fmt.Errorf("槽位 %d 没有可用的主节点，无法迁移", slot)
+		}
+
+		progress.Slot = slot
+		progress.SlotKeysMoved = 0
+		progress.SlotDone = false
+		if m.opts.DryRun {
+			count, err := src.client.ClusterCountKeysInSlot(ctx, slot).Result()
+			if err != nil {
+				return result, fmt.Errorf("统计槽位 %d 的键数量失败: %w", slot, err)
+			}
+			progress.SlotKeysMoved = count
+			progress.KeysMoved += count
+		} else if err := m.migrateSlot(ctx, slot, src, dst, masters, &progress); err != nil {
+			result.KeysMoved = progress.KeysMoved
+			return result, err
+		}
+
+		result.Migrated = append(result.Migrated, slot)
+		result.KeysMoved = progress.KeysMoved
+		progress.SlotsDone++
+		progress.SlotDone = true
+		m.report(progress)
+	}
+
+	if !m.opts.DryRun && len(result.Migrated) > 0 {
+		m.manager.cluster.ReloadState(ctx)
+	}
+	return result, nil
+}
+
+// migrateSlot 迁移单个槽位
+func (m *SlotMigrator) migrateSlot(ctx context.Context, slot int, src, dst *migrationNode, masters map[string]*migrationNode, progress *SlotMigrationProgress) error {
+	// 先在目标节点标记 IMPORTING，再在源节点标记 MIGRATING，避免出现源节点重定向而目标节点拒绝的窗口
+	if err := dst.client.Do(ctx, "CLUSTER", "SETSLOT", slot, "IMPORTING", src.id).Err(); err != nil {
+		return fmt.Errorf("目标节点 %s 设置槽位 %d IMPORTING 失败: %w", dst.addr, slot, err)
+	}
+	if err := src.client.Do(ctx, "CLUSTER", "SETSLOT", slot, "MIGRATING", dst.id).Err(); err != nil {
+		return fmt.Errorf("源节点 %s 设置槽位 %d MIGRATING 失败: %w", src.addr, slot, err)
+	}
+
+	host, port, err := net.SplitHostPort(dst.addr)
+	if err != nil {
+		return fmt.Errorf("解析目标节点地址 %s 失败: %w", dst.addr, err)
+	}
+	for {
+		keys, err := src.client.ClusterGetKeysInSlot(ctx, slot, m.opts.BatchSize).Result()
+		if err != nil {
+			return fmt.Errorf("获取槽位 %d 的键失败: %w", slot, err)
+		}
+		if len(keys) == 0 {
+			break
+		}
+
+		args := []interface{}{"MIGRATE", host, port, "", 0, m.opts.Timeout.Milliseconds()}
+		if m.opts.Replace {
+			args = append(args, "REPLACE")
+		}
+		if clusterOpts := m.manager.cluster.Options(); clusterOpts.Username != "" {
+			args = append(args, "AUTH2", clusterOpts.Username, clusterOpts.Password)
+		} else if clusterOpts.Password != "" {
+			args = append(args, "AUTH", clusterOpts.Password)
+		}
+		args = append(args, "KEYS")
+		for _, key := range keys {
+			args = append(args, key)
+		}
+		if err := src.client.Do(ctx, args...).Err(); err != nil {
+			return fmt.Errorf("迁移槽位 %d 的 %d 个键到 %s 失败: %w", slot, len(keys), dst.addr, err)
+		}
+
+		progress.SlotKeysMoved += int64(len(keys))
+		progress.KeysMoved += int64(len(keys))
+		m.report(*progress)
+
+		if err := m.waitIfPaused(ctx); err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+
+	// 先通知目标节点与源节点，再广播给其余主节点，确保新归属尽快生效
+	notify := []*migrationNode{dst, src}
+	for id, node := range masters {
+		if id != dst.id && id != src.id {
+			notify = append(notify, node)
+		}
+	}
+	for _, node := range notify {
+		if err := node.client.Do(ctx, "CLUSTER", "SETSLOT", slot, "NODE", dst.id).Err(); err != nil {
+			return fmt.Errorf("节点 %s 设置槽位 %d 归属失败: %w", node.addr, slot, err)
+		}
+	}
+	return nil
+}
+
+// masters 返回所有主节点，以节点 ID 为键
+func (m *SlotMigrator) masters(ctx context.Context) (map[string]*migrationNode, error) {
+	var mu sync.Mutex
+	masters := make(map[string]*migrationNode)
+	err := m.manager.cluster.ForEachMaster(ctx, func(ctx context.Context, client *redis.Client) error {
+		id, err := client.ClusterMyID(ctx).Result()
+		if err != nil {
+			return fmt.Errorf("查询节点 %s 的 ID 失败: %w", client.Options().Addr, err)
+		}
+		mu.Lock()
+		masters[id] = &migrationNode{id: id, addr: client.Options().Addr, client: client}
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(masters) == 0 {
+		return nil, errors.New("集群中没有可用的主节点")
+	}
+	return masters, nil
+}
+
+// slotOwners 返回每个槽位当前的主节点 ID
+func slotOwners(topology *ClusterTopology) []string {
+	owners := make([]string, ClusterSlots)
+	for _, node := range topology.Masters() {
+		if node.Failed() {
+			continue
+		}
+		for _, r := range node.Slots {
+			for slot := r.Start; slot <= r.End; slot++ {
+				owners[slot] = node.ID
+			}
+		}
+	}
+	return owners
+}
+
+// report 触发进度回调
+func (m *SlotMigrator) report(progress SlotMigrationProgress) {
+	if m.opts.OnProgress != nil {
+		m.opts.OnProgress(progress)
+	}
+}
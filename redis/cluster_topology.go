@@ -0,0 +1,448 @@
+package redis
+
+// Redis Cluster 拓扑检查
+//
+// 场景说明：
+//   节点故障时需要尽快知道哪些槽位失去了可用的主节点、主节点之间槽位是否失衡、哪些从节点复制落后。
+//   这里将 CLUSTER SHARDS（Redis 7+）、CLUSTER SLOTS 与 CLUSTER NODES 的输出解析为统一的 ClusterTopology，
+//   再由 Report 生成覆盖率报告。解析函数只依赖命令输出，可以直接用录制的输出进行测试。
+//
+// 数据来源差异：
+//   - CLUSTER SHARDS 包含复制偏移量与节点健康状态，是首选来源；
+//   - CLUSTER NODES 包含标志位、链路状态与配置纪元，但没有复制偏移量；
+//   - CLUSTER SLOTS 只包含槽位与节点地址（第一个为主节点），无法判断节点是否故障。
+//
+// 注意：
+//   - 只有 fail 标志（集群多数主节点达成共识）才视为故障；fail?（PFAIL）与链路断开只是被查询节点的视角，
+//     会在网络抖动时短暂出现，报告中单独列为疑似故障，不影响槽位覆盖与 Healthy 的判断。
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// 节点角色
+const (
+	NodeRoleMaster  = "master"
+	NodeRoleReplica = "replica"
+)
+
+// SlotRange 连续的槽位区间（含两端）
+type SlotRange struct {
+	Start int
+	End   int
+}
+
+// Count 返回区间内的槽位数量
+func (r SlotRange) Count() int {
+	return r.End - r.Start + 1
+}
+
+func (r SlotRange) String() string {
+	if r.Start == r.End {
+		return strconv.Itoa(r.Start)
+	}
+	return fmt.Sprintf("%d-%d", r.Start, r.End)
+}
+
+// ClusterNodeInfo 集群节点信息
+type ClusterNodeInfo struct {
+	ID                string      // 节点 ID
+	Addr              string      // 客户端地址 host:port
+	Role              string      // NodeRoleMaster 或 NodeRoleReplica
+	MasterID          string      // 从节点对应的主节点 ID，主节点为空
+	Flags             []string    // CLUSTER NODES 中的标志位，如 myself、fail、fail?
+	LinkState         string      // 集群总线链路状态：connected/disconnected，来源不提供时为空
+	Health            string      // CLUSTER SHARDS 中的健康状态：online/failed/loading，来源不提供时为空
+	ConfigEpoch       int64       // 配置纪元
+	ReplicationOffset int64       // 复制偏移量，来源不提供时为 -1
+	Slots             []SlotRange // 主节点负责的槽位
+}
+
+// Failed 判断节点是否已被集群确认故障（fail 标志、没有地址或健康状态为 failed）
+func (n *ClusterNodeInfo) Failed() bool {
+	for _, flag := range n.Flags {
+		if flag == "fail" || flag == "noaddr" {
+			return true
+		}
+	}
+	return n.Health == "failed"
+}
+
+// Suspected 判断节点是否疑似故障（fail? 标志或链路断开）
+// 这两种状态只代表被查询节点的视角，集群尚未达成共识，节点可能仍在正常服务
+func (n *ClusterNodeInfo) Suspected() bool {
+	if n.Failed() {
+		return false
+	}
+	for _, flag := range n.Flags {
+		if flag == "fail?" {
+			return true
+		}
+	}
+	return n.LinkState == "disconnected"
+}
+
+// SlotCount 返回节点负责的槽位数量
+func (n *ClusterNodeInfo) SlotCount() int {
+	count := 0
+	for _, r := range n.Slots {
+		count += r.Count()
+	}
+	return count
+}
+
+// ClusterTopology 集群拓扑
+type ClusterTopology struct {
+	Nodes []*ClusterNodeInfo
+}
+
+// Masters 返回所有主节点
+func (t *ClusterTopology) Masters() []*ClusterNodeInfo {
+	var masters []*ClusterNodeInfo
+	for _, n := range t.Nodes {
+		if n.Role == NodeRoleMaster {
+			masters = append(masters, n)
+		}
+	}
+	return masters
+}
+
+// ReplicasOf 返回指定主节点的所有从节点
+func (t *ClusterTopology) ReplicasOf(masterID string) []*ClusterNodeInfo {
+	var replicas []*ClusterNodeInfo
+	for _, n := range t.Nodes {
+		if n.Role == NodeRoleReplica && n.MasterID == masterID {
+			replicas = append(replicas, n)
+		}
+	}
+	return replicas
+}
+
+// node 按 ID 查找节点
+func (t *ClusterTopology) node(id string) *ClusterNodeInfo {
+	for _, n := range t.Nodes {
+		if n.ID == id {
+			return n
+		}
+	}
+	return nil
+}
+
+// appendSlot 将槽位追加到按顺序排列的区间列表，与最后一个区间相邻时合并
+func appendSlot(ranges []SlotRange, slot int) []SlotRange {
+	if last := len(ranges) - 1; last >= 0 && ranges[last].End == slot-1 {
+		ranges[last].End = slot
+		return ranges
+	}
+	return append(ranges, SlotRange{Start: slot, End: slot})
+}
+
+// =============================================================================
+// 解析
+// =============================================================================
+
+// ParseClusterNodes 解析 CLUSTER NODES 的文本输出
+// 每行格式：<id> <ip:port@cport[,hostname]> <flags> <master> <ping-sent> <pong-recv> <config-epoch> <link-state> <slot> ...
+// 正在迁移的槽位（[slot->-id] 与 [slot-<-id]）不计入节点负责的槽位
+func ParseClusterNodes(output string) (*ClusterTopology, error) {
+	topology := &ClusterTopology{}
+	for lineNo, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 8 {
+			return nil, fmt.Errorf("CLUSTER NODES 第 %d 行字段不足: %q", lineNo+1, line)
+		}
+
+		node := &ClusterNodeInfo{
+			ID:                fields[0],
+			Addr:              nodeAddr(fields[1]),
+			Flags:             strings.Split(fields[2], ","),
+			LinkState:         fields[7],
+			ReplicationOffset: -1,
+		}
+		node.Role = NodeRoleMaster
+		for _, flag := range node.Flags {
+			if flag == "slave" || flag == "replica" {
+				node.Role = NodeRoleReplica
+			}
+		}
+		if fields[3] != "-" {
+			node.MasterID = fields[3]
+		}
+		epoch, err := strconv.ParseInt(fields[6], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("CLUSTER NODES 第 %d 行配置纪元无效: %w", lineNo+1, err)
+		}
+		node.ConfigEpoch = epoch
+
+		for _, field := range fields[8:] {
+			if strings.HasPrefix(field, "[") {
+				continue
+			}
+			r, err := parseSlotRange(field)
+			if err != nil {
+				return nil, fmt.Errorf("CLUSTER NODES 第 %d 行槽位无效: %w", lineNo+1, err)
+			}
+			node.Slots = append(node.Slots, r)
+		}
+		topology.Nodes = append(topology.Nodes, node)
+	}
+	return topology, nil
+}
+
+// nodeAddr 从 ip:port@cport[,hostname] 中提取客户端地址
+func nodeAddr(field string) string {
+	addr, _, _ := strings.Cut(field, ",")
+	addr, _, _ = strings.Cut(addr, "@")
+	return addr
+}
+
+// parseSlotRange 解析 "start-end" 或单个槽位
+func parseSlotRange(s string) (SlotRange, error) {
+	startStr, endStr, isRange := strings.Cut(s, "-")
+	start, err := strconv.Atoi(startStr)
+	if err != nil {
+		return SlotRange{}, fmt.Errorf("槽位 %q: %w", s, err)
+	}
+	end := start
+	if isRange {
+		if end, err = strconv.Atoi(endStr); err != nil {
+			return SlotRange{}, fmt.Errorf("槽位 %q: %w", s, err)
+		}
+	}
+	if start < 0 || end >= ClusterSlots || start > end {
+		return SlotRange{}, fmt.Errorf("槽位 %q 超出范围", s)
+	}
+	return SlotRange{Start: start, End: end}, nil
+}
+
+// TopologyFromShards 将 CLUSTER SHARDS 的结果转换为集群拓扑
+func TopologyFromShards(shards []redis.ClusterShard) *ClusterTopology {
+	topology := &ClusterTopology{}
+	for _, shard := range shards {
+		var slots []SlotRange
+		for _, r := range shard.Slots {
+			slots = append(slots, SlotRange{Start: int(r.Start), End: int(r.End)})
+		}
+
+		var masterID string
+		for _, n := range shard.Nodes {
+			if n.Role == NodeRoleMaster {
+				masterID = n.ID
+			}
+		}
+		for _, n := range shard.Nodes {
+			addr := n.Endpoint
+			if addr == "" || addr == "?" {
+				addr = n.IP
+			}
+			node := &ClusterNodeInfo{
+				ID:                n.ID,
+				Addr:              fmt.Sprintf("%s:%d", addr, n.Port),
+				Role:              n.Role,
+				Health:            n.Health,
+				ReplicationOffset: n.ReplicationOffset,
+			}
+			if n.Role == NodeRoleMaster {
+				node.Slots = slots
+			} else {
+				node.Role = NodeRoleReplica
+				node.MasterID = masterID
+			}
+			topology.Nodes = append(topology.Nodes, node)
+		}
+	}
+	return topology
+}
+
+// TopologyFromSlots 将 CLUSTER SLOTS 的结果转换为集群拓扑，每个区间的第一个节点为主节点
+func TopologyFromSlots(slots []redis.ClusterSlot) *ClusterTopology {
+	topology := &ClusterTopology{}
+	byID := make(map[string]*ClusterNodeInfo)
+	get := func(n redis.ClusterNode, role, masterID string) *ClusterNodeInfo {
+		key := n.ID
+		if key == "" {
+			key = n.Addr
+		}
+		if node, ok := byID[key]; ok {
+			return node
+		}
+		node := &ClusterNodeInfo{ID: n.ID, Addr: n.Addr, Role: role, MasterID: masterID, ReplicationOffset: -1}
+		byID[key] = node
+		topology.Nodes = append(topology.Nodes, node)
+		return node
+	}
+
+	for _, s := range slots {
+		if len(s.Nodes) == 0 {
+			continue
+		}
+		master := get(s.Nodes[0], NodeRoleMaster, "")
+		master.Slots = append(master.Slots, SlotRange{Start: s.Start, End: s.End})
+		for _, n := range s.Nodes[1:] {
+			get(n, NodeRoleReplica, master.ID)
+		}
+	}
+	return topology
+}
+
+// Topology 查询集群拓扑，优先使用 CLUSTER SHARDS，服务端不支持时退回 CLUSTER NODES
+func (c *ClusterManager) Topology(ctx context.Context) (*ClusterTopology, error) {
+	shards, err := c.cluster.ClusterShards(ctx).Result()
+	if err == nil {
+		return TopologyFromShards(shards), nil
+	}
+
+	output, nodesErr := c.cluster.ClusterNodes(ctx).Result()
+	if nodesErr != nil {
+		return nil, fmt.Errorf("查询集群拓扑失败: CLUSTER SHARDS: %v; CLUSTER NODES: %w", err, nodesErr)
+	}
+	return ParseClusterNodes(output)
+}
+
+// =============================================================================
+// 覆盖率报告
+// =============================================================================
+
+// CoverageOptions 覆盖率报告的判断阈值
+type CoverageOptions struct {
+	ImbalanceTolerance float64 // 主节点槽位数偏离平均值的比例超过该值视为失衡，默认为 0.2
+	MaxReplicaLag      int64   // 从节点复制偏移量落后主节点超过该字节数视为延迟，默认为 1MB
+}
+
+// MasterSummary 主节点槽位分布摘要
+type MasterSummary struct {
+	ID       string
+	Addr     string
+	Slots    int     // 负责的槽位数量
+	Replicas int     // 健康的从节点数量
+	Skew     float64 // 相对平均槽位数的偏离比例，正数表示偏多
+}
+
+// ReplicaLag 复制延迟的从节点
+type ReplicaLag struct {
+	ID       string
+	Addr     string
+	MasterID string
+	Lag      int64 // 落后主节点的字节数
+}
+
+// CoverageReport 集群槽位覆盖率报告
+type CoverageReport struct {
+	CoveredSlots    int                // 由健康主节点负责的槽位数量
+	UncoveredSlots  []SlotRange        // 没有健康主节点负责的槽位区间
+	FailedNodes     []*ClusterNodeInfo // 已确认故障的节点
+	SuspectedNodes  []*ClusterNodeInfo // 疑似故障（PFAIL 或链路断开）的节点，仍计入槽位覆盖
+	Masters         []MasterSummary    // 所有健康主节点的槽位分布，按地址排序
+	Imbalanced      []MasterSummary    // 槽位数偏离平均值超过阈值的主节点
+	OrphanedMasters []MasterSummary    // 负责槽位但没有健康从节点的主节点
+	LaggingReplicas []ReplicaLag       // 复制延迟超过阈值的从节点
+}
+
+// Healthy 判断集群是否所有槽位均被覆盖且没有已确认故障的节点，疑似故障的节点不影响结果
+func (r *CoverageReport) Healthy() bool {
+	return len(r.UncoveredSlots) == 0 && len(r.FailedNodes) == 0
+}
+
+// Report 生成槽位覆盖率报告
+// 参数：
+//   - opts: 判断阈值，为 nil 时使用默认值
+//
+// 返回：
+//   - *CoverageReport: 覆盖率报告；已确认故障的主节点负责的槽位计为未覆盖，疑似故障的节点单独列出
+func (t *ClusterTopology) Report(opts *CoverageOptions) *CoverageReport {
+	o := CoverageOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.ImbalanceTolerance <= 0 {
+		o.ImbalanceTolerance = 0.2
+	}
+	if o.MaxReplicaLag <= 0 {
+		o.MaxReplicaLag = 1 << 20
+	}
+
+	report := &CoverageReport{}
+	var covered [ClusterSlots]bool
+	for _, n := range t.Nodes {
+		if n.Failed() {
+			report.FailedNodes = append(report.FailedNodes, n)
+			continue
+		}
+		if n.Suspected() {
+			report.SuspectedNodes = append(report.SuspectedNodes, n)
+		}
+		if n.Role != NodeRoleMaster {
+			continue
+		}
+		for _, r := range n.Slots {
+			for slot := r.Start; slot <= r.End; slot++ {
+				covered[slot] = true
+			}
+		}
+	}
+	for slot := 0; slot < ClusterSlots; slot++ {
+		if covered[slot] {
+			report.CoveredSlots++
+			continue
+		}
+		report.UncoveredSlots = appendSlot(report.UncoveredSlots, slot)
+	}
+
+	// 只统计健康且负责槽位的主节点
+	for _, m := range t.Masters() {
+		if m.Failed() || len(m.Slots) == 0 {
+			continue
+		}
+		summary := MasterSummary{ID: m.ID, Addr: m.Addr, Slots: m.SlotCount()}
+		for _, replica := range t.ReplicasOf(m.ID) {
+			if !replica.Failed() {
+				summary.Replicas++
+			}
+		}
+		report.Masters = append(report.Masters, summary)
+	}
+	sort.Slice(report.Masters, func(i, j int) bool { return report.Masters[i].Addr < report.Masters[j].Addr })
+
+	if len(report.Masters) > 0 {
+		avg := float64(report.CoveredSlots) / float64(len(report.Masters))
+		for i := range report.Masters {
+			m := &report.Masters[i]
+			m.Skew = (float64(m.Slots) - avg) / avg
+			if m.Skew > o.ImbalanceTolerance || m.Skew < -o.ImbalanceTolerance {
+				report.Imbalanced = append(report.Imbalanced, *m)
+			}
+			if m.Replicas == 0 {
+				report.OrphanedMasters = append(report.OrphanedMasters, *m)
+			}
+		}
+	}
+
+	for _, n := range t.Nodes {
+		if n.Role != NodeRoleReplica || n.Failed() || n.ReplicationOffset < 0 {
+			continue
+		}
+		master := t.node(n.MasterID)
+		if master == nil || master.ReplicationOffset < 0 {
+			continue
+		}
+		if lag := master.ReplicationOffset - n.ReplicationOffset; lag > o.MaxReplicaLag {
+			report.LaggingReplicas = append(report.LaggingReplicas, ReplicaLag{
+				ID:       n.ID,
+				Addr:     n.Addr,
+				MasterID: n.MasterID,
+				Lag:      lag,
+			})
+		}
+	}
+	return report
+}
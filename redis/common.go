@@ -1 +1,245 @@
 package redis
+
+// 基于游标的键扫描
+//
+// 场景说明：
+//   KEYS 会一次性遍历整个键空间并阻塞服务端，在生产环境中执行可能导致数秒的停顿。SCAN 系列命令
+//   以游标分批遍历，每次只处理 COUNT 个槽位左右的数据。这里把 SCAN/HSCAN/SSCAN/ZSCAN 封装为
+//   Go 1.23 的 iter.Seq2 迭代器，可以直接用 for range 遍历，中途 break 即停止扫描：
+//
+//     for key, err := range manager.Scan(ctx, &ScanOptions{Match: "session:*", Type: "string"}) {
+//         if err != nil { ... }
+//     }
+//
+// 注意：
+//   - SCAN 只保证遍历期间一直存在的元素至少返回一次，rehash 时同一元素可能返回多次，迭代器默认在内存中去重；
+//     键空间很大时可以设置 NoDedup 关闭去重以节省内存，此时调用方需要容忍重复；
+//   - 遍历期间新增或删除的元素可能返回也可能不返回；
+//   - MATCH 与 TYPE 在服务端取出一批元素之后才过滤，匹配很少时一页可能为空，迭代器会继续翻页；
+//   - 集群模式下 SCAN 依次遍历每个主节点；HSCAN/SSCAN/ZSCAN 只涉及一个键，由客户端自动路由；
+//   - 服务端返回 invalid cursor（如节点重启或故障转移导致游标失效）时从头重新扫描，已返回的元素由去重过滤。
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ScanOptions 扫描配置
+type ScanOptions struct {
+	Match   string // MATCH 模式（glob 语法），为空时不过滤
+	Count   int64  // 每次迭代的 COUNT 提示，默认为 100
+	Type    string // 仅用于 SCAN：按类型过滤（string/list/set/zset/hash/stream），需要 Redis 6.0+
+	NoDedup bool   // 关闭去重
+}
+
+// HashField 哈希字段与值
+type HashField struct {
+	Field string
+	Value string
+}
+
+// maxScanRestarts 游标失效时单个节点最多重新扫描的次数
+const maxScanRestarts = 3
+
+// scanFunc 执行一次 SCAN 类命令，返回本页元素与下一个游标
+type scanFunc func(ctx context.Context, cursor uint64) ([]string, uint64, error)
+
+// Scan 遍历匹配条件的键
+// 参数：
+//   - ctx: 上下文，取消后迭代器返回 ctx.Err() 并结束
+//   - opts: 扫描配置，为 nil 时遍历全部键
+//
+// 返回：
+//   - iter.Seq2[string, error]: 键迭代器；出错时产生一次 ("", err) 后结束
+func (r *RedisManager) Scan(ctx context.Context, opts *ScanOptions) iter.Seq2[string, error] {
+	o := scanDefaults(opts)
+	return func(yield func(string, error) bool) {
+		clients := []redis.UniversalClient{r.client}
+		if cluster := r.clusterClient(); cluster != nil {
+			masters, err := clusterMasters(ctx, cluster)
+			if err != nil {
+				yield("", err)
+				return
+			}
+			clients = masters
+		}
+
+		sources := make([]scanFunc, len(clients))
+		for i, client := range clients {
+			sources[i] = func(ctx context.Context, cursor uint64) ([]string, uint64, error) {
+				if o.Type != "" {
+					return client.ScanType(ctx, cursor, o.Match, o.Count, o.Type).Result()
+				}
+				return client.Scan(ctx, cursor, o.Match, o.Count).Result()
+			}
+		}
+		scanSeq(ctx, o, "SCAN", sources, 1, func(item []string) (string, error) {
+			return item[0], nil
+		})(yield)
+	}
+}
+
+// SScan 遍历集合中匹配条件的成员
+// 参数：
+//   - ctx: 上下文
+//   - key: 集合键名
+//   - opts: 扫描配置，为 nil 时遍历全部成员；Type 不生效
+//
+// 返回：
+//   - iter.Seq2[string, error]: 成员迭代器
+func (r *RedisManager) SScan(ctx context.Context, key string, opts *ScanOptions) iter.Seq2[string, error] {
+	o := scanDefaults(opts)
+	source := func(ctx context.Context, cursor uint64) ([]string, uint64, error) {
+		return r.client.SScan(ctx, key, cursor, o.Match, o.Count).Result()
+	}
+	return scanSeq(ctx, o, "SSCAN "+key, []scanFunc{source}, 1, func(item []string) (string, error) {
+		return item[0], nil
+	})
+}
+
+// HScan 遍历哈希中匹配条件的字段（MATCH 作用于字段名）
+// 参数：
+//   - ctx: 上下文
+//   - key: 哈希键名
+//   - opts: 扫描配置，为 nil 时遍历全部字段；Type 不生效
+//
+// 返回：
+//   - iter.Seq2[HashField, error]: 字段与值的迭代器
+func (r *RedisManager) HScan(ctx context.Context, key string, opts *ScanOptions) iter.Seq2[HashField, error] {
+	o := scanDefaults(opts)
+	source := func(ctx context.Context, cursor uint64) ([]string, uint64, error) {
+		return r.client.HScan(ctx, key, cursor, o.Match, o.Count).Result()
+	}
+	return scanSeq(ctx, o, "HSCAN "+key, []scanFunc{source}, 2, func(item []string) (HashField, error) {
+		return HashField{Field: item[0], Value: item[1]}, nil
+	})
+}
+
+// ZScan 遍历有序集合中匹配条件的成员（MATCH 作用于成员）
+// 参数：
+//   - ctx: 上下文
+//   - key: 有序集合键名
+//   - opts: 扫描配置，为 nil 时遍历全部成员；Type 不生效
+//
+// 返回：
+//   - iter.Seq2[redis.Z, error]: 成员与分数的迭代器，不保证按分数排序
+func (r *RedisManager) ZScan(ctx context.Context, key string, opts *ScanOptions) iter.Seq2[redis.Z, error] {
+	o := scanDefaults(opts)
+	source := func(ctx context.Context, cursor uint64) ([]string, uint64, error) {
+		return r.client.ZScan(ctx, key, cursor, o.Match, o.Count).Result()
+	}
+	return scanSeq(ctx, o, "ZSCAN "+key, []scanFunc{source}, 2, func(item []string) (redis.Z, error) {
+		score, err := strconv.ParseFloat(item[1], 64)
+		if err != nil {
+			return redis.Z{}, fmt.Errorf("解析成员 %s 的分数 %q 失败: %w", item[0], item[1], err)
+		}
+		return redis.Z{Member: item[0], Score: score}, nil
+	})
+}
+
+// scanDefaults 填充扫描配置的默认值
+func scanDefaults(opts *ScanOptions) ScanOptions {
+	o := ScanOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.Count <= 0 {
+		o.Count = 100
+	}
+	return o
+}
+
+// scanSeq 依次遍历每个来源直到游标归零，按 stride 将元素分组后转换、去重并产出
+// 去重以每组的第一个元素（键名、字段名或成员）为准
+func scanSeq[T any](ctx context.Context, opts ScanOptions, command string, sources []scanFunc, stride int, convert func(item []string) (T, error)) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+		var seen map[string]struct{}
+		if !opts.NoDedup {
+			seen = make(map[string]struct{})
+		}
+
+		for _, source := range sources {
+			var cursor uint64
+			restarts := 0
+			for {
+				if err := ctx.Err(); err != nil {
+					yield(zero, err)
+					return
+				}
+
+				items, next, err := source(ctx, cursor)
+				if err != nil {
+					if isInvalidCursor(err) && restarts < maxScanRestarts {
+						restarts++
+						cursor = 0
+						continue
+					}
+					yield(zero, fmt.Errorf("%s 失败: %w", command, err))
+					return
+				}
+				if len(items)%stride != 0 {
+					yield(zero, fmt.Errorf("%s 返回的元素数量 %d 不正确", command, len(items)))
+					return
+				}
+
+				for i := 0; i < len(items); i += stride {
+					item := items[i : i+stride]
+					if seen != nil {
+						if _, dup := seen[item[0]]; dup {
+							continue
+						}
+						seen[item[0]] = struct{}{}
+					}
+					value, err := convert(item)
+					if err != nil {
+						yield(zero, err)
+						return
+					}
+					if !yield(value, nil) {
+						return
+					}
+				}
+
+				if next == 0 {
+					break
+				}
+				cursor = next
+			}
+		}
+	}
+}
+
+// isInvalidCursor 判断是否为游标失效错误
+func isInvalidCursor(err error) bool {
+	return strings.Contains(strings.ToLower(err.Error()), "invalid cursor")
+}
+
+// clusterMasters 返回集群的所有主节点客户端，按地址排序
+func clusterMasters(ctx context.Context, cluster *redis.ClusterClient) ([]redis.UniversalClient, error) {
+	var mu sync.Mutex
+	var masters []*redis.Client
+	err := cluster.ForEachMaster(ctx, func(ctx context.Context, client *redis.Client) error {
+		mu.Lock()
+		masters = append(masters, client)
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("获取集群主节点失败: %w", err)
+	}
+	sort.Slice(masters, func(i, j int) bool { return masters[i].Options().Addr < masters[j].Options().Addr })
+
+	clients := make([]redis.UniversalClient, len(masters))
+	for i, m := range masters {
+		clients[i] = m
+	}
+	return clients, nil
+}
@@ -0,0 +1,588 @@
+package redis
+
+// 故障转移事件
+//
+// 场景说明：
+//   主节点切换后，本地缓存可能保存了旧主节点上未复制的数据，分布式锁也可能随未同步的写入一起丢失。
+//   与其等到请求报错才发现切换，不如主动监听拓扑变化：FailoverWatcher 从三个来源检测切换，
+//   并以 FailoverEvent 的形式发布到通道上，同时在主节点切换时调用注册的钩子（清理缓存、重新加锁等）：
+//   1. 定期拉取集群拓扑（CLUSTER SHARDS/NODES）并与上一次比较：从节点晋升、节点故障/恢复、槽位归属变化；
+//   2. 在集群各节点客户端上挂载钩子统计 MOVED 重定向，短时间内大量 MOVED（MOVED 风暴）通常意味着
+//      发生了切换或重分片，此时立即拉取一次拓扑，而不必等到下一个轮询周期；
+//   3. 订阅 Sentinel 的 +switch-master、+sdown、-sdown 频道。
+//
+// 注意：
+//   - 事件通道有缓冲，消费不及时导致缓冲区满时丢弃新事件（可通过 Dropped 查看数量），钩子总会被调用；
+//   - 钩子在检测到事件的 goroutine 中同步执行，耗时操作请自行异步处理；
+//   - MOVED 钩子挂载到客户端后无法移除，Close 后钩子只做计数检查，不再产生事件；
+//   - Sentinel 通知同样是"至多一次"投递，断线期间的切换只能由重连后的拓扑轮询或业务错误发现。
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// FailoverEventType 故障转移事件类型
+type FailoverEventType string
+
+const (
+	FailoverMasterChanged FailoverEventType = "master_changed" // 主节点切换：从节点晋升或 Sentinel +switch-master
+	FailoverNodeFailed    FailoverEventType = "node_failed"    // 节点被标记为故障（集群 fail 或 Sentinel +sdown）
+	FailoverNodeRecovered FailoverEventType = "node_recovered" // 节点从故障中恢复（集群故障标志清除或 Sentinel -sdown）
+	FailoverSlotsMoved    FailoverEventType = "slots_moved"    // 槽位归属变化（重分片或手动迁移）
+	FailoverMovedStorm    FailoverEventType = "moved_storm"    // 短时间内收到大量 MOVED 重定向
+)
+
+// 事件来源
+const (
+	FailoverSourceCluster  = "cluster"
+	FailoverSourceSentinel = "sentinel"
+	FailoverSourceMoved    = "moved"
+)
+
+// FailoverEvent 故障转移事件
+type FailoverEvent struct {
+	Type       FailoverEventType
+	Source     string      // 事件来源：cluster/sentinel/moved
+	MasterName string      // Sentinel 监控的主节点名称，集群事件为空
+	NodeID     string      // 相关节点 ID（集群事件）；主节点切换时为新主节点
+	OldAddr    string      // 切换前的地址：旧主节点或槽位原归属节点
+	NewAddr    string      // 切换后的地址：新主节点或槽位新归属节点；节点故障/恢复事件为该节点地址
+	Slots      []SlotRange // 涉及的槽位（集群事件）
+	Count      int         // MOVED 风暴窗口内的重定向次数
+	Time       time.Time   // 检测到事件的时间
+}
+
+// FailoverHook 主节点切换钩子
+type FailoverHook func(ctx context.Context, event FailoverEvent)
+
+// FailoverWatcherOptions 故障转移监听配置，Cluster 与 SentinelAddrs 至少设置一个
+type FailoverWatcherOptions struct {
+	Cluster          *ClusterManager // 需要监听的集群
+	SentinelAddrs    []string        // Sentinel 地址列表，连接断开时依次尝试下一个
+	SentinelUsername string
+	SentinelPassword string
+	MasterName       string        // 只关注指定主节点名称的 Sentinel 事件，为空时关注全部
+	PollInterval     time.Duration // 集群拓扑轮询间隔，默认为 5 秒
+	MovedThreshold   int           // 窗口内 MOVED 次数达到该值视为风暴，默认为 50
+	MovedWindow      time.Duration // MOVED 统计窗口，默认为 1 秒
+	BufferSize       int           // 事件通道缓冲区大小，默认为 64
+	OnError          func(err error)
+}
+
+// FailoverWatcher 故障转移监听器
+type FailoverWatcher struct {
+	opts   FailoverWatcherOptions
+	events chan FailoverEvent
+	hooks  []FailoverHook
+
+	mu      sync.RWMutex // 保护 hooks、closed 与 started，emit 发送事件时持读锁
+	closed  bool
+	started bool
+	dropped atomic.Int64
+
+	movedMu     sync.Mutex
+	movedStart  time.Time
+	movedCount  int
+	pollTrigger chan struct{}
+
+	ctx            context.Context
+	cancel         context.CancelFunc
+	wg             sync.WaitGroup
+	sentinel       *Subscriber
+	sentinelClient *redis.Client
+}
+
+// NewFailoverWatcher 创建故障转移监听器
+// 参数：
+//   - opts: 监听配置
+//
+// 返回：
+//   - *FailoverWatcher: 监听器实例，注册钩子后调用 Start 开始监听
+//   - error: 未配置集群或 Sentinel 时返回错误
+func NewFailoverWatcher(opts FailoverWatcherOptions) (*FailoverWatcher, error) {
+	if opts.Cluster == nil && len(opts.SentinelAddrs) == 0 {
+		return nil, errors.New("故障转移监听需要配置集群或 Sentinel 地址")
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = 5 * time.Second
+	}
+	if opts.MovedThreshold <= 0 {
+		opts.MovedThreshold = 50
+	}
+	if opts.MovedWindow <= 0 {
+		opts.MovedWindow = time.Second
+	}
+	if opts.BufferSize <= 0 {
+		opts.BufferSize = 64
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &FailoverWatcher{
+		opts:        opts,
+		events:      make(chan FailoverEvent, opts.BufferSize),
+		pollTrigger: make(chan struct{}, 1),
+		ctx:         ctx,
+		cancel:      cancel,
+	}, nil
+}
+
+// Events 返回事件通道，Close 后关闭
+func (w *FailoverWatcher) Events() <-chan FailoverEvent {
+	return w.events
+}
+
+// Dropped 返回因事件通道已满而丢弃的事件数量
+func (w *FailoverWatcher) Dropped() int64 {
+	return w.dropped.Load()
+}
+
+// OnFailover 注册主节点切换钩子，只在 FailoverMasterChanged 事件时调用
+// 典型用法是清空本地缓存、重新获取分布式锁或重建依赖旧主节点的连接
+func (w *FailoverWatcher) OnFailover(hook FailoverHook) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.hooks = append(w.hooks, hook)
+}
+
+// Start 开始监听
+// 参数：
+//   - ctx: 上下文，仅用于初始拓扑查询与 Sentinel 订阅
+//
+// 返回：
+//   - error: 初始拓扑查询或 Sentinel 订阅失败时返回错误
+func (w *FailoverWatcher) Start(ctx context.Context) error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return errors.New("故障转移监听器已关闭")
+	}
+	if w.started {
+		w.mu.Unlock()
+		return errors.New("故障转移监听器已启动")
+	}
+	w.started = true
+	w.mu.Unlock()
+
+	if w.opts.Cluster != nil {
+		topology, err := w.opts.Cluster.Topology(ctx)
+		if err != nil {
+			return fmt.Errorf("查询初始集群拓扑失败: %w", err)
+		}
+		w.watchMoved()
+		w.wg.Add(1)
+		go w.pollLoop(topology)
+	}
+
+	if len(w.opts.SentinelAddrs) > 0 {
+		if err := w.subscribeSentinel(ctx); err != nil {
+			w.Close()
+			return err
+		}
+	}
+	return nil
+}
+
+// Close 停止监听并关闭事件通道
+func (w *FailoverWatcher) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	w.mu.Unlock()
+
+	// 先停止所有事件来源，再关闭事件通道
+	w.cancel()
+	var err error
+	if w.sentinel != nil {
+		err = errors.Join(w.sentinel.Close(context.Background()), w.sentinelClient.Close())
+	}
+	w.wg.Wait()
+	close(w.events)
+	return err
+}
+
+// emit 发布事件，主节点切换时调用钩子
+func (w *FailoverWatcher) emit(event FailoverEvent) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	w.mu.RLock()
+	if w.closed {
+		w.mu.RUnlock()
+		return
+	}
+	select {
+	case w.events <- event:
+	default:
+		w.dropped.Add(1)
+	}
+	hooks := w.hooks
+	w.mu.RUnlock()
+
+	if event.Type != FailoverMasterChanged {
+		return
+	}
+	for _, hook := range hooks {
+		w.runHook(hook, event)
+	}
+}
+
+// runHook 执行钩子，钩子 panic 时上报错误而不影响监听
+func (w *FailoverWatcher) runHook(hook FailoverHook, event FailoverEvent) {
+	defer func() {
+		if r := recover(); r != nil {
+			w.reportError(fmt.Errorf("故障转移钩子 panic: %v", r))
+		}
+	}()
+	hook(w.ctx, event)
+}
+
+// reportError 上报错误
+func (w *FailoverWatcher) reportError(err error) {
+	if w.opts.OnError != nil {
+		w.opts.OnError(err)
+	}
+}
+
+// =============================================================================
+// 集群拓扑轮询
+// =============================================================================
+
+// pollLoop 定期拉取拓扑并与上一次比较，MOVED 风暴时立即拉取
+func (w *FailoverWatcher) pollLoop(previous *ClusterTopology) {
+	defer w.wg.Done()
+	ticker := time.NewTicker(w.opts.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.ctx.Done():
+			return
+		case <-ticker.C:
+		case <-w.pollTrigger:
+		}
+
+		current, err := w.opts.Cluster.Topology(w.ctx)
+		if err != nil {
+			if w.ctx.Err() == nil {
+				w.reportError(err)
+			}
+			continue
+		}
+		events := DiffTopology(previous, current)
+		previous = current
+		if len(events) == 0 {
+			continue
+		}
+		// 拓扑已变化，主动刷新客户端的槽位表，减少后续请求的重定向
+		w.opts.Cluster.cluster.ReloadState(w.ctx)
+		for _, event := range events {
+			w.emit(event)
+		}
+	}
+}
+
+// DiffTopology 比较前后两次拓扑，返回其间发生的故障转移事件
+// 参数：
+//   - old: 上一次的拓扑，为 nil（尚无快照）时没有可比较的基准
+//   - current: 本次的拓扑
+//
+// 返回：
+//   - []FailoverEvent: 按节点顺序排列的主节点切换、节点故障/恢复事件，随后是按槽位顺序排列的槽位迁移事件；
+//     晋升节点接管的槽位已包含在主节点切换事件中，不再单独产生槽位迁移事件；任一拓扑为 nil 时返回 nil
+func DiffTopology(old, current *ClusterTopology) []FailoverEvent {
+	if old == nil || current == nil {
+		return nil
+	}
+	now := time.Now()
+	var events []FailoverEvent
+	promoted := make(map[string]bool)
+
+	for _, n := range current.Nodes {
+		prev := old.node(n.ID)
+		if prev == nil {
+			continue
+		}
+		if prev.Role == NodeRoleReplica && n.Role == NodeRoleMaster {
+			promoted[n.ID] = true
+			event := FailoverEvent{
+				Type:    FailoverMasterChanged,
+				Source:  FailoverSourceCluster,
+				NodeID:  n.ID,
+				NewAddr: n.Addr,
+				Slots:   n.Slots,
+				Time:    now,
+			}
+			if master := old.node(prev.MasterID); master != nil {
+				event.OldAddr = master.Addr
+			}
+			events = append(events, event)
+		}
+		if failed := n.Failed(); failed != prev.Failed() {
+			eventType := FailoverNodeFailed
+			if !failed {
+				eventType = FailoverNodeRecovered
+			}
+			events = append(events, FailoverEvent{
+				Type:    eventType,
+				Source:  FailoverSourceCluster,
+				NodeID:  n.ID,
+				NewAddr: n.Addr,
+				Time:    now,
+			})
+		}
+	}
+
+	// 槽位归属变化，按（原节点，新节点）分组并合并为连续区间
+	type move struct{ from, to string }
+	oldOwners, newOwners := masterOfSlots(old), masterOfSlots(current)
+	moves := make(map[move]int)
+	for slot := range ClusterSlots {
+		from, to := oldOwners[slot], newOwners[slot]
+		if from == nil || to == nil || from.ID == to.ID || promoted[to.ID] {
+			continue
+		}
+		key := move{from.ID, to.ID}
+		i, ok := moves[key]
+		if !ok {
+			i = len(events)
+			moves[key] = i
+			events = append(events, FailoverEvent{
+				Type:    FailoverSlotsMoved,
+				Source:  FailoverSourceCluster,
+				NodeID:  to.ID,
+				OldAddr: from.Addr,
+				NewAddr: to.Addr,
+				Time:    now,
+			})
+		}
+		events[i].Slots = appendSlot(events[i].Slots, slot)
+	}
+	return events
+}
+
+// masterOfSlots 返回每个槽位所属的主节点（包括故障的主节点）
+func masterOfSlots(t *ClusterTopology) []*ClusterNodeInfo {
+	owners := make([]*ClusterNodeInfo, ClusterSlots)
+	for _, m := range t.Masters() {
+		for _, r := range m.Slots {
+			for slot := r.Start; slot <= r.End; slot++ {
+				owners[slot] = m
+			}
+		}
+	}
+	return owners
+}
+
+// =============================================================================
+// MOVED 风暴检测
+// =============================================================================
+
+// movedHook 统计节点客户端收到的 MOVED 重定向
+type movedHook struct {
+	watcher *FailoverWatcher
+}
+
+func (h movedHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (h movedHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		err := next(ctx, cmd)
+		if isMovedError(err) {
+			h.watcher.recordMoved(1)
+		}
+		return err
+	}
+}
+
+func (h movedHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		err := next(ctx, cmds)
+		moved := 0
+		for _, cmd := range cmds {
+			if isMovedError(cmd.Err()) {
+				moved++
+			}
+		}
+		if moved > 0 {
+			h.watcher.recordMoved(moved)
+		}
+		return err
+	}
+}
+
+// isMovedError 判断是否为 MOVED 重定向错误
+func isMovedError(err error) bool {
+	return err != nil && strings.HasPrefix(err.Error(), "MOVED ")
+}
+
+// watchMoved 在现有与后续新建的节点客户端上挂载 MOVED 统计钩子
+func (w *FailoverWatcher) watchMoved() {
+	hook := movedHook{watcher: w}
+	cluster := w.opts.Cluster.cluster
+	cluster.OnNewNode(func(client *redis.Client) { client.AddHook(hook) })
+	cluster.ForEachShard(w.ctx, func(ctx context.Context, client *redis.Client) error {
+		client.AddHook(hook)
+		return nil
+	})
+}
+
+// recordMoved 记录 MOVED 次数，窗口内达到阈值时发布风暴事件并立即拉取拓扑
+func (w *FailoverWatcher) recordMoved(n int) {
+	if w.ctx.Err() != nil {
+		return
+	}
+
+	w.movedMu.Lock()
+	now := time.Now()
+	if now.Sub(w.movedStart) > w.opts.MovedWindow {
+		w.movedStart = now
+		w.movedCount = 0
+	}
+	before := w.movedCount
+	w.movedCount += n
+	count := w.movedCount
+	w.movedMu.Unlock()
+
+	// 每个窗口只在首次越过阈值时触发一次
+	if before >= w.opts.MovedThreshold || count < w.opts.MovedThreshold {
+		return
+	}
+	w.emit(FailoverEvent{Type: FailoverMovedStorm, Source: FailoverSourceMoved, Count: count, Time: now})
+	select {
+	case w.pollTrigger <- struct{}{}:
+	default:
+	}
+}
+
+// =============================================================================
+// Sentinel 事件
+// =============================================================================
+
+// Sentinel 事件频道
+const (
+	sentinelSwitchMaster = "+switch-master"
+	sentinelSDown        = "+sdown"
+	sentinelSDownCleared = "-sdown"
+)
+
+// ParseSentinelEvent 解析 Sentinel 事件频道的消息
+// 消息格式：
+//   - +switch-master：<master-name> <old-ip> <old-port> <new-ip> <new-port>
+//   - +sdown/-sdown：<instance-type> <name> <ip> <port> [@ <master-name> <master-ip> <master-port>]
+//
+// 参数：
+//   - channel: 频道名称
+//   - payload: 消息内容
+//
+// 返回：
+//   - *FailoverEvent: 解析后的事件；与主节点无关的实例（如其他 Sentinel）返回 nil
+//   - error: 频道不支持或消息格式不正确时返回错误
+func ParseSentinelEvent(channel, payload string) (*FailoverEvent, error) {
+	fields := strings.Fields(payload)
+	switch channel {
+	case sentinelSwitchMaster:
+		if len(fields) != 5 {
+			return nil, fmt.Errorf("+switch-master 消息格式不正确: %s", payload)
+		}
+		return &FailoverEvent{
+			Type:       FailoverMasterChanged,
+			Source:     FailoverSourceSentinel,
+			MasterName: fields[0],
+			OldAddr:    net.JoinHostPort(fields[1], fields[2]),
+			NewAddr:    net.JoinHostPort(fields[3], fields[4]),
+		}, nil
+
+	case sentinelSDown, sentinelSDownCleared:
+		if len(fields) < 4 {
+			return nil, fmt.Errorf("%s 消息格式不正确: %s", channel, payload)
+		}
+		event := &FailoverEvent{
+			Type:    FailoverNodeFailed,
+			Source:  FailoverSourceSentinel,
+			NewAddr: net.JoinHostPort(fields[2], fields[3]),
+		}
+		if channel == sentinelSDownCleared {
+			event.Type = FailoverNodeRecovered
+		}
+		switch fields[0] {
+		case "master":
+			event.MasterName = fields[1]
+		case "slave", "replica":
+			if len(fields) < 6 || fields[4] != "@" {
+				return nil, fmt.Errorf("%s 消息格式不正确: %s", channel, payload)
+			}
+			event.MasterName = fields[5]
+		default:
+			return nil, nil
+		}
+		return event, nil
+	}
+	return nil, fmt.Errorf("不支持的 Sentinel 频道 %s", channel)
+}
+
+// subscribeSentinel 订阅 Sentinel 事件，连接断开时由 Subscriber 重连到下一个可用的 Sentinel
+func (w *FailoverWatcher) subscribeSentinel(ctx context.Context) error {
+	w.sentinelClient = redis.NewClient(&redis.Options{
+		Addr:     w.opts.SentinelAddrs[0],
+		Username: w.opts.SentinelUsername,
+		Password: w.opts.SentinelPassword,
+		Dialer:   sentinelDialer(w.opts.SentinelAddrs),
+	})
+	w.sentinel = newSubscriber(w.sentinelClient, &SubscriberOptions{Workers: 1, OnError: w.opts.OnError})
+
+	handler := func(ctx context.Context, msg *redis.Message) error {
+		event, err := ParseSentinelEvent(msg.Channel, msg.Payload)
+		if err != nil || event == nil {
+			return err
+		}
+		if w.opts.MasterName != "" && event.MasterName != w.opts.MasterName {
+			return nil
+		}
+		w.emit(*event)
+		return nil
+	}
+	for _, channel := range []string{sentinelSwitchMaster, sentinelSDown, sentinelSDownCleared} {
+		if err := w.sentinel.Handle(ctx, channel, handler); err != nil {
+			return err
+		}
+	}
+	if err := w.sentinel.Start(ctx); err != nil {
+		return fmt.Errorf("订阅 Sentinel 事件失败: %w", err)
+	}
+	return nil
+}
+
+// sentinelDialer 依次尝试连接 Sentinel 地址，从上一次成功的地址开始
+func sentinelDialer(addrs []string) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	var next atomic.Int64
+	return func(ctx context.Context, network, _ string) (net.Conn, error) {
+		var dialer net.Dialer
+		var errs []error
+		start := int(next.Load())
+		for i := range addrs {
+			idx := (start + i) % len(addrs)
+			conn, err := dialer.DialContext(ctx, network, addrs[idx])
+			if err == nil {
+				next.Store(int64(idx))
+				return conn, nil
+			}
+			errs = append(errs, err)
+		}
+		return nil, fmt.Errorf("连接 Sentinel 失败: %w", errors.Join(errs...))
+	}
+}
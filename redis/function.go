@@ -0,0 +1,439 @@
+package redis
+
+// Redis Functions（FUNCTION LOAD / FCALL）函数库管理
+//
+// 场景说明：
+//   与临时 EVAL 脚本不同，Redis 7 引入的 Functions 会随 RDB/AOF 持久化，并在主从间复制，
+//   重启后无需重新加载。FunctionManager 负责：
+//   1. 加载版本化的函数库源码（通常通过 go:embed 嵌入）；
+//   2. 通过 FUNCTION LIST WITHCODE 与嵌入源码比对，检测服务端代码漂移；
+//   3. 源码变更时使用 FUNCTION LOAD REPLACE 原地升级；
+//   4. 提供 FCALL / FCALL_RO 的类型化封装；
+//   5. 服务端版本低于 7 时自动降级为基于 EVALSHA/EVAL 的脚本执行。
+//
+// 注意：
+//   - 函数库只在接收 FUNCTION LOAD 的节点（及其从节点）上存在。集群模式下 go-redis 只会把 FUNCTION 命令
+//     发给一个节点，因此加载、升级、删除与查询都逐个主节点执行，任一主节点的代码不一致即视为漂移；
+//   - 同步之后新加入的主节点不会自动加载函数库，扩容后需要重新调用 Sync。
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// FunctionLibrary 版本化的 Redis 函数库定义
+type FunctionLibrary struct {
+	Name    string // 库名，必须与源码首行 "#!lua name=<Name>" 保持一致
+	Version string // 库版本号，用于日志与同步结果展示
+	Code    string // 库源码，首行必须是 "#!lua name=<Name>"
+
+	// Fallback 函数名到等价 EVAL 脚本的映射，用于 Redis 7 以下版本
+	// 注意：EVAL 脚本通过全局变量 KEYS / ARGV 获取参数，而函数通过 (keys, args) 形参获取
+	Fallback map[string]string
+}
+
+// FunctionSyncAction 函数库同步动作
+type FunctionSyncAction string
+
+const (
+	FunctionActionLoaded    FunctionSyncAction = "loaded"    // 服务端不存在该库，已首次加载
+	FunctionActionUpgraded  FunctionSyncAction = "upgraded"  // 服务端代码与嵌入源码不一致，已 REPLACE 升级
+	FunctionActionUnchanged FunctionSyncAction = "unchanged" // 服务端代码与嵌入源码一致，无需操作
+	FunctionActionFallback  FunctionSyncAction = "fallback"  // 服务端低于 Redis 7，已降级为 EVAL 脚本
+)
+
+// FunctionSyncResult 函数库同步结果
+type FunctionSyncResult struct {
+	Library       string             // 库名
+	Version       string             // 嵌入源码的版本号
+	Action        FunctionSyncAction // 实际执行的同步动作
+	ServerVersion string             // Redis 服务端版本，如 "7.2.4"，无法获取时为空
+}
+
+// functionMode 函数调用模式
+type functionMode int
+
+const (
+	functionModeUnknown  functionMode = iota // 尚未同步
+	functionModeNative                       // 使用 FCALL / FCALL_RO
+	functionModeFallback                     // 使用 EVALSHA / EVAL
+)
+
+// FunctionManager Redis Functions 函数库管理器
+type FunctionManager struct {
+	manager *RedisManager
+	library FunctionLibrary
+
+	mu      sync.RWMutex
+	mode    functionMode
+	scripts map[string]*redis.Script // 降级模式下的函数名到脚本映射
+}
+
+// NewFunctionManager 创建函数库管理器
+// 参数：
+//   - manager: Redis 管理器
+//   - library: 函数库定义
+//
+// 返回：
+//   - *FunctionManager: 函数库管理器实例
+//   - error: 函数库定义不合法时返回错误
+func NewFunctionManager(manager *RedisManager, library FunctionLibrary) (*FunctionManager, error) {
+	if manager == nil {
+		return nil, fmt.Errorf("Redis 管理器不能为空")
+	}
+	if library.Name == "" {
+		return nil, fmt.Errorf("函数库名称不能为空")
+	}
+	if library.Code == "" {
+		return nil, fmt.Errorf("函数库 %s 源码不能为空", library.Name)
+	}
+
+	// 校验源码首行的 shebang，避免加载后库名与预期不一致
+	firstLine := strings.SplitN(library.Code, "\n", 2)[0]
+	if name, ok := shebangLibraryName(firstLine); !ok || name != library.Name {
+		return nil, fmt.Errorf("函数库 %s 源码首行必须为 \"#!lua name=%s\"", library.Name, library.Name)
+	}
+
+	scripts := make(map[string]*redis.Script, len(library.Fallback))
+	for name, src := range library.Fallback {
+		scripts[name] = redis.NewScript(src)
+	}
+
+	return &FunctionManager{
+		manager: manager,
+		library: library,
+		scripts: scripts,
+	}, nil
+}
+
+// shebangLibraryName 解析源码首行 "#!lua name=<库名> ..." 中的库名
+func shebangLibraryName(line string) (string, bool) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 || fields[0] != "#!lua" {
+		return "", false
+	}
+	for _, field := range fields[1:] {
+		if name, ok := strings.CutPrefix(field, "name="); ok {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// Library 返回函数库定义
+func (f *FunctionManager) Library() FunctionLibrary {
+	return f.library
+}
+
+// Sync 将嵌入的函数库同步到服务端
+// Redis 7 及以上：不存在则 FUNCTION LOAD，代码漂移则 FUNCTION LOAD REPLACE；
+// Redis 7 以下：预加载降级脚本（SCRIPT LOAD），后续调用走 EVALSHA；
+// 无法获取版本时以 FUNCTION LIST 是否可用决定走哪条路径
+// 参数：
+//   - ctx: 上下文，用于控制超时和取消
+//
+// 返回：
+//   - *FunctionSyncResult: 同步结果
+//   - error: 同步失败时返回错误
+func (f *FunctionManager) Sync(ctx context.Context) (*FunctionSyncResult, error) {
+	result := &FunctionSyncResult{
+		Library: f.library.Name,
+		Version: f.library.Version,
+	}
+
+	// 部分兼容实现或受限账号无法通过 INFO 获取版本，此时直接探测 FUNCTION LIST 是否可用
+	var (
+		loaded   []nodeLibrary
+		probeErr error
+		native   bool
+	)
+	serverVersion, major, versionErr := f.manager.serverVersion(ctx)
+	if versionErr == nil {
+		result.ServerVersion = serverVersion
+		native = major >= 7
+	} else {
+		loaded, probeErr = f.loadedLibraries(ctx)
+		native = probeErr == nil
+	}
+
+	if !native {
+		if len(f.scripts) == 0 {
+			if probeErr != nil {
+				return nil, fmt.Errorf("无法确定服务端是否支持 Functions（%v），且函数库 %s 未提供降级脚本: %w", versionErr, f.library.Name, probeErr)
+			}
+			return nil, fmt.Errorf("Redis %s 不支持 Functions，且函数库 %s 未提供降级脚本", serverVersion, f.library.Name)
+		}
+		for name, script := range f.scripts {
+			if err := script.Load(ctx, f.manager.client).Err(); err != nil {
+				return nil, fmt.Errorf("预加载降级脚本 %s 失败: %w", name, err)
+			}
+		}
+		f.setMode(functionModeFallback)
+		result.Action = FunctionActionFallback
+		return result, nil
+	}
+
+	if versionErr == nil {
+		var err error
+		if loaded, err = f.loadedLibraries(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	// 逐个节点同步：任一节点升级时结果为升级，否则任一节点首次加载时结果为加载
+	result.Action = FunctionActionUnchanged
+	for _, node := range loaded {
+		switch {
+		case node.library == nil:
+			if err := node.client.FunctionLoad(ctx, f.library.Code).Err(); err != nil {
+				return nil, fmt.Errorf("加载函数库 %s 失败: %w", f.library.Name, node.wrap(err))
+			}
+			if result.Action == FunctionActionUnchanged {
+				result.Action = FunctionActionLoaded
+			}
+		case node.library.Code != f.library.Code:
+			if err := node.client.FunctionLoadReplace(ctx, f.library.Code).Err(); err != nil {
+				return nil, fmt.Errorf("升级函数库 %s 失败: %w", f.library.Name, node.wrap(err))
+			}
+			result.Action = FunctionActionUpgraded
+		}
+	}
+
+	f.setMode(functionModeNative)
+	return result, nil
+}
+
+// CheckDrift 检测服务端函数库是否与嵌入源码不一致
+// 参数：
+//   - ctx: 上下文，用于控制超时和取消
+//
+// 返回：
+//   - bool: true 表示服务端（集群模式下任一主节点）未加载该库或代码不一致
+//   - error: 查询失败时返回错误
+func (f *FunctionManager) CheckDrift(ctx context.Context) (bool, error) {
+	loaded, err := f.loadedLibraries(ctx)
+	if err != nil {
+		return false, err
+	}
+	for _, node := range loaded {
+		if node.library == nil || node.library.Code != f.library.Code {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Delete 从服务端删除函数库（库不存在时不返回错误），集群模式下从所有主节点删除
+func (f *FunctionManager) Delete(ctx context.Context) error {
+	nodes, err := f.functionNodes(ctx)
+	if err != nil {
+		return err
+	}
+	for _, node := range nodes {
+		err := node.client.FunctionDelete(ctx, f.library.Name).Err()
+		if err != nil && !strings.Contains(err.Error(), "Library not found") {
+			return fmt.Errorf("删除函数库 %s 失败: %w", f.library.Name, node.wrap(err))
+		}
+	}
+	f.setMode(functionModeUnknown)
+	return nil
+}
+
+// FCall 调用函数库中的函数（FCALL），降级模式下使用 EVALSHA
+// 参数：
+//   - ctx: 上下文，用于控制超时和取消
+//   - function: 函数名
+//   - keys: 函数访问的键
+//   - args: 函数参数
+//
+// 返回：
+//   - *redis.Cmd: 命令结果，可通过 Int64/Text/StringSlice 等方法读取
+func (f *FunctionManager) FCall(ctx context.Context, function string, keys []string, args ...interface{}) *redis.Cmd {
+	return f.call(ctx, false, function, keys, args...)
+}
+
+// FCallRO 以只读方式调用函数（FCALL_RO），可被路由到只读副本
+// 函数必须在源码中声明 flags = { 'no-writes' }
+func (f *FunctionManager) FCallRO(ctx context.Context, function string, keys []string, args ...interface{}) *redis.Cmd {
+	return f.call(ctx, true, function, keys, args...)
+}
+
+// FCallInt64 调用函数并将结果解析为整数
+func (f *FunctionManager) FCallInt64(ctx context.Context, function string, keys []string, args ...interface{}) (int64, error) {
+	val, err := f.FCall(ctx, function, keys, args...).Int64()
+	if err != nil {
+		return 0, fmt.Errorf("调用函数 %s 失败: %w", function, err)
+	}
+	return val, nil
+}
+
+// FCallString 调用函数并将结果解析为字符串
+func (f *FunctionManager) FCallString(ctx context.Context, function string, keys []string, args ...interface{}) (string, error) {
+	val, err := f.FCall(ctx, function, keys, args...).Text()
+	if err != nil {
+		return "", fmt.Errorf("调用函数 %s 失败: %w", function, err)
+	}
+	return val, nil
+}
+
+// FCallStringSlice 调用函数并将结果解析为字符串切片
+func (f *FunctionManager) FCallStringSlice(ctx context.Context, function string, keys []string, args ...interface{}) ([]string, error) {
+	val, err := f.FCall(ctx, function, keys, args...).StringSlice()
+	if err != nil {
+		return nil, fmt.Errorf("调用函数 %s 失败: %w", function, err)
+	}
+	return val, nil
+}
+
+// FCallROInt64 以只读方式调用函数并将结果解析为整数
+func (f *FunctionManager) FCallROInt64(ctx context.Context, function string, keys []string, args ...interface{}) (int64, error) {
+	val, err := f.FCallRO(ctx, function, keys, args...).Int64()
+	if err != nil {
+		return 0, fmt.Errorf("只读调用函数 %s 失败: %w", function, err)
+	}
+	return val, nil
+}
+
+// FCallROString 以只读方式调用函数并将结果解析为字符串
+func (f *FunctionManager) FCallROString(ctx context.Context, function string, keys []string, args ...interface{}) (string, error) {
+	val, err := f.FCallRO(ctx, function, keys, args...).Text()
+	if err != nil {
+		return "", fmt.Errorf("只读调用函数 %s 失败: %w", function, err)
+	}
+	return val, nil
+}
+
+// FCallROStringSlice 以只读方式调用函数并将结果解析为字符串切片
+func (f *FunctionManager) FCallROStringSlice(ctx context.Context, function string, keys []string, args ...interface{}) ([]string, error) {
+	val, err := f.FCallRO(ctx, function, keys, args...).StringSlice()
+	if err != nil {
+		return nil, fmt.Errorf("只读调用函数 %s 失败: %w", function, err)
+	}
+	return val, nil
+}
+
+// call 根据当前模式分发到 FCALL 或 EVALSHA
+func (f *FunctionManager) call(ctx context.Context, readOnly bool, function string, keys []string, args ...interface{}) *redis.Cmd {
+	// 集群模式下提前发现跨槽位的键，避免请求发出后才收到 CROSSSLOT 错误
+	if err := f.manager.checkSameSlot(keys); err != nil {
+		return errorCmd(ctx, fmt.Errorf("调用函数 %s: %w", function, err))
+	}
+
+	f.mu.RLock()
+	mode := f.mode
+	f.mu.RUnlock()
+
+	switch mode {
+	case functionModeNative:
+		if readOnly {
+			return f.manager.client.FCallRO(ctx, function, keys, args...)
+		}
+		return f.manager.client.FCall(ctx, function, keys, args...)
+	case functionModeFallback:
+		script, ok := f.scripts[function]
+		if !ok {
+			return errorCmd(ctx, fmt.Errorf("函数 %s 未提供降级脚本", function))
+		}
+		// Redis 7 以下不支持 EVALSHA_RO，只读调用同样走 EVALSHA
+		return f.manager.runScript(ctx, script, keys, args...)
+	default:
+		return errorCmd(ctx, fmt.Errorf("函数库 %s 尚未同步，请先调用 Sync", f.library.Name))
+	}
+}
+
+// nodeLibrary 一个节点及其上已加载的同名函数库
+type nodeLibrary struct {
+	addr    string                // 节点地址，非集群模式为空
+	client  redis.UniversalClient // 执行 FUNCTION 命令的客户端
+	library *redis.Library        // 节点上已加载的函数库，不存在时为 nil
+}
+
+// wrap 在错误信息中附加节点地址
+func (n nodeLibrary) wrap(err error) error {
+	if n.addr == "" {
+		return err
+	}
+	return fmt.Errorf("节点 %s: %w", n.addr, err)
+}
+
+// functionNodes 返回需要管理函数库的节点：集群模式下为所有主节点，其余模式为管理器的客户端
+func (f *FunctionManager) functionNodes(ctx context.Context) ([]nodeLibrary, error) {
+	cluster := f.manager.clusterClient()
+	if cluster == nil {
+		return []nodeLibrary{{client: f.manager.client}}, nil
+	}
+	masters, err := clusterMasters(ctx, cluster)
+	if err != nil {
+		return nil, err
+	}
+	nodes := make([]nodeLibrary, len(masters))
+	for i, master := range masters {
+		nodes[i] = nodeLibrary{addr: master.(*redis.Client).Options().Addr, client: master}
+	}
+	return nodes, nil
+}
+
+// loadedLibraries 查询每个节点上已加载的同名函数库
+func (f *FunctionManager) loadedLibraries(ctx context.Context) ([]nodeLibrary, error) {
+	nodes, err := f.functionNodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range nodes {
+		libs, err := nodes[i].client.FunctionList(ctx, redis.FunctionListQuery{
+			LibraryNamePattern: f.library.Name,
+			WithCode:           true,
+		}).Result()
+		if err != nil {
+			return nil, fmt.Errorf("查询函数库 %s 失败: %w", f.library.Name, nodes[i].wrap(err))
+		}
+		for j := range libs {
+			if libs[j].Name == f.library.Name {
+				nodes[i].library = &libs[j]
+				break
+			}
+		}
+	}
+	return nodes, nil
+}
+
+// setMode 设置函数调用模式
+func (f *FunctionManager) setMode(mode functionMode) {
+	f.mu.Lock()
+	f.mode = mode
+	f.mu.Unlock()
+}
+
+// serverVersion 获取 Redis 服务端版本号及主版本
+func (r *RedisManager) serverVersion(ctx context.Context) (string, int, error) {
+	info, err := r.client.Info(ctx, "server").Result()
+	if err != nil {
+		return "", 0, fmt.Errorf("获取 Redis 版本失败: %w", err)
+	}
+
+	for _, line := range strings.Split(info, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "redis_version:") {
+			continue
+		}
+		version := strings.TrimPrefix(line, "redis_version:")
+		major, err := strconv.Atoi(strings.SplitN(version, ".", 2)[0])
+		if err != nil {
+			return "", 0, fmt.Errorf("解析 Redis 版本 %q 失败: %w", version, err)
+		}
+		return version, major, nil
+	}
+	return "", 0, fmt.Errorf("INFO 输出中未找到 redis_version")
+}
+
+// errorCmd 构造一个携带错误的命令结果，便于统一返回 *redis.Cmd
+func errorCmd(ctx context.Context, err error) *redis.Cmd {
+	cmd := redis.NewCmd(ctx)
+	cmd.SetErr(err)
+	return cmd
+}
@@ -0,0 +1,392 @@
+package redis
+
+// 键空间通知
+//
+// 场景说明：
+//   会话键过期、缓存键被淘汰时需要触发清理或回源逻辑。Redis 的键空间通知会把这些事件发布到
+//   __keyevent@<db>__:<event> 频道（消息内容为键名）。KeyspaceListener 基于 Subscriber 订阅这些频道，
+//   将消息解析为 KeyEvent，并按键模式过滤后交给处理函数。
+//
+// 注意：
+//   - 键空间通知默认关闭，可通过 EnableNotifications 在启动时用 CONFIG SET 开启（与现有配置合并）；
+//     托管的 Redis 服务通常禁用 CONFIG 命令，此时需要在服务端预先配置；
+//   - 过期事件在键被实际删除时触发（惰性删除或定期扫描），可能晚于 TTL 到期时间；
+//   - 与 Pub/Sub 一样为"至多一次"投递，不能作为唯一的可靠触发机制；
+//   - 键空间通知只在键所在的节点上发布，集群模式下 Start 会分别订阅每个主节点，
+//     Start 之后新增的主节点（扩容或故障转移后的新主节点）需要重新创建监听器才能覆盖。
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// KeyEventType 键事件类型，对应 __keyevent@<db>__:<event> 中的 event
+type KeyEventType string
+
+const (
+	KeyEventExpired KeyEventType = "expired" // 键过期被删除
+	KeyEventEvicted KeyEventType = "evicted" // 键因 maxmemory 被淘汰
+	KeyEventSet     KeyEventType = "set"     // SET 类命令
+	KeyEventDel     KeyEventType = "del"     // DEL 命令
+	KeyEventExpire  KeyEventType = "expire"  // 设置过期时间
+	KeyEventRename  KeyEventType = "rename_to"
+	KeyEventHSet    KeyEventType = "hset"
+	KeyEventLPush   KeyEventType = "lpush"
+	KeyEventRPush   KeyEventType = "rpush"
+	KeyEventSAdd    KeyEventType = "sadd"
+	KeyEventZAdd    KeyEventType = "zadd"
+	KeyEventIncrBy  KeyEventType = "incrby"
+)
+
+// KeyEvent 解析后的键事件
+type KeyEvent struct {
+	Key  string       // 发生事件的键
+	Type KeyEventType // 事件类型
+	DB   int          // 数据库编号
+}
+
+// KeyEventHandler 键事件处理函数
+type KeyEventHandler func(ctx context.Context, event *KeyEvent) error
+
+// keyEventPrefix 键事件频道前缀
+const keyEventPrefix = "__keyevent@"
+
+// ParseKeyEvent 将 __keyevent@<db>__:<event> 频道的消息解析为键事件
+// 参数：
+//   - msg: 订阅收到的消息
+//
+// 返回：
+//   - *KeyEvent: 解析后的键事件
+//   - error: 频道格式不正确时返回错误
+func ParseKeyEvent(msg *redis.Message) (*KeyEvent, error) {
+	rest, ok := strings.CutPrefix(msg.Channel, keyEventPrefix)
+	if !ok {
+		return nil, fmt.Errorf("频道 %s 不是键事件频道", msg.Channel)
+	}
+	dbStr, event, ok := strings.Cut(rest, "__:")
+	if !ok || event == "" {
+		return nil, fmt.Errorf("键事件频道 %s 格式不正确", msg.Channel)
+	}
+	db, err := strconv.Atoi(dbStr)
+	if err != nil {
+		return nil, fmt.Errorf("解析键事件频道 %s 的数据库编号失败: %w", msg.Channel, err)
+	}
+	return &KeyEvent{Key: msg.Payload, Type: KeyEventType(event), DB: db}, nil
+}
+
+// KeyspaceListenerOptions 键空间监听配置
+type KeyspaceListenerOptions struct {
+	Events              []KeyEventType // 关注的事件类型，为空时订阅全部事件
+	Pattern             string         // 键的 glob 模式（语法同 KEYS/SCAN MATCH），为空时不过滤
+	EnableNotifications bool           // 启动时是否通过 CONFIG SET 开启所需的通知类型
+	Subscriber          *SubscriberOptions
+}
+
+// KeyspaceListener 键空间通知监听器
+type KeyspaceListener struct {
+	manager *RedisManager
+	opts    KeyspaceListenerOptions
+	db      int
+
+	mu   sync.Mutex
+	subs []*Subscriber // 单机与 Sentinel 模式下一个，集群模式下每个主节点一个
+}
+
+// NewKeyspaceListener 创建键空间通知监听器，监听管理器当前配置的数据库
+// 参数：
+//   - manager: Redis 管理器
+//   - opts: 监听配置，为 nil 时订阅全部事件且不过滤
+//
+// 返回：
+//   - *KeyspaceListener: 监听器实例，调用 Start 开始监听
+func NewKeyspaceListener(manager *RedisManager, opts *KeyspaceListenerOptions) *KeyspaceListener {
+	o := KeyspaceListenerOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	return &KeyspaceListener{
+		manager: manager,
+		opts:    o,
+		db:      manager.db(),
+	}
+}
+
+// Start 开启通知（可选）、订阅键事件频道并开始分发事件
+// 参数：
+//   - ctx: 上下文
+//   - handler: 键事件处理函数，由 Subscriber 的 worker 并发调用
+//
+// 返回：
+//   - error: 开启通知或订阅失败时返回错误
+func (l *KeyspaceListener) Start(ctx context.Context, handler KeyEventHandler) error {
+	if handler == nil {
+		return fmt.Errorf("键事件处理函数不能为空")
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.subs != nil {
+		return fmt.Errorf("键空间监听器已启动")
+	}
+
+	clients, err := l.nodeClients(ctx)
+	if err != nil {
+		return err
+	}
+	if l.opts.EnableNotifications {
+		for _, client := range clients {
+			if err := l.enableNotifications(ctx, client); err != nil {
+				return err
+			}
+		}
+	}
+
+	dispatch := func(ctx context.Context, msg *redis.Message) error {
+		event, err := ParseKeyEvent(msg)
+		if err != nil {
+			return err
+		}
+		if l.opts.Pattern != "" && !MatchPattern(l.opts.Pattern, event.Key) {
+			return nil
+		}
+		return handler(ctx, event)
+	}
+
+	subs := make([]*Subscriber, 0, len(clients))
+	for _, client := range clients {
+		sub, err := l.subscribe(ctx, client, dispatch)
+		if err != nil {
+			for _, started := range subs {
+				started.Close(ctx)
+			}
+			return err
+		}
+		subs = append(subs, sub)
+	}
+	l.subs = subs
+	return nil
+}
+
+// subscribe 在指定节点上订阅键事件频道
+func (l *KeyspaceListener) subscribe(ctx context.Context, client redis.UniversalClient, dispatch MessageHandler) (*Subscriber, error) {
+	sub := newSubscriber(client, l.opts.Subscriber)
+	if len(l.opts.Events) == 0 {
+		if err := sub.HandlePattern(ctx, l.channel("*"), dispatch); err != nil {
+			return nil, err
+		}
+	} else {
+		for _, event := range l.opts.Events {
+			if err := sub.Handle(ctx, l.channel(string(event)), dispatch); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := sub.Start(ctx); err != nil {
+		sub.Close(ctx)
+		return nil, err
+	}
+	return sub, nil
+}
+
+// nodeClients 返回需要订阅的节点：集群模式下为每个主节点，其余模式为管理器的客户端
+func (l *KeyspaceListener) nodeClients(ctx context.Context) ([]redis.UniversalClient, error) {
+	cluster := l.manager.clusterClient()
+	if cluster == nil {
+		return []redis.UniversalClient{l.manager.client}, nil
+	}
+
+	var (
+		mu      sync.Mutex
+		clients []redis.UniversalClient
+	)
+	err := cluster.ForEachMaster(ctx, func(ctx context.Context, node *redis.Client) error {
+		mu.Lock()
+		defer mu.Unlock()
+		clients = append(clients, node)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("获取集群主节点失败: %w", err)
+	}
+	return clients, nil
+}
+
+// Close 停止监听并等待已接收的事件处理完毕
+func (l *KeyspaceListener) Close(ctx context.Context) error {
+	l.mu.Lock()
+	subs := l.subs
+	l.mu.Unlock()
+
+	var errs []error
+	for _, sub := range subs {
+		if err := sub.Close(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// channel 返回当前数据库指定事件的频道名
+func (l *KeyspaceListener) channel(event string) string {
+	return fmt.Sprintf("%s%d__:%s", keyEventPrefix, l.db, event)
+}
+
+// enableNotifications 将所需的通知类型合并到节点现有的 notify-keyspace-events 配置中
+func (l *KeyspaceListener) enableNotifications(ctx context.Context, client redis.UniversalClient) error {
+	current, err := client.ConfigGet(ctx, "notify-keyspace-events").Result()
+	if err != nil {
+		return fmt.Errorf("读取 notify-keyspace-events 配置失败: %w", err)
+	}
+	flags := mergeNotifyFlags(current["notify-keyspace-events"], notifyFlagsFor(l.opts.Events))
+	if flags == current["notify-keyspace-events"] {
+		return nil
+	}
+	if err := client.ConfigSet(ctx, "notify-keyspace-events", flags).Err(); err != nil {
+		return fmt.Errorf("开启键空间通知失败: %w", err)
+	}
+	return nil
+}
+
+// notifyFlagsFor 返回订阅指定事件所需的通知类型标志
+func notifyFlagsFor(events []KeyEventType) string {
+	if len(events) == 0 {
+		return "EA"
+	}
+	flags := "E"
+	for _, event := range events {
+		var flag string
+		switch event {
+		case KeyEventExpired:
+			flag = "x"
+		case KeyEventEvicted:
+			flag = "e"
+		case KeyEventSet, KeyEventIncrBy:
+			flag = "$"
+		case KeyEventHSet:
+			flag = "h"
+		case KeyEventLPush, KeyEventRPush:
+			flag = "l"
+		case KeyEventSAdd:
+			flag = "s"
+		case KeyEventZAdd:
+			flag = "z"
+		default:
+			// del、expire、rename 等通用命令及未知事件
+			flag = "g"
+		}
+		if !strings.Contains(flags, flag) {
+			flags += flag
+		}
+	}
+	return flags
+}
+
+// mergeNotifyFlags 合并两组通知标志，保留现有配置中的标志
+func mergeNotifyFlags(current, required string) string {
+	merged := current
+	for _, c := range required {
+		if strings.ContainsRune(merged, c) {
+			continue
+		}
+		// A 已包含除 K/E 外的大部分事件类型
+		if c != 'K' && c != 'E' && strings.ContainsRune(merged, 'A') && strings.ContainsRune("g$lshzxetd", c) {
+			continue
+		}
+		merged += string(c)
+	}
+	return merged
+}
+
+// MatchPattern 按 Redis glob 语法（KEYS/SCAN MATCH/PSUBSCRIBE）匹配字符串
+// 支持 *、?、[abc]、[^abc]、[a-z] 以及反斜杠转义
+// 遇到 * 时只记录最近一个 * 的位置，匹配失败时让它多吞一个字符再重试，最坏复杂度为 O(len(pattern)*len(s))
+func MatchPattern(pattern, s string) bool {
+	p, i := 0, 0
+	starP, starI := -1, 0
+	for i < len(s) {
+		if p < len(pattern) {
+			if pattern[p] == '*' {
+				for p < len(pattern) && pattern[p] == '*' {
+					p++
+				}
+				if p == len(pattern) {
+					return true
+				}
+				starP, starI = p, i
+				continue
+			}
+			if rest, ok := matchOne(pattern[p:], s[i]); ok {
+				p, i = len(pattern)-len(rest), i+1
+				continue
+			}
+		}
+		if starP < 0 {
+			return false
+		}
+		// 回溯到最近的 *，让它多匹配一个字符
+		starI++
+		p, i = starP, starI
+	}
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+	return p == len(pattern)
+}
+
+// matchOne 用模式开头的单个元素（?、[...]、转义字符或普通字符）匹配字符 c，返回剩余模式与是否匹配
+func matchOne(pattern string, c byte) (string, bool) {
+	switch pattern[0] {
+	case '?':
+		return pattern[1:], true
+	case '[':
+		return matchClass(pattern[1:], c)
+	case '\\':
+		if len(pattern) >= 2 {
+			pattern = pattern[1:]
+		}
+	}
+	return pattern[1:], pattern[0] == c
+}
+
+// matchClass 匹配字符集合 [...]，pattern 为 '[' 之后的部分，返回 ']' 之后的剩余模式与是否匹配
+func matchClass(pattern string, c byte) (string, bool) {
+	negate := len(pattern) > 0 && pattern[0] == '^'
+	if negate {
+		pattern = pattern[1:]
+	}
+	matched := false
+	for len(pattern) > 0 && pattern[0] != ']' {
+		switch {
+		case pattern[0] == '\\' && len(pattern) >= 2:
+			if pattern[1] == c {
+				matched = true
+			}
+			pattern = pattern[2:]
+		case len(pattern) >= 3 && pattern[1] == '-' && pattern[2] != ']':
+			lo, hi := pattern[0], pattern[2]
+			if lo > hi {
+				lo, hi = hi, lo
+			}
+			if c >= lo && c <= hi {
+				matched = true
+			}
+			pattern = pattern[3:]
+		default:
+			if pattern[0] == c {
+				matched = true
+			}
+			pattern = pattern[1:]
+		}
+	}
+	// 与 Redis 一致：缺少 ']' 时视为集合在模式末尾结束
+	if len(pattern) > 0 {
+		pattern = pattern[1:]
+	}
+	return pattern, matched != negate
+}
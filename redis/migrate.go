@@ -0,0 +1,606 @@
+package redis
+
+// 在线键迁移
+//
+// 场景说明：
+//   把某个租户的键（如 tenant:42:*）从一个数据库或实例迁移到另一个实例时，停写整个迁移过程的代价往往不可接受。
+//   KeyMigrator 分三个阶段完成迁移，只有最后一步需要短暂停写：
+//
+//     1. Copy：用 SCAN 遍历匹配的键并逐批复制。两端都是单机实例且允许覆盖时使用 MIGRATE ... COPY REPLACE KEYS
+//        由源实例直接传输，否则通过 DUMP/PTTL 读取再在目标端 RESTORE；
+//     2. Sync：开启 SyncChanges 时，Copy 开始前先订阅源库的键空间通知，复制期间及之后被修改、删除或过期的键
+//        记录为脏键，Sync 把脏键重新复制到目标端（源端已不存在的键在目标端删除），可以反复调用追赶增量；
+//     3. Finish：业务停写源库后调用，持续同步直到一段时间内不再有新的变更，然后停止订阅并校验两端数据。
+//
+//   Verify 分别统计两端匹配的键数量，并按键比较内容摘要：摘要基于原生读命令得到的规范化数据（与 ExportJSON 相同），
+//   不依赖 DUMP 格式，两端 Redis 版本或内部编码不同也能比较。
+//
+// 注意：
+//   - 键空间通知需要源库开启 notify-keyspace-events（可设置 EnableNotifications 自动开启），且为"至多一次"投递，
+//     订阅连接断开期间的变更会丢失，Finish 的校验结果可以发现这类遗漏；
+//   - 集群模式下键空间通知只在键所在的节点发布，源端为集群时不支持 SyncChanges；
+//   - MIGRATE 由源实例主动连接目标实例，需要源实例能访问目标地址；Auto 模式下 MIGRATE 失败会自动改用 DUMP/RESTORE；
+//   - DUMP/RESTORE 与 MIGRATE 都依赖 RDB 序列化格式，目标实例的版本不能低于源实例，跨版本降级请使用 ExportJSON/ImportJSON。
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// KeyMigrationMethod 键复制方式
+type KeyMigrationMethod string
+
+const (
+	KeyMigrationAuto        KeyMigrationMethod = "auto"         // 条件允许时使用 MIGRATE，否则或失败时使用 DUMP/RESTORE
+	KeyMigrationMigrate     KeyMigrationMethod = "migrate"      // 只使用 MIGRATE，不满足条件时返回错误
+	KeyMigrationDumpRestore KeyMigrationMethod = "dump_restore" // 只使用 DUMP/RESTORE
+)
+
+// maxVerifySamples 校验结果中最多记录的不一致键数量
+const maxVerifySamples = 100
+
+// KeyMigratorOptions 键迁移配置
+type KeyMigratorOptions struct {
+	Match               string             // 要迁移的键的 glob 模式，不能为空
+	BatchSize           int                // 每批复制的键数量，默认为 100
+	Method              KeyMigrationMethod // 复制方式，默认为 KeyMigrationAuto
+	Replace             bool               // 覆盖目标端已存在的键；为 false 时跳过这些键，且不会使用 MIGRATE
+	Timeout             time.Duration      // 单次 MIGRATE 的超时时间，默认为 5 秒
+	MaxKeysPerSecond    int                // Copy 阶段每秒最多复制的键数量，0 表示不限速
+	SyncChanges         bool               // 订阅源库的键空间通知，同步复制期间及之后的变更
+	EnableNotifications bool               // 启动同步时通过 CONFIG SET 开启源库的键空间通知
+	QuietPeriod         time.Duration      // Finish 时持续多久没有新的变更视为同步完成，默认为 500 毫秒
+	OnProgress          func(stats KeyMigrationStats)
+}
+
+// KeyMigrationStats 迁移统计
+type KeyMigrationStats struct {
+	Scanned int64              // Copy 阶段扫描到的键数量
+	Copied  int64              // Copy 阶段复制的键数量
+	Skipped int64              // 目标端已存在而跳过的键数量
+	Missing int64              // 扫描后、复制前被删除的键数量
+	Synced  int64              // Sync 阶段重新复制或删除的键数量
+	Pending int64              // 等待同步的脏键数量
+	Method  KeyMigrationMethod // Copy 阶段实际使用的复制方式（Auto 模式下失败回退后为 dump_restore）
+	Elapsed time.Duration
+}
+
+// KeyMigrationVerification 迁移校验结果
+type KeyMigrationVerification struct {
+	SourceKeys     int64    // 源端匹配的键数量
+	TargetKeys     int64    // 目标端匹配的键数量
+	SourceChecksum uint64   // 源端所有键摘要的异或，与键的遍历顺序无关
+	TargetChecksum uint64   // 目标端所有键摘要的异或
+	Missing        int64    // 源端存在而目标端不存在的键数量
+	Extra          int64    // 目标端存在而源端不存在的键数量
+	Mismatched     int64    // 两端内容不一致的键数量
+	Samples        []string // 不一致的键示例，最多 100 个
+}
+
+// OK 两端数据是否一致
+func (v *KeyMigrationVerification) OK() bool {
+	return v.Missing == 0 && v.Extra == 0 && v.Mismatched == 0
+}
+
+// KeyMigrator 在两个 Redis 管理器之间迁移匹配模式的键
+type KeyMigrator struct {
+	source   *RedisManager
+	target   *RedisManager
+	opts     KeyMigratorOptions
+	listener *KeyspaceListener
+	start    time.Time
+
+	mu      sync.Mutex
+	stats   KeyMigrationStats
+	dirty   map[string]struct{}
+	changed chan struct{} // 有新的脏键时非阻塞通知
+}
+
+// NewKeyMigrator 创建键迁移器
+// 参数：
+//   - source: 源端管理器
+//   - target: 目标端管理器
+//   - opts: 迁移配置，Match 不能为空
+//
+// 返回：
+//   - *KeyMigrator: 迁移器实例，调用 Copy 开始迁移
+//   - error: 配置不合法时返回错误
+func NewKeyMigrator(source, target *RedisManager, opts *KeyMigratorOptions) (*KeyMigrator, error) {
+	if source == nil || target == nil {
+		return nil, errors.New("源端与目标端管理器不能为空")
+	}
+	o := KeyMigratorOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.Match == "" {
+		return nil, errors.New("键模式不能为空，需要迁移全部键时显式传入 *")
+	}
+	if o.BatchSize <= 0 {
+		o.BatchSize = 100
+	}
+	if o.Method == "" {
+		o.Method = KeyMigrationAuto
+	}
+	switch o.Method {
+	case KeyMigrationAuto, KeyMigrationDumpRestore:
+	case KeyMigrationMigrate:
+		if err := canMigrate(source, target, o.Replace); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("不支持的复制方式 %s", o.Method)
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = 5 * time.Second
+	}
+	if o.QuietPeriod <= 0 {
+		o.QuietPeriod = 500 * time.Millisecond
+	}
+	if o.SyncChanges && source.clusterClient() != nil {
+		return nil, errors.New("源端为集群时不支持同步键空间通知")
+	}
+
+	m := &KeyMigrator{
+		source:  source,
+		target:  target,
+		opts:    o,
+		dirty:   make(map[string]struct{}),
+		changed: make(chan struct{}, 1),
+	}
+	m.stats.Method = o.Method
+	if o.Method == KeyMigrationAuto {
+		m.stats.Method = KeyMigrationDumpRestore
+		if canMigrate(source, target, o.Replace) == nil {
+			m.stats.Method = KeyMigrationMigrate
+		}
+	}
+	return m, nil
+}
+
+// canMigrate 判断能否使用 MIGRATE：两端都是直连的单机实例且允许覆盖
+func canMigrate(source, target *RedisManager, replace bool) error {
+	if !replace {
+		return errors.New("MIGRATE 只在允许覆盖（Replace）时使用")
+	}
+	if _, ok := source.client.(*redis.Client); !ok || (source.config != nil && source.config.UseSentinel()) {
+		return errors.New("MIGRATE 要求源端为直连的单机实例")
+	}
+	if _, ok := target.client.(*redis.Client); !ok || (target.config != nil && target.config.UseSentinel()) {
+		return errors.New("MIGRATE 要求目标端为直连的单机实例")
+	}
+	return nil
+}
+
+// Stats 返回当前的迁移统计
+func (m *KeyMigrator) Stats() KeyMigrationStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stats := m.stats
+	stats.Pending = int64(len(m.dirty))
+	if !m.start.IsZero() {
+		stats.Elapsed = time.Since(m.start)
+	}
+	return stats
+}
+
+// Copy 复制所有匹配的键；开启 SyncChanges 时先开始订阅键空间通知
+// 参数：
+//   - ctx: 上下文，取消后在当前批次结束时停止
+//
+// 返回：
+//   - KeyMigrationStats: 迁移统计
+//   - error: 订阅、扫描或复制失败时返回错误
+func (m *KeyMigrator) Copy(ctx context.Context) (KeyMigrationStats, error) {
+	m.mu.Lock()
+	if m.start.IsZero() {
+		m.start = time.Now()
+	}
+	m.mu.Unlock()
+
+	if m.opts.SyncChanges && m.listener == nil {
+		listener := NewKeyspaceListener(m.source, &KeyspaceListenerOptions{
+			Pattern:             m.opts.Match,
+			EnableNotifications: m.opts.EnableNotifications,
+		})
+		if err := listener.Start(ctx, m.markDirty); err != nil {
+			listener.Close(context.Background())
+			return m.Stats(), fmt.Errorf("订阅源库键空间通知失败: %w", err)
+		}
+		m.listener = listener
+	}
+
+	copyStart := time.Now()
+	var scanned int64
+	batch := make([]string, 0, m.opts.BatchSize)
+	flush := func() error {
+		if err := m.copyBatch(ctx, batch); err != nil {
+			return err
+		}
+		scanned += int64(len(batch))
+		batch = batch[:0]
+		m.report()
+		return throttle(ctx, copyStart, scanned, m.opts.MaxKeysPerSecond)
+	}
+
+	for key, err := range m.source.Scan(ctx, &ScanOptions{Match: m.opts.Match, Count: int64(m.opts.BatchSize)}) {
+		if err != nil {
+			return m.Stats(), err
+		}
+		batch = append(batch, key)
+		if len(batch) == m.opts.BatchSize {
+			if err := flush(); err != nil {
+				return m.Stats(), err
+			}
+		}
+	}
+	if len(batch) > 0 {
+		if err := flush(); err != nil {
+			return m.Stats(), err
+		}
+	}
+	return m.Stats(), nil
+}
+
+// copyBatch 复制一批键，Auto 模式下 MIGRATE 失败后改用 DUMP/RESTORE 并不再尝试 MIGRATE
+func (m *KeyMigrator) copyBatch(ctx context.Context, keys []string) error {
+	m.mu.Lock()
+	m.stats.Scanned += int64(len(keys))
+	method := m.stats.Method
+	m.mu.Unlock()
+
+	if method == KeyMigrationMigrate {
+		copied, err := m.migrateKeys(ctx, keys)
+		if err == nil {
+			m.mu.Lock()
+			m.stats.Copied += copied
+			m.stats.Missing += int64(len(keys)) - copied
+			m.mu.Unlock()
+			return nil
+		}
+		if m.opts.Method == KeyMigrationMigrate || ctx.Err() != nil {
+			return err
+		}
+		m.mu.Lock()
+		m.stats.Method = KeyMigrationDumpRestore
+		m.mu.Unlock()
+	}
+
+	records, err := m.source.dumpKeys(ctx, keys)
+	if err != nil {
+		return err
+	}
+	batch := make([]BackupRecord, 0, len(records))
+	var missing int64
+	for _, rec := range records {
+		if rec == nil {
+			missing++
+			continue
+		}
+		batch = append(batch, *rec)
+	}
+	result := &ImportResult{}
+	err = m.target.importBatch(ctx, batch, m.opts.Replace, result)
+
+	m.mu.Lock()
+	m.stats.Missing += missing
+	m.stats.Copied += result.Restored
+	m.stats.Skipped += result.Skipped
+	m.mu.Unlock()
+	return err
+}
+
+// migrateKeys 在源实例上执行 MIGRATE ... COPY REPLACE KEYS，由源实例把键传输到目标实例
+// MIGRATE 会跳过不存在的键且不报告是哪些，因此先用一次流水线 EXISTS 排除已被删除的键，返回实际复制的键数量
+func (m *KeyMigrator) migrateKeys(ctx context.Context, keys []string) (int64, error) {
+	targetOpts := m.target.client.(*redis.Client).Options()
+	host, port, err := net.SplitHostPort(targetOpts.Addr)
+	if err != nil {
+		return 0, fmt.Errorf("解析目标地址 %s 失败: %w", targetOpts.Addr, err)
+	}
+
+	exists := make([]*redis.IntCmd, len(keys))
+	_, err = m.source.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, key := range keys {
+			exists[i] = pipe.Exists(ctx, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("检查 %d 个键是否存在失败: %w", len(keys), err)
+	}
+	present := make([]string, 0, len(keys))
+	for i, key := range keys {
+		if exists[i].Val() > 0 {
+			present = append(present, key)
+		}
+	}
+	if len(present) == 0 {
+		return 0, nil
+	}
+
+	args := []interface{}{"MIGRATE", host, port, "", targetOpts.DB, m.opts.Timeout.Milliseconds(), "COPY", "REPLACE"}
+	if targetOpts.Username != "" {
+		args = append(args, "AUTH2", targetOpts.Username, targetOpts.Password)
+	} else if targetOpts.Password != "" {
+		args = append(args, "AUTH", targetOpts.Password)
+	}
+	args = append(args, "KEYS")
+	for _, key := range present {
+		args = append(args, key)
+	}
+	reply, err := m.source.client.Do(ctx, args...).Text()
+	if err != nil {
+		return 0, fmt.Errorf("MIGRATE %d 个键到 %s 失败: %w", len(present), targetOpts.Addr, err)
+	}
+	// 检查之后到执行之前所有键都被删除时返回 NOKEY
+	if reply == "NOKEY" {
+		return 0, nil
+	}
+	return int64(len(present)), nil
+}
+
+// markDirty 记录发生变更的键
+func (m *KeyMigrator) markDirty(ctx context.Context, event *KeyEvent) error {
+	m.mu.Lock()
+	m.dirty[event.Key] = struct{}{}
+	m.mu.Unlock()
+	select {
+	case m.changed <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Sync 把当前记录的脏键同步到目标端：源端存在的键覆盖复制，已不存在的键在目标端删除
+// 参数：
+//   - ctx: 上下文
+//
+// 返回：
+//   - int64: 本次同步的键数量
+//   - error: 同步失败时返回错误，未同步的键保留在脏键中，下次调用时重试
+func (m *KeyMigrator) Sync(ctx context.Context) (int64, error) {
+	m.mu.Lock()
+	keys := make([]string, 0, len(m.dirty))
+	for key := range m.dirty {
+		keys = append(keys, key)
+	}
+	m.dirty = make(map[string]struct{})
+	m.mu.Unlock()
+
+	var synced int64
+	for start := 0; start < len(keys); start += m.opts.BatchSize {
+		batch := keys[start:min(start+m.opts.BatchSize, len(keys))]
+		if err := m.syncBatch(ctx, batch); err != nil {
+			m.mu.Lock()
+			for _, key := range keys[start:] {
+				m.dirty[key] = struct{}{}
+			}
+			m.mu.Unlock()
+			return synced, err
+		}
+		synced += int64(len(batch))
+		m.mu.Lock()
+		m.stats.Synced += int64(len(batch))
+		m.mu.Unlock()
+	}
+	if synced > 0 {
+		m.report()
+	}
+	return synced, nil
+}
+
+// syncBatch 同步一批脏键
+func (m *KeyMigrator) syncBatch(ctx context.Context, keys []string) error {
+	records, err := m.source.dumpKeys(ctx, keys)
+	if err != nil {
+		return err
+	}
+	var restore []BackupRecord
+	var deleted []string
+	for i, rec := range records {
+		if rec == nil {
+			deleted = append(deleted, keys[i])
+		} else {
+			restore = append(restore, *rec)
+		}
+	}
+	if err := m.target.importBatch(ctx, restore, true, &ImportResult{}); err != nil {
+		return err
+	}
+	if len(deleted) > 0 {
+		if _, err := m.target.DelCount(ctx, deleted...); err != nil {
+			return fmt.Errorf("删除目标端的 %d 个键失败: %w", len(deleted), err)
+		}
+	}
+	return nil
+}
+
+// Finish 在源库停写后完成迁移：持续同步直到 QuietPeriod 内没有新的变更，停止订阅后校验两端数据
+// 参数：
+//   - ctx: 上下文，源库未停写导致一直有变更时由调用方通过超时结束
+//
+// 返回：
+//   - *KeyMigrationVerification: 校验结果
+//   - error: 同步或校验失败时返回错误
+func (m *KeyMigrator) Finish(ctx context.Context) (*KeyMigrationVerification, error) {
+	if m.listener != nil {
+		quiet := time.NewTimer(m.opts.QuietPeriod)
+		defer quiet.Stop()
+		for done := false; !done; {
+			synced, err := m.Sync(ctx)
+			if err != nil {
+				return nil, err
+			}
+			if synced > 0 {
+				quiet.Reset(m.opts.QuietPeriod)
+			}
+			select {
+			case <-m.changed:
+			case <-quiet.C:
+				done = true
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+		if err := m.Close(ctx); err != nil {
+			return nil, err
+		}
+		// 停止订阅前最后到达的事件
+		if _, err := m.Sync(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return m.Verify(ctx)
+}
+
+// Close 停止订阅键空间通知，不影响已复制的数据
+func (m *KeyMigrator) Close(ctx context.Context) error {
+	if m.listener == nil {
+		return nil
+	}
+	listener := m.listener
+	m.listener = nil
+	return listener.Close(ctx)
+}
+
+// report 回调进度
+func (m *KeyMigrator) report() {
+	if m.opts.OnProgress != nil {
+		m.opts.OnProgress(m.Stats())
+	}
+}
+
+// =============================================================================
+// 校验
+// =============================================================================
+
+// Verify 比较两端匹配的键数量与每个键的内容摘要
+// 参数：
+//   - ctx: 上下文
+//
+// 返回：
+//   - *KeyMigrationVerification: 校验结果，源端仍有写入时结果可能不一致
+//   - error: 扫描或读取失败时返回错误
+func (m *KeyMigrator) Verify(ctx context.Context) (*KeyMigrationVerification, error) {
+	v := &KeyMigrationVerification{}
+	sample := func(key string) {
+		if len(v.Samples) < maxVerifySamples {
+			v.Samples = append(v.Samples, key)
+		}
+	}
+
+	// 以源端为准逐批比较，同时计算两端的摘要
+	err := scanBatches(ctx, m.source, m.opts.Match, m.opts.BatchSize, func(keys []string) error {
+		sourceDigests, err := m.source.digestKeys(ctx, keys)
+		if err != nil {
+			return err
+		}
+		targetDigests, err := m.target.digestKeys(ctx, keys)
+		if err != nil {
+			return err
+		}
+		for i, key := range keys {
+			src, dst := sourceDigests[i], targetDigests[i]
+			if src == 0 {
+				// 校验期间在源端被删除
+				continue
+			}
+			v.SourceKeys++
+			v.SourceChecksum ^= src
+			switch {
+			case dst == 0:
+				v.Missing++
+				sample(key)
+			case dst != src:
+				v.Mismatched++
+				sample(key)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return v, err
+	}
+
+	// 遍历目标端统计键数量与摘要，并找出源端不存在的键
+	err = scanBatches(ctx, m.target, m.opts.Match, m.opts.BatchSize, func(keys []string) error {
+		targetDigests, err := m.target.digestKeys(ctx, keys)
+		if err != nil {
+			return err
+		}
+		exists := make([]*redis.IntCmd, len(keys))
+		_, _ = m.source.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+			for i, key := range keys {
+				exists[i] = pipe.Exists(ctx, key)
+			}
+			return nil
+		})
+		for i, key := range keys {
+			if targetDigests[i] == 0 {
+				continue
+			}
+			v.TargetKeys++
+			v.TargetChecksum ^= targetDigests[i]
+			n, err := exists[i].Result()
+			if err != nil {
+				return fmt.Errorf("检查源端键 %s 是否存在失败: %w", key, err)
+			}
+			if n == 0 {
+				v.Extra++
+				sample(key)
+			}
+		}
+		return nil
+	})
+	return v, err
+}
+
+// scanBatches 扫描匹配的键并按批次回调
+func scanBatches(ctx context.Context, manager *RedisManager, match string, size int, fn func(keys []string) error) error {
+	batch := make([]string, 0, size)
+	for key, err := range manager.Scan(ctx, &ScanOptions{Match: match, Count: int64(size)}) {
+		if err != nil {
+			return err
+		}
+		batch = append(batch, key)
+		if len(batch) == size {
+			if err := fn(batch); err != nil {
+				return err
+			}
+			batch = batch[:0]
+		}
+	}
+	if len(batch) > 0 {
+		return fn(batch)
+	}
+	return nil
+}
+
+// digestKeys 计算一批键的内容摘要（键名、类型与规范化后的值），不存在的键为 0
+func (r *RedisManager) digestKeys(ctx context.Context, keys []string) ([]uint64, error) {
+	records, err := r.readJSONBatch(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+	digests := make([]uint64, len(keys))
+	for i, rec := range records {
+		if rec == nil {
+			continue
+		}
+		h := fnv.New64a()
+		h.Write([]byte(strings.Join([]string{keys[i], rec.Type, rec.Encoding}, "\x00")))
+		h.Write([]byte{0})
+		h.Write(rec.Value)
+		digests[i] = max(h.Sum64(), 1)
+	}
+	return digests, nil
+}
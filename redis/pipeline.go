@@ -1 +1,334 @@
 package redis
+
+// Pipeline 批量操作
+//
+// 场景说明：
+//   逐个执行命令时，每条命令都要付出一次网络往返（RTT）。批量执行器将大量操作按配置的
+//   块大小切分，每块通过一个 Pipeline 一次性发送，并返回与输入一一对应的结果切片，
+//   失败的操作会被汇总到 BatchError 中，便于调用方定位失败下标并重试。
+//   单个 Pipeline 会在一条连接上串行执行，ExecBatchParallel 将分块分发给多个 worker，
+//   借助连接池并发执行，适合缓存预热等大批量任务。
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// DefaultBatchChunkSize 默认每个 Pipeline 包含的命令数
+const DefaultBatchChunkSize = 500
+
+// BatchOperation 批量操作：向管道追加一条命令，并返回该命令以便读取结果
+type BatchOperation func(ctx context.Context, pipe redis.Pipeliner) redis.Cmder
+
+// BatchResult 单个操作的执行结果，Index 与输入切片下标一致
+type BatchResult struct {
+	Index int         // 操作在输入切片中的下标
+	Cmd   redis.Cmder // 命令对象，可类型断言为 *redis.StringCmd 等读取返回值
+	Err   error       // 操作错误，键不存在时为 redis.Nil
+}
+
+// BatchFailure 单个失败操作
+type BatchFailure struct {
+	Index int   // 操作在输入切片中的下标
+	Err   error // 失败原因
+}
+
+// BatchError 批量执行的聚合错误，列出所有失败的下标
+type BatchError struct {
+	Total    int            // 操作总数
+	Failures []BatchFailure // 失败的操作，按下标升序
+}
+
+// maxBatchErrorDetails 错误信息中最多展示的失败明细条数
+const maxBatchErrorDetails = 10
+
+func (e *BatchError) Error() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "批量操作共 %d 条，失败 %d 条", e.Total, len(e.Failures))
+	for i, f := range e.Failures {
+		if i == maxBatchErrorDetails {
+			fmt.Fprintf(&sb, "; 其余 %d 条省略", len(e.Failures)-maxBatchErrorDetails)
+			break
+		}
+		fmt.Fprintf(&sb, "; [%d] %v", f.Index, f.Err)
+	}
+	return sb.String()
+}
+
+// Unwrap 返回所有失败原因，支持 errors.Is / errors.As
+func (e *BatchError) Unwrap() []error {
+	errs := make([]error, len(e.Failures))
+	for i, f := range e.Failures {
+		errs[i] = f.Err
+	}
+	return errs
+}
+
+// FailedIndices 返回所有失败操作的下标
+func (e *BatchError) FailedIndices() []int {
+	indices := make([]int, len(e.Failures))
+	for i, f := range e.Failures {
+		indices[i] = f.Index
+	}
+	return indices
+}
+
+// BatchOptions 批量执行配置
+type BatchOptions struct {
+	ChunkSize int // 每个 Pipeline 包含的命令数，默认为 DefaultBatchChunkSize
+}
+
+// ExecBatch 分块执行批量操作
+// 键不存在（redis.Nil）不视为失败，仍会记录在对应结果的 Err 中。
+// 上下文取消时停止发送后续分块，未执行的操作以 ctx.Err() 记为失败。
+// 参数：
+//   - ctx: 上下文，用于控制超时和取消
+//   - ops: 批量操作列表
+//   - opts: 执行配置，为 nil 时使用默认配置
+//
+// 返回：
+//   - []BatchResult: 与 ops 一一对应的结果
+//   - error: 存在失败操作时返回 *BatchError
+func (r *RedisManager) ExecBatch(ctx context.Context, ops []BatchOperation, opts *BatchOptions) ([]BatchResult, error) {
+	chunkSize := DefaultBatchChunkSize
+	if opts != nil && opts.ChunkSize > 0 {
+		chunkSize = opts.ChunkSize
+	}
+
+	results := make([]BatchResult, len(ops))
+	for start := 0; start < len(ops); start += chunkSize {
+		end := min(start+chunkSize, len(ops))
+
+		if err := ctx.Err(); err != nil {
+			for i := start; i < len(ops); i++ {
+				results[i] = BatchResult{Index: i, Err: err}
+			}
+			break
+		}
+		r.execChunk(ctx, ops[start:end], results[start:end], start)
+	}
+
+	return results, collectBatchError(results)
+}
+
+// execChunk 通过一个 Pipeline 执行一块操作，结果写入 results（与 ops 等长）
+func (r *RedisManager) execChunk(ctx context.Context, ops []BatchOperation, results []BatchResult, offset int) {
+	execPipeline(ctx, r.client, ops, results, offset)
+}
+
+// execPipeline 在指定客户端上通过一个 Pipeline 执行一块操作，结果写入 results（与 ops 等长）
+func execPipeline(ctx context.Context, client redis.UniversalClient, ops []BatchOperation, results []BatchResult, offset int) {
+	pipe := client.Pipeline()
+	cmds := make([]redis.Cmder, len(ops))
+	for i, op := range ops {
+		cmds[i] = op(ctx, pipe)
+	}
+
+	// Exec 返回的是第一个失败命令的错误，逐条读取 Cmd.Err() 获得每个操作的结果
+	_, execErr := pipe.Exec(ctx)
+
+	for i, cmd := range cmds {
+		result := BatchResult{Index: offset + i, Cmd: cmd}
+		switch {
+		case cmd == nil:
+			result.Err = fmt.Errorf("批量操作 %d 未返回命令", offset+i)
+		case cmd.Err() != nil:
+			result.Err = cmd.Err()
+		case execErr != nil && isPipelineFatal(execErr):
+			// 网络等整体性错误时，个别命令可能未被标记错误
+			result.Err = execErr
+		}
+		results[i] = result
+	}
+}
+
+// collectBatchError 从结果中汇总失败操作，无失败时返回 nil
+func collectBatchError(results []BatchResult) error {
+	var failures []BatchFailure
+	for _, res := range results {
+		if res.Err != nil && !errors.Is(res.Err, redis.Nil) {
+			failures = append(failures, BatchFailure{Index: res.Index, Err: res.Err})
+		}
+	}
+	if len(failures) == 0 {
+		return nil
+	}
+	return &BatchError{Total: len(results), Failures: failures}
+}
+
+// isPipelineFatal 判断 Pipeline 错误是否为影响整个分块的错误（非单条命令的 Redis 错误）
+func isPipelineFatal(err error) bool {
+	var redisErr redis.Error
+	return !errors.As(err, &redisErr)
+}
+
+// ErrErrorBudgetExceeded 失败数超过错误预算，剩余操作未执行
+var ErrErrorBudgetExceeded = errors.New("批量操作失败数超过错误预算，已停止执行")
+
+// ParallelBatchOptions 并行批量执行配置
+type ParallelBatchOptions struct {
+	ChunkSize   int // 每个 Pipeline 包含的命令数，默认为 DefaultBatchChunkSize
+	Workers     int // 并发 worker 数，默认为 4，建议不超过连接池大小
+	ErrorBudget int // 允许的最大失败数，超过后停止分发剩余分块，0 表示不限制
+}
+
+// ExecBatchParallel 将批量操作分块后由多个 worker 并发执行
+// 结果与输入顺序一致；上下文取消或失败数超过错误预算时停止分发剩余分块，
+// 已在执行中的分块会执行完毕，未执行的操作分别以 ctx.Err() 或 ErrErrorBudgetExceeded 记为失败。
+// 注意：不同分块之间没有执行顺序保证，存在先后依赖的操作应放在同一分块或使用 ExecBatch。
+// 参数：
+//   - ctx: 上下文，用于控制超时和取消
+//   - ops: 批量操作列表
+//   - opts: 执行配置，为 nil 时使用默认配置
+//
+// 返回：
+//   - []BatchResult: 与 ops 一一对应的结果
+//   - error: 存在失败操作时返回 *BatchError
+func (r *RedisManager) ExecBatchParallel(ctx context.Context, ops []BatchOperation, opts *ParallelBatchOptions) ([]BatchResult, error) {
+	o := ParallelBatchOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.ChunkSize <= 0 {
+		o.ChunkSize = DefaultBatchChunkSize
+	}
+	if o.Workers <= 0 {
+		o.Workers = 4
+	}
+
+	results := make([]BatchResult, len(ops))
+	chunks := make(chan int)
+	stop := make(chan struct{})
+	var (
+		failures atomic.Int64
+		aborted  atomic.Bool
+		stopOnce sync.Once
+		wg       sync.WaitGroup
+	)
+
+	// stopReason 返回停止执行的原因，上下文取消优先于超出错误预算
+	stopReason := func() error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if aborted.Load() {
+			return ErrErrorBudgetExceeded
+		}
+		return nil
+	}
+	skip := func(start, end int, reason error) {
+		for i := start; i < end; i++ {
+			results[i] = BatchResult{Index: i, Err: reason}
+		}
+	}
+
+	for w := 0; w < o.Workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for start := range chunks {
+				end := min(start+o.ChunkSize, len(ops))
+				// select 在多个分支同时就绪时随机选择，已取消或超出预算后收到的分块不再执行
+				if reason := stopReason(); reason != nil {
+					skip(start, end, reason)
+					continue
+				}
+				r.execChunk(ctx, ops[start:end], results[start:end], start)
+
+				if o.ErrorBudget > 0 {
+					for _, res := range results[start:end] {
+						if res.Err != nil && !errors.Is(res.Err, redis.Nil) {
+							failures.Add(1)
+						}
+					}
+					if failures.Load() > int64(o.ErrorBudget) {
+						aborted.Store(true)
+						stopOnce.Do(func() { close(stop) })
+					}
+				}
+			}
+		}()
+	}
+
+	// 按顺序分发分块，每次发送前检查取消与错误预算
+	next := 0
+dispatch:
+	for ; next < len(ops); next += o.ChunkSize {
+		if stopReason() != nil {
+			break
+		}
+		select {
+		case chunks <- next:
+		case <-ctx.Done():
+			break dispatch
+		case <-stop:
+			break dispatch
+		}
+	}
+	close(chunks)
+	wg.Wait()
+
+	// 标记未分发的操作
+	if next < len(ops) {
+		reason := stopReason()
+		if reason == nil {
+			reason = ErrErrorBudgetExceeded
+		}
+		skip(next, len(ops), reason)
+	}
+
+	return results, collectBatchError(results)
+}
+
+// =============================================================================
+// 常用批量操作构造函数
+// =============================================================================
+
+// SetOp 构造 SET 批量操作
+func SetOp(key, value string, expiration time.Duration) BatchOperation {
+	return func(ctx context.Context, pipe redis.Pipeliner) redis.Cmder {
+		return pipe.Set(ctx, key, value, expiration)
+	}
+}
+
+// GetOp 构造 GET 批量操作，结果可断言为 *redis.StringCmd
+func GetOp(key string) BatchOperation {
+	return func(ctx context.Context, pipe redis.Pipeliner) redis.Cmder {
+		return pipe.Get(ctx, key)
+	}
+}
+
+// DelOp 构造 DEL 批量操作
+func DelOp(keys ...string) BatchOperation {
+	return func(ctx context.Context, pipe redis.Pipeliner) redis.Cmder {
+		return pipe.Del(ctx, keys...)
+	}
+}
+
+// IncrOp 构造 INCR 批量操作，结果可断言为 *redis.IntCmd
+func IncrOp(key string) BatchOperation {
+	return func(ctx context.Context, pipe redis.Pipeliner) redis.Cmder {
+		return pipe.Incr(ctx, key)
+	}
+}
+
+// HSetOp 构造 HSET 批量操作
+func HSetOp(key string, values ...interface{}) BatchOperation {
+	return func(ctx context.Context, pipe redis.Pipeliner) redis.Cmder {
+		return pipe.HSet(ctx, key, values...)
+	}
+}
+
+// ExpireOp 构造 EXPIRE 批量操作
+func ExpireOp(key string, expiration time.Duration) BatchOperation {
+	return func(ctx context.Context, pipe redis.Pipeliner) redis.Cmder {
+		return pipe.Expire(ctx, key, expiration)
+	}
+}
@@ -1 +1,649 @@
 package redis
+
+// 发布/订阅
+//
+// 场景说明：
+//   Subscriber 在 go-redis PubSub 之上提供托管的订阅能力：
+//   - 支持频道（SUBSCRIBE）、模式（PSUBSCRIBE）与分片频道（SSUBSCRIBE）订阅，三者共用同一套处理函数 API；
+//   - 消息分发到固定大小的 worker 池并发处理，处理函数 panic 会被恢复并通过 OnError 上报；
+//   - 连接断开或心跳失败后按指数退避重建连接，并重新订阅所有频道与模式；
+//   - Close(ctx) 停止接收新消息，等待已接收消息处理完毕（优雅关闭），超时后取消处理上下文。
+//
+// 分片 Pub/Sub（Redis 7+）：
+//   普通 Pub/Sub 在集群中会广播到所有节点，无法水平扩展。分片频道按哈希槽归属到单个分片，
+//   SPUBLISH 只在该分片内传播。集群模式下订阅者按频道所在槽位的主节点分组，每个节点使用一条连接，
+//   同一连接上按槽位分别发送 SSUBSCRIBE（单条命令的频道必须位于同一槽位）；槽位迁移时服务端会主动
+//   下发 sunsubscribe，订阅者据此刷新集群拓扑，重新分组后向新的槽位所有者订阅。
+//
+// 注意：Pub/Sub 为"至多一次"投递，断线期间发布的消息会丢失；需要可靠投递时请使用 Stream 或列表。
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// MessageHandler 消息处理函数
+type MessageHandler func(ctx context.Context, msg *redis.Message) error
+
+// ErrSubscriberClosed 订阅者已关闭
+var ErrSubscriberClosed = errors.New("订阅者已关闭")
+
+// HandlerPanicError 处理函数 panic 时上报的错误
+type HandlerPanicError struct {
+	Channel string      // 消息所在频道
+	Value   interface{} // recover() 得到的值
+	Stack   []byte      // panic 时的调用栈
+}
+
+func (e *HandlerPanicError) Error() string {
+	return fmt.Sprintf("处理频道 %s 的消息时发生 panic: %v", e.Channel, e.Value)
+}
+
+// SubscriberOptions 订阅者配置
+type SubscriberOptions struct {
+	Workers        int           // 处理消息的 worker 数，默认为 8；需要严格保序时设置为 1
+	BufferSize     int           // 待处理消息缓冲区大小，默认为 1024，缓冲区满时暂停接收
+	PingInterval   time.Duration // 无消息时的心跳间隔，默认为 30 秒
+	MinBackoff     time.Duration // 重连最小退避时间，默认为 100 毫秒
+	MaxBackoff     time.Duration // 重连最大退避时间，默认为 5 秒
+	OnError        func(err error)
+	OnResubscribed func() // 断线重连（或槽位迁移）并重新订阅成功后回调，可用于补偿断线期间丢失的消息
+}
+
+// subscriptionKind 订阅类型
+type subscriptionKind int
+
+const (
+	kindChannel subscriptionKind = iota // SUBSCRIBE
+	kindPattern                         // PSUBSCRIBE
+	kindShard                           // SSUBSCRIBE
+)
+
+// subscription 订阅的频道、模式或分片频道
+type subscription struct {
+	kind    subscriptionKind
+	handler MessageHandler
+}
+
+const (
+	classicConn = ""      // 普通频道与模式共用连接的标识
+	shardConn   = "shard" // 非集群模式下分片频道共用连接的标识
+)
+
+// subConn 一条订阅连接：普通频道与模式共用一条连接，集群模式下分片频道按所在主节点各用一条连接
+type subConn struct {
+	node    string // 分片频道所在主节点地址，普通连接为 classicConn
+	pubsub  *redis.PubSub
+	names   map[string]struct{} // 该连接上已订阅的频道与模式
+	pending []*redis.Message    // 等待订阅确认期间收到的消息，由接收 goroutine 投递
+}
+
+// Subscriber 托管的 Pub/Sub 订阅者
+type Subscriber struct {
+	client redis.UniversalClient
+	opts   SubscriberOptions
+
+	mu      sync.Mutex
+	subs    map[string]subscription // 频道/模式名 -> 订阅信息，同名只能注册一次
+	conns   map[string]*subConn     // 节点地址 -> 订阅连接，classicConn 为普通连接
+	started bool
+	closed  bool
+
+	jobs          chan *redis.Message
+	recvCtx       context.Context
+	recvCancel    context.CancelFunc
+	handlerCtx    context.Context
+	handlerCancel context.CancelFunc
+	recvWG        sync.WaitGroup
+	workerWG      sync.WaitGroup
+}
+
+// NewSubscriber 创建托管的订阅者
+// 参数：
+//   - opts: 订阅者配置，为 nil 时使用默认配置
+//
+// 返回：
+//   - *Subscriber: 订阅者实例，注册处理函数后调用 Start 开始接收
+func (r *RedisManager) NewSubscriber(opts *SubscriberOptions) *Subscriber {
+	return newSubscriber(r.client, opts)
+}
+
+// newSubscriber 基于任意拓扑的客户端创建订阅者
+func newSubscriber(client redis.UniversalClient, opts *SubscriberOptions) *Subscriber {
+	o := SubscriberOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.Workers <= 0 {
+		o.Workers = 8
+	}
+	if o.BufferSize <= 0 {
+		o.BufferSize = 1024
+	}
+	if o.PingInterval <= 0 {
+		o.PingInterval = 30 * time.Second
+	}
+	if o.MinBackoff <= 0 {
+		o.MinBackoff = 100 * time.Millisecond
+	}
+	if o.MaxBackoff < o.MinBackoff {
+		o.MaxBackoff = max(5*time.Second, o.MinBackoff)
+	}
+
+	recvCtx, recvCancel := context.WithCancel(context.Background())
+	handlerCtx, handlerCancel := context.WithCancel(context.Background())
+	return &Subscriber{
+		client:        client,
+		opts:          o,
+		subs:          make(map[string]subscription),
+		conns:         make(map[string]*subConn),
+		jobs:          make(chan *redis.Message, o.BufferSize),
+		recvCtx:       recvCtx,
+		recvCancel:    recvCancel,
+		handlerCtx:    handlerCtx,
+		handlerCancel: handlerCancel,
+	}
+}
+
+// Publish 向频道发布消息
+// 返回：
+//   - int64: 收到消息的订阅者数量
+//   - error: 发布失败时返回错误
+func (r *RedisManager) Publish(ctx context.Context, channel string, message interface{}) (int64, error) {
+	receivers, err := r.client.Publish(ctx, channel, message).Result()
+	if err != nil {
+		return 0, fmt.Errorf("向频道 %s 发布消息失败: %w", channel, err)
+	}
+	return receivers, nil
+}
+
+// SPublish 向分片频道发布消息（Redis 7+），集群中只在频道所属分片内传播
+// 返回：
+//   - int64: 收到消息的订阅者数量
+//   - error: 发布失败时返回错误
+func (r *RedisManager) SPublish(ctx context.Context, channel string, message interface{}) (int64, error) {
+	receivers, err := r.client.SPublish(ctx, channel, message).Result()
+	if err != nil {
+		return 0, fmt.Errorf("向分片频道 %s 发布消息失败: %w", channel, err)
+	}
+	return receivers, nil
+}
+
+// Handle 注册频道处理函数，Start 之后调用会立即订阅该频道
+func (s *Subscriber) Handle(ctx context.Context, channel string, handler MessageHandler) error {
+	return s.register(ctx, channel, subscription{kind: kindChannel, handler: handler})
+}
+
+// HandlePattern 注册模式处理函数（PSUBSCRIBE），Start 之后调用会立即订阅该模式
+func (s *Subscriber) HandlePattern(ctx context.Context, pattern string, handler MessageHandler) error {
+	return s.register(ctx, pattern, subscription{kind: kindPattern, handler: handler})
+}
+
+// HandleShard 注册分片频道处理函数（SSUBSCRIBE，Redis 7+），Start 之后调用会立即订阅该频道
+func (s *Subscriber) HandleShard(ctx context.Context, channel string, handler MessageHandler) error {
+	return s.register(ctx, channel, subscription{kind: kindShard, handler: handler})
+}
+
+// register 登记订阅，已启动时立即向服务端订阅
+func (s *Subscriber) register(ctx context.Context, name string, sub subscription) error {
+	if name == "" || sub.handler == nil {
+		return fmt.Errorf("订阅名称和处理函数不能为空")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrSubscriberClosed
+	}
+	if _, exists := s.subs[name]; exists {
+		return fmt.Errorf("%s 已注册处理函数", name)
+	}
+	s.subs[name] = sub
+
+	if !s.started {
+		return nil
+	}
+
+	node, err := s.nodeOf(ctx, name, sub)
+	if err != nil {
+		delete(s.subs, name)
+		return err
+	}
+	if conn, ok := s.conns[node]; ok {
+		// 已有连接时在该连接上追加订阅
+		if err := subscribeTo(ctx, conn.pubsub, name, sub); err != nil {
+			delete(s.subs, name)
+			return fmt.Errorf("订阅 %s 失败: %w", name, err)
+		}
+		conn.names[name] = struct{}{}
+		return nil
+	}
+
+	if err := s.openConn(ctx, node, []string{name}); err != nil {
+		delete(s.subs, name)
+		return err
+	}
+	return nil
+}
+
+// Start 订阅所有已注册的频道、模式与分片频道，并启动接收与处理 goroutine
+func (s *Subscriber) Start(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrSubscriberClosed
+	}
+	if s.started {
+		return fmt.Errorf("订阅者已启动")
+	}
+	if len(s.subs) == 0 {
+		return fmt.Errorf("至少需要注册一个频道或模式")
+	}
+
+	groups, err := s.groupByNode(ctx)
+	if err != nil {
+		return err
+	}
+
+	// 先建立所有连接，全部成功后再启动接收 goroutine，避免部分失败时残留重连循环
+	conns := make([]*subConn, 0, len(groups))
+	for node, names := range groups {
+		conn, err := s.subscribeNode(ctx, node, names)
+		if err != nil {
+			for _, conn := range conns {
+				conn.pubsub.Close()
+			}
+			return err
+		}
+		conns = append(conns, conn)
+	}
+	for _, conn := range conns {
+		s.startConn(conn)
+	}
+	s.started = true
+
+	for i := 0; i < s.opts.Workers; i++ {
+		s.workerWG.Add(1)
+		go s.worker()
+	}
+	return nil
+}
+
+// Close 优雅关闭：停止接收新消息，等待已接收的消息处理完毕
+// 参数：
+//   - ctx: 等待处理完毕的超时控制，超时后会取消传给处理函数的上下文
+//
+// 返回：
+//   - error: 未能在 ctx 截止前处理完毕时返回 ctx.Err()
+func (s *Subscriber) Close(ctx context.Context) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	started := s.started
+	s.recvCancel()
+	s.closeConns()
+	s.mu.Unlock()
+
+	if !started {
+		s.handlerCancel()
+		return nil
+	}
+
+	// 接收 goroutine 全部退出后不会再写入 jobs，可以安全关闭
+	s.recvWG.Wait()
+	close(s.jobs)
+
+	drained := make(chan struct{})
+	go func() {
+		s.workerWG.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		s.handlerCancel()
+		return nil
+	case <-ctx.Done():
+		// 通知仍在执行的处理函数尽快退出
+		s.handlerCancel()
+		return fmt.Errorf("等待消息处理完毕超时: %w", ctx.Err())
+	}
+}
+
+// nodeOf 返回订阅所属的连接标识：普通订阅共用一条连接，集群模式下分片频道按所在槽位的主节点分组
+func (s *Subscriber) nodeOf(ctx context.Context, name string, sub subscription) (string, error) {
+	if sub.kind != kindShard {
+		return classicConn, nil
+	}
+	cluster, ok := s.client.(*redis.ClusterClient)
+	if !ok {
+		// 非集群模式下分片频道可以混合订阅，全部放在同一条连接上
+		return shardConn, nil
+	}
+	master, err := cluster.MasterForKey(ctx, name)
+	if err != nil {
+		return "", fmt.Errorf("查找分片频道 %s 所在节点失败: %w", name, err)
+	}
+	return master.Options().Addr, nil
+}
+
+// groupByNode 将所有订阅按所属连接分组（需持有 s.mu）
+func (s *Subscriber) groupByNode(ctx context.Context) (map[string][]string, error) {
+	groups := make(map[string][]string)
+	for name, sub := range s.subs {
+		node, err := s.nodeOf(ctx, name, sub)
+		if err != nil {
+			return nil, err
+		}
+		groups[node] = append(groups[node], name)
+	}
+	return groups, nil
+}
+
+// openConn 为指定节点建立订阅连接并启动接收 goroutine（需持有 s.mu）
+func (s *Subscriber) openConn(ctx context.Context, node string, names []string) error {
+	conn, err := s.subscribeNode(ctx, node, names)
+	if err != nil {
+		return err
+	}
+	s.startConn(conn)
+	return nil
+}
+
+// startConn 登记订阅连接并启动接收 goroutine（需持有 s.mu）
+func (s *Subscriber) startConn(conn *subConn) {
+	s.conns[conn.node] = conn
+	s.recvWG.Add(1)
+	go s.receive(conn)
+}
+
+// closeConns 关闭所有订阅连接（需持有 s.mu）
+func (s *Subscriber) closeConns() {
+	for node, conn := range s.conns {
+		conn.pubsub.Close()
+		delete(s.conns, node)
+	}
+}
+
+// receive 接收一条连接上的消息并投递到 worker，连接异常或槽位迁移时重建订阅
+func (s *Subscriber) receive(conn *subConn) {
+	defer s.recvWG.Done()
+
+	backoff := s.opts.MinBackoff
+	for {
+		s.mu.Lock()
+		pubsub := conn.pubsub
+		pending := conn.pending
+		conn.pending = nil
+		retired := s.conns[conn.node] != conn
+		s.mu.Unlock()
+		if retired {
+			// 节点上已没有需要订阅的频道，连接已关闭
+			return
+		}
+
+		// 先投递等待订阅确认期间收到的消息，缓冲区满时阻塞等待，不丢弃
+		for _, m := range pending {
+			select {
+			case s.jobs <- m:
+			case <-s.recvCtx.Done():
+				return
+			}
+		}
+
+		msg, err := pubsub.ReceiveTimeout(s.recvCtx, s.opts.PingInterval)
+		if err != nil {
+			if s.recvCtx.Err() != nil {
+				return
+			}
+			if isTimeout(err) {
+				// 长时间无消息，发送心跳检测连接是否存活，PONG 会在下次 Receive 时返回
+				if pingErr := pubsub.Ping(s.recvCtx); pingErr == nil {
+					continue
+				}
+			}
+
+			s.reportError(fmt.Errorf("接收订阅消息失败，%v 后重新订阅: %w", backoff, err))
+			if !s.sleep(backoff) {
+				return
+			}
+			backoff = min(backoff*2, s.opts.MaxBackoff)
+			if s.resubscribe(conn) {
+				backoff = s.opts.MinBackoff
+			}
+			continue
+		}
+
+		switch m := msg.(type) {
+		case *redis.Message:
+			select {
+			case s.jobs <- m:
+			case <-s.recvCtx.Done():
+				return
+			}
+		case *redis.Subscription:
+			// 服务端主动退订分片频道，说明槽位已迁移到其他节点
+			if m.Kind == "sunsubscribe" && s.isRegistered(m.Channel) {
+				s.reportError(fmt.Errorf("分片频道 %s 被服务端退订（槽位迁移），重新订阅", m.Channel))
+				if !s.resubscribe(conn) {
+					// 新的槽位所有者暂不可用时关闭连接，由上面的退避重连逻辑继续重试
+					s.mu.Lock()
+					conn.pubsub.Close()
+					s.mu.Unlock()
+				}
+			}
+		case *redis.Pong:
+			// 心跳响应无需处理
+		}
+	}
+}
+
+// resubscribe 关闭旧连接并重新订阅该连接负责的所有频道，成功返回 true
+// 集群模式下会按最新拓扑重新分组，迁移到其他节点的分片频道改由对应节点的连接订阅
+func (s *Subscriber) resubscribe(conn *subConn) bool {
+	// 集群拓扑可能已变化（故障转移或槽位迁移），重新订阅前刷新槽位映射
+	cluster, isCluster := s.client.(*redis.ClusterClient)
+	if isCluster && conn.node != classicConn {
+		cluster.ReloadState(s.recvCtx)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return false
+	}
+
+	groups, err := s.groupByNode(s.recvCtx)
+	if err != nil {
+		s.reportError(err)
+		return false
+	}
+	if len(groups[conn.node]) == 0 {
+		// 该节点不再负责任何频道，关闭连接，接收 goroutine 随后退出
+		conn.pubsub.Close()
+		delete(s.conns, conn.node)
+	} else {
+		fresh, err := s.subscribeNode(s.recvCtx, conn.node, groups[conn.node])
+		if err != nil {
+			s.reportError(err)
+			return false
+		}
+		conn.pubsub.Close()
+		conn.pubsub, conn.names, conn.pending = fresh.pubsub, fresh.names, fresh.pending
+	}
+
+	// 迁入其他节点的分片频道：已有连接时追加订阅，否则为该节点新建连接
+	for node, names := range groups {
+		if node == conn.node || node == classicConn {
+			continue
+		}
+		other, ok := s.conns[node]
+		if !ok {
+			if err := s.openConn(s.recvCtx, node, names); err != nil {
+				s.reportError(err)
+			}
+			continue
+		}
+		for _, name := range names {
+			if _, subscribed := other.names[name]; subscribed {
+				continue
+			}
+			if err := subscribeTo(s.recvCtx, other.pubsub, name, s.subs[name]); err != nil {
+				s.reportError(fmt.Errorf("订阅 %s 失败: %w", name, err))
+				continue
+			}
+			other.names[name] = struct{}{}
+		}
+	}
+
+	if s.opts.OnResubscribed != nil {
+		go s.opts.OnResubscribed()
+	}
+	return true
+}
+
+// subscribeNode 创建新的 PubSub 连接并订阅指定的频道、模式或分片频道（需持有 s.mu）
+func (s *Subscriber) subscribeNode(ctx context.Context, node string, names []string) (*subConn, error) {
+	var channels, patterns, shards []string
+	for _, name := range names {
+		switch s.subs[name].kind {
+		case kindChannel:
+			channels = append(channels, name)
+		case kindPattern:
+			patterns = append(patterns, name)
+		case kindShard:
+			shards = append(shards, name)
+		}
+	}
+
+	var pubsub *redis.PubSub
+	if node == classicConn {
+		pubsub = s.client.Subscribe(ctx)
+		if len(channels) > 0 {
+			if err := pubsub.Subscribe(ctx, channels...); err != nil {
+				pubsub.Close()
+				return nil, fmt.Errorf("订阅频道 %v 失败: %w", channels, err)
+			}
+		}
+		if len(patterns) > 0 {
+			if err := pubsub.PSubscribe(ctx, patterns...); err != nil {
+				pubsub.Close()
+				return nil, fmt.Errorf("订阅模式 %v 失败: %w", patterns, err)
+			}
+		}
+	} else {
+		// 集群客户端根据首个频道的槽位选择节点，同一节点上不同槽位的频道分别发送 SSUBSCRIBE
+		pubsub = s.client.SSubscribe(ctx)
+		for _, group := range GroupKeysBySlot(shards) {
+			if err := pubsub.SSubscribe(ctx, group...); err != nil {
+				pubsub.Close()
+				return nil, fmt.Errorf("订阅分片频道 %v 失败: %w", group, err)
+			}
+		}
+	}
+
+	// 等待订阅确认，确保 Start 返回后即可收到消息；确认之前到达的消息暂存，由接收 goroutine 投递
+	conn := &subConn{node: node, pubsub: pubsub, names: make(map[string]struct{}, len(names))}
+	for i := 0; i < len(names); i++ {
+		msg, err := pubsub.Receive(ctx)
+		if err != nil {
+			pubsub.Close()
+			return nil, fmt.Errorf("等待订阅确认失败: %w", err)
+		}
+		if m, ok := msg.(*redis.Message); ok {
+			i--
+			conn.pending = append(conn.pending, m)
+		}
+	}
+	for _, name := range names {
+		conn.names[name] = struct{}{}
+	}
+	return conn, nil
+}
+
+// subscribeTo 在已有连接上追加订阅
+func subscribeTo(ctx context.Context, pubsub *redis.PubSub, name string, sub subscription) error {
+	switch sub.kind {
+	case kindPattern:
+		return pubsub.PSubscribe(ctx, name)
+	case kindShard:
+		return pubsub.SSubscribe(ctx, name)
+	default:
+		return pubsub.Subscribe(ctx, name)
+	}
+}
+
+// isRegistered 判断频道是否仍处于订阅状态
+func (s *Subscriber) isRegistered(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.subs[name]
+	return ok
+}
+
+// worker 从缓冲区取出消息并调用处理函数
+func (s *Subscriber) worker() {
+	defer s.workerWG.Done()
+	for msg := range s.jobs {
+		s.dispatch(msg)
+	}
+}
+
+// dispatch 查找处理函数并执行，恢复 panic
+func (s *Subscriber) dispatch(msg *redis.Message) {
+	name := msg.Channel
+	if msg.Pattern != "" {
+		name = msg.Pattern
+	}
+
+	s.mu.Lock()
+	sub, ok := s.subs[name]
+	s.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	defer func() {
+		if v := recover(); v != nil {
+			s.reportError(&HandlerPanicError{Channel: msg.Channel, Value: v, Stack: debug.Stack()})
+		}
+	}()
+	if err := sub.handler(s.handlerCtx, msg); err != nil {
+		s.reportError(fmt.Errorf("处理频道 %s 的消息失败: %w", msg.Channel, err))
+	}
+}
+
+// reportError 上报错误
+func (s *Subscriber) reportError(err error) {
+	if s.opts.OnError != nil {
+		s.opts.OnError(err)
+	}
+}
+
+// sleep 可被 Close 中断的等待，被中断时返回 false
+func (s *Subscriber) sleep(d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-s.recvCtx.Done():
+		return false
+	}
+}
+
+// isTimeout 判断是否为读超时错误
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
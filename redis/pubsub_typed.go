@@ -0,0 +1,193 @@
+package redis
+
+// 类型化发布/订阅
+//
+// 场景说明：
+//   服务之间通过 Pub/Sub 交换结构化事件时，手写序列化代码容易出错且缺少统一的元数据。
+//   Publisher[T] / Subscription[T] 通过可插拔的 Codec 编解码负载，并为每条消息附加信封元数据
+//   （事件 ID、时间戳、来源）。解码失败的消息不会被静默丢弃，而是交给错误回调处理。
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Codec 消息编解码器
+type Codec interface {
+	Name() string                               // 编解码器名称，如 "json"
+	Marshal(v interface{}) ([]byte, error)      // 编码
+	Unmarshal(data []byte, v interface{}) error // 解码
+}
+
+// JSONCodec 基于 encoding/json 的编解码器
+type JSONCodec struct{}
+
+// Name 返回编解码器名称
+func (JSONCodec) Name() string { return "json" }
+
+// Marshal 编码为 JSON
+func (JSONCodec) Marshal(v interface{}) ([]byte, error) { return json.Marshal(v) }
+
+// Unmarshal 从 JSON 解码
+func (JSONCodec) Unmarshal(data []byte, v interface{}) error { return json.Unmarshal(data, v) }
+
+// Envelope 消息信封，携带元数据与类型化负载
+type Envelope[T any] struct {
+	ID        string    `json:"id"`        // 事件 ID，发布时自动生成
+	Timestamp time.Time `json:"timestamp"` // 发布时间
+	Source    string    `json:"source"`    // 发布方标识，如服务名
+	Payload   T         `json:"payload"`   // 业务负载
+}
+
+// DecodeError 消息解码失败时交给错误回调的错误
+type DecodeError struct {
+	Channel string // 消息所在频道
+	Payload string // 原始消息内容
+	Err     error  // 解码错误
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("解码频道 %s 的消息失败: %v", e.Channel, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// Publisher 类型化发布者
+type Publisher[T any] struct {
+	manager *RedisManager
+	channel string
+	source  string
+	codec   Codec
+}
+
+// NewPublisher 创建类型化发布者
+// 参数：
+//   - manager: Redis 管理器
+//   - channel: 发布的频道
+//   - source: 发布方标识，写入信封的 Source 字段
+//   - codec: 编解码器，为 nil 时使用 JSONCodec
+//
+// 返回：
+//   - *Publisher[T]: 发布者实例
+func NewPublisher[T any](manager *RedisManager, channel, source string, codec Codec) *Publisher[T] {
+	if codec == nil {
+		codec = JSONCodec{}
+	}
+	return &Publisher[T]{
+		manager: manager,
+		channel: channel,
+		source:  source,
+		codec:   codec,
+	}
+}
+
+// Publish 封装信封并发布消息
+// 返回：
+//   - *Envelope[T]: 实际发布的信封（含生成的事件 ID 与时间戳）
+//   - int64: 收到消息的订阅者数量
+//   - error: 编码或发布失败时返回错误
+func (p *Publisher[T]) Publish(ctx context.Context, payload T) (*Envelope[T], int64, error) {
+	id, err := newEventID()
+	if err != nil {
+		return nil, 0, err
+	}
+	env := &Envelope[T]{
+		ID:        id,
+		Timestamp: time.Now().UTC(),
+		Source:    p.source,
+		Payload:   payload,
+	}
+
+	data, err := p.codec.Marshal(env)
+	if err != nil {
+		return nil, 0, fmt.Errorf("使用 %s 编码频道 %s 的消息失败: %w", p.codec.Name(), p.channel, err)
+	}
+
+	receivers, err := p.manager.Publish(ctx, p.channel, data)
+	if err != nil {
+		return nil, 0, err
+	}
+	return env, receivers, nil
+}
+
+// EnvelopeHandler 类型化消息处理函数
+type EnvelopeHandler[T any] func(ctx context.Context, env *Envelope[T]) error
+
+// Subscription 类型化订阅，基于 Subscriber 的频道/模式处理函数实现
+type Subscription[T any] struct {
+	codec   Codec
+	handler EnvelopeHandler[T]
+	onError func(err error)
+}
+
+// SubscribeTyped 在订阅者上注册类型化频道处理函数
+// 参数：
+//   - ctx: 上下文，订阅者已启动时用于立即订阅
+//   - sub: 订阅者
+//   - channel: 频道名
+//   - codec: 编解码器，为 nil 时使用 JSONCodec
+//   - handler: 解码成功后的处理函数
+//   - onError: 解码失败时的回调，为 nil 时错误交给订阅者的 OnError
+//
+// 返回：
+//   - *Subscription[T]: 订阅实例
+//   - error: 注册失败时返回错误
+func SubscribeTyped[T any](ctx context.Context, sub *Subscriber, channel string, codec Codec, handler EnvelopeHandler[T], onError func(err error)) (*Subscription[T], error) {
+	s := newSubscription(codec, handler, onError)
+	if err := sub.Handle(ctx, channel, s.handle); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// SubscribeTypedPattern 在订阅者上注册类型化模式处理函数，参数同 SubscribeTyped
+func SubscribeTypedPattern[T any](ctx context.Context, sub *Subscriber, pattern string, codec Codec, handler EnvelopeHandler[T], onError func(err error)) (*Subscription[T], error) {
+	s := newSubscription(codec, handler, onError)
+	if err := sub.HandlePattern(ctx, pattern, s.handle); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// newSubscription 创建类型化订阅
+func newSubscription[T any](codec Codec, handler EnvelopeHandler[T], onError func(err error)) *Subscription[T] {
+	if codec == nil {
+		codec = JSONCodec{}
+	}
+	return &Subscription[T]{
+		codec:   codec,
+		handler: handler,
+		onError: onError,
+	}
+}
+
+// handle 解码原始消息并调用类型化处理函数
+func (s *Subscription[T]) handle(ctx context.Context, msg *redis.Message) error {
+	var env Envelope[T]
+	if err := s.codec.Unmarshal([]byte(msg.Payload), &env); err != nil {
+		decodeErr := &DecodeError{Channel: msg.Channel, Payload: msg.Payload, Err: err}
+		if s.onError != nil {
+			s.onError(decodeErr)
+			return nil
+		}
+		return decodeErr
+	}
+	return s.handler(ctx, &env)
+}
+
+// newEventID 生成 128 位随机事件 ID
+func newEventID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", fmt.Errorf("生成事件 ID 失败: %w", err)
+	}
+	return hex.EncodeToString(b[:]), nil
+}
@@ -0,0 +1,1432 @@
+package redis
+
+// RDB 文件解析
+//
+// 场景说明：
+//   在繁忙的主节点上用 SCAN 做大键分析或导出数据会占用服务端资源，而每晚生成的 dump.rdb 已经包含了全部数据。
+//   RDBReader 流式解析 RDB 文件，每次返回一个键（数据库编号、键名、类型、值、过期时间），
+//   内存占用只与单个键的大小有关。AnalyzeRDB 把解析结果交给 KeyspaceReportBuilder 生成与 AnalyzeKeyspace
+//   相同结构的大键/热键报告，ExportRDBToJSON 输出与 ExportJSON 相同格式的 JSON Lines，可以直接用 ImportJSON 导入。
+//
+//   支持的编码：
+//     string：原始字符串、整数编码、LZF 压缩
+//     list：  linkedlist、ziplist、quicklist（ziplist 节点）、quicklist2（listpack 节点与 plain 节点）
+//     set：   hashtable、intset、listpack
+//     zset：  skiplist（字符串与二进制分数）、ziplist、listpack
+//     hash：  hashtable、zipmap、ziplist、listpack
+//     stream：listpacks v1~v3（消费者组信息只解析不返回）
+//   以及 AUX、SELECTDB、RESIZEDB、EXPIRETIME、FREQ、IDLE、FUNCTION2、SLOT_INFO 等操作码与结尾的 CRC64 校验和。
+//
+// 注意：
+//   - 报告中的内存为值在 RDB 中的序列化字节数，通常小于 MEMORY USAGE 的结果（不含指针、哈希表等开销），适合相对比较；
+//   - 只有使用 LFU 淘汰策略时 RDB 才包含 FREQ，热键排行依赖该信息；
+//   - 模块类型（MODULE_2）只跳过值、不解析内容，导出 JSON 时返回 ErrRDBUnsupported；
+//     带字段过期时间的哈希（Redis 7.4+）不支持解析，读取时返回 ErrRDBUnsupported；
+//   - 集合的值会完整载入内存，单个超大键可能占用较多内存。
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc64"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	// ErrRDBFormat RDB 文件格式不正确或被截断
+	ErrRDBFormat = errors.New("RDB 文件格式不正确")
+	// ErrRDBChecksum RDB 文件校验和不匹配
+	ErrRDBChecksum = errors.New("RDB 文件校验和不匹配")
+	// ErrRDBUnsupported RDB 文件包含不支持解析的类型或操作码
+	ErrRDBUnsupported = errors.New("RDB 文件包含不支持的内容")
+)
+
+// RDB 操作码
+const (
+	rdbOpSlotInfo      = 0xF4
+	rdbOpFunction2     = 0xF5
+	rdbOpFunctionPreGA = 0xF6
+	rdbOpModuleAux     = 0xF7
+	rdbOpIdle          = 0xF8
+	rdbOpFreq          = 0xF9
+	rdbOpAux           = 0xFA
+	rdbOpResizeDB      = 0xFB
+	rdbOpExpireTimeMs  = 0xFC
+	rdbOpExpireTime    = 0xFD
+	rdbOpSelectDB      = 0xFE
+	rdbOpEOF           = 0xFF
+)
+
+// RDB 值类型
+const (
+	rdbTypeString          = 0
+	rdbTypeList            = 1
+	rdbTypeSet             = 2
+	rdbTypeZSet            = 3
+	rdbTypeHash            = 4
+	rdbTypeZSet2           = 5
+	rdbTypeModulePreGA     = 6
+	rdbTypeModule2         = 7
+	rdbTypeHashZipmap      = 9
+	rdbTypeListZiplist     = 10
+	rdbTypeSetIntset       = 11
+	rdbTypeZSetZiplist     = 12
+	rdbTypeHashZiplist     = 13
+	rdbTypeListQuicklist   = 14
+	rdbTypeStreamListpacks = 15
+	rdbTypeHashListpack    = 16
+	rdbTypeZSetListpack    = 17
+	rdbTypeListQuicklist2  = 18
+	rdbTypeStreamListpack2 = 19
+	rdbTypeSetListpack     = 20
+	rdbTypeStreamListpack3 = 21
+)
+
+// rdbTypes 值类型对应的 Redis 类型与编码名称
+var rdbTypes = map[byte][2]string{
+	rdbTypeString:          {"string", "string"},
+	rdbTypeList:            {"list", "linkedlist"},
+	rdbTypeSet:             {"set", "hashtable"},
+	rdbTypeZSet:            {"zset", "skiplist"},
+	rdbTypeHash:            {"hash", "hashtable"},
+	rdbTypeZSet2:           {"zset", "skiplist"},
+	rdbTypeModule2:         {"module", "module"},
+	rdbTypeHashZipmap:      {"hash", "zipmap"},
+	rdbTypeListZiplist:     {"list", "ziplist"},
+	rdbTypeSetIntset:       {"set", "intset"},
+	rdbTypeZSetZiplist:     {"zset", "ziplist"},
+	rdbTypeHashZiplist:     {"hash", "ziplist"},
+	rdbTypeListQuicklist:   {"list", "quicklist"},
+	rdbTypeStreamListpacks: {"stream", "listpacks"},
+	rdbTypeHashListpack:    {"hash", "listpack"},
+	rdbTypeZSetListpack:    {"zset", "listpack"},
+	rdbTypeListQuicklist2:  {"list", "quicklist"},
+	rdbTypeStreamListpack2: {"stream", "listpacks"},
+	rdbTypeSetListpack:     {"set", "listpack"},
+	rdbTypeStreamListpack3: {"stream", "listpacks"},
+}
+
+// rdbCRCTable Redis 使用的 CRC-64/Jones（反射形式的多项式）
+var rdbCRCTable = crc64.MakeTable(0x95ac9329ac4bc9b5)
+
+// RDBEntry RDB 文件中的一个键
+type RDBEntry struct {
+	DB       int
+	Key      string
+	Type     string    // string/list/set/zset/hash/stream/module
+	Encoding string    // RDB 中的编码，如 listpack、quicklist、intset
+	Expiry   time.Time // 过期时间，零值表示不过期
+	Freq     int64     // LFU 访问频率，RDB 中没有 FREQ 时为 0
+	Size     int64     // 值在 RDB 中的序列化字节数
+	Elements int64     // 元素数量，string 类型为字节长度
+	// Value 键的值：string 为 string，list/set 为 []string，zset 为 []redis.Z（成员为 string），
+	// hash 为 []HashField，stream 为 []JSONStreamEntry（不含已删除的消息），module 为 nil
+	Value any
+}
+
+// RDBReader 流式解析 RDB 文件
+type RDBReader struct {
+	r       *bufio.Reader
+	version int
+	aux     map[string]string
+	crc     uint64
+	offset  int64
+	done    bool
+
+	db     int
+	expiry time.Time
+	freq   int64
+}
+
+// NewRDBReader 创建 RDB 读取器并校验文件头
+// 参数：
+//   - r: RDB 文件内容
+//
+// 返回：
+//   - *RDBReader: 读取器，调用 Next 逐个读取键
+//   - error: 文件头不正确时返回 ErrRDBFormat
+func NewRDBReader(r io.Reader) (*RDBReader, error) {
+	rr := &RDBReader{r: bufio.NewReaderSize(r, 64*1024), aux: make(map[string]string)}
+	header, err := rr.readN(9)
+	if err != nil {
+		return nil, err
+	}
+	if string(header[:5]) != "REDIS" {
+		return nil, fmt.Errorf("%w: 文件标识不匹配", ErrRDBFormat)
+	}
+	version, err := strconv.Atoi(string(header[5:]))
+	if err != nil || version < 1 {
+		return nil, fmt.Errorf("%w: 版本号 %q 不正确", ErrRDBFormat, header[5:])
+	}
+	rr.version = version
+	return rr, nil
+}
+
+// NewRDBFileReader 打开 RDB 文件
+// 返回：
+//   - *RDBReader: 读取器
+//   - io.Closer: 用于关闭文件
+//   - error: 打开文件或文件头不正确时返回错误
+func NewRDBFileReader(path string) (*RDBReader, io.Closer, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("打开 RDB 文件失败: %w", err)
+	}
+	rr, err := NewRDBReader(f)
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	return rr, f, nil
+}
+
+// Version 返回 RDB 格式版本
+func (rr *RDBReader) Version() int {
+	return rr.version
+}
+
+// Aux 返回已读取到的辅助字段，如 redis-ver、ctime、used-mem
+func (rr *RDBReader) Aux() map[string]string {
+	return rr.aux
+}
+
+// Next 读取下一个键
+// 返回：
+//   - *RDBEntry: 键
+//   - error: 读到文件结尾并通过校验时返回 io.EOF；格式错误返回 ErrRDBFormat，校验和不匹配返回 ErrRDBChecksum
+func (rr *RDBReader) Next() (*RDBEntry, error) {
+	if rr.done {
+		return nil, io.EOF
+	}
+	for {
+		op, err := rr.readByte()
+		if err != nil {
+			return nil, err
+		}
+		switch op {
+		case rdbOpAux:
+			key, err := rr.readString()
+			if err != nil {
+				return nil, err
+			}
+			value, err := rr.readString()
+			if err != nil {
+				return nil, err
+			}
+			rr.aux[key] = value
+		case rdbOpSelectDB:
+			db, err := rr.readLength()
+			if err != nil {
+				return nil, err
+			}
+			rr.db = int(db)
+		case rdbOpResizeDB:
+			if err := rr.skipLengths(2); err != nil {
+				return nil, err
+			}
+		case rdbOpSlotInfo:
+			if err := rr.skipLengths(3); err != nil {
+				return nil, err
+			}
+		case rdbOpExpireTime:
+			data, err := rr.readN(4)
+			if err != nil {
+				return nil, err
+			}
+			rr.expiry = time.Unix(int64(binary.LittleEndian.Uint32(data)), 0)
+		case rdbOpExpireTimeMs:
+			ms, err := rr.readMillis()
+			if err != nil {
+				return nil, err
+			}
+			rr.expiry = time.UnixMilli(ms)
+		case rdbOpFreq:
+			freq, err := rr.readByte()
+			if err != nil {
+				return nil, err
+			}
+			rr.freq = int64(freq)
+		case rdbOpIdle:
+			if err := rr.skipLengths(1); err != nil {
+				return nil, err
+			}
+		case rdbOpFunction2:
+			if _, err := rr.readString(); err != nil {
+				return nil, err
+			}
+		case rdbOpModuleAux:
+			// 模块 ID、when_opcode、when，之后是带类型标记的值
+			if err := rr.skipLengths(3); err != nil {
+				return nil, err
+			}
+			if err := rr.skipModuleValues(); err != nil {
+				return nil, err
+			}
+		case rdbOpFunctionPreGA:
+			return nil, fmt.Errorf("%w: Redis 7.0 预览版的函数格式", ErrRDBUnsupported)
+		case rdbOpEOF:
+			return nil, rr.finish()
+		default:
+			return rr.readEntry(op)
+		}
+	}
+}
+
+// finish 校验文件结尾的 CRC64 校验和（RDB 5 起），为 0 表示生成时关闭了校验
+func (rr *RDBReader) finish() error {
+	rr.done = true
+	if rr.version < 5 {
+		return io.EOF
+	}
+	expected := rr.crc
+	data, err := rr.readN(8)
+	if err != nil {
+		return err
+	}
+	if sum := binary.LittleEndian.Uint64(data); sum != 0 && sum != expected {
+		return fmt.Errorf("%w: 期望 %016x，实际为 %016x", ErrRDBChecksum, sum, expected)
+	}
+	return io.EOF
+}
+
+// readEntry 读取键名与值，并附加之前读到的过期时间与访问频率
+func (rr *RDBReader) readEntry(valueType byte) (*RDBEntry, error) {
+	names, ok := rdbTypes[valueType]
+	if !ok {
+		return nil, fmt.Errorf("%w: 偏移 %d 处的值类型 %d", ErrRDBUnsupported, rr.offset-1, valueType)
+	}
+	key, err := rr.readString()
+	if err != nil {
+		return nil, err
+	}
+	entry := &RDBEntry{DB: rr.db, Key: key, Type: names[0], Encoding: names[1], Expiry: rr.expiry, Freq: rr.freq}
+	rr.expiry, rr.freq = time.Time{}, 0
+
+	start := rr.offset
+	if err := rr.readValue(valueType, entry); err != nil {
+		return nil, fmt.Errorf("解析键 %q 失败: %w", key, err)
+	}
+	entry.Size = rr.offset - start
+	return entry, nil
+}
+
+// readValue 按值类型解析值并填写 Value 与 Elements
+func (rr *RDBReader) readValue(valueType byte, entry *RDBEntry) error {
+	switch valueType {
+	case rdbTypeString:
+		s, err := rr.readString()
+		if err != nil {
+			return err
+		}
+		entry.Value, entry.Elements = s, int64(len(s))
+		return nil
+
+	case rdbTypeList, rdbTypeSet:
+		items, err := rr.readStrings(1)
+		if err != nil {
+			return err
+		}
+		entry.Value, entry.Elements = items, int64(len(items))
+		return nil
+
+	case rdbTypeHash:
+		items, err := rr.readStrings(2)
+		if err != nil {
+			return err
+		}
+		return setHashValue(entry, items)
+
+	case rdbTypeZSet, rdbTypeZSet2:
+		n, err := rr.readLength()
+		if err != nil {
+			return err
+		}
+		members := make([]redis.Z, 0, min(n, 1024))
+		for range n {
+			member, err := rr.readString()
+			if err != nil {
+				return err
+			}
+			var score float64
+			if valueType == rdbTypeZSet2 {
+				data, err := rr.readN(8)
+				if err != nil {
+					return err
+				}
+				score = math.Float64frombits(binary.LittleEndian.Uint64(data))
+			} else if score, err = rr.readStringScore(); err != nil {
+				return err
+			}
+			members = append(members, redis.Z{Member: member, Score: score})
+		}
+		entry.Value, entry.Elements = members, int64(len(members))
+		return nil
+
+	case rdbTypeModule2:
+		// 模块 ID 之后是带类型标记的值，只跳过不解析
+		if err := rr.skipLengths(1); err != nil {
+			return err
+		}
+		return rr.skipModuleValues()
+
+	case rdbTypeHashZipmap:
+		blob, err := rr.readString()
+		if err != nil {
+			return err
+		}
+		items, err := parseZipmap([]byte(blob))
+		if err != nil {
+			return err
+		}
+		return setHashValue(entry, items)
+
+	case rdbTypeSetIntset:
+		blob, err := rr.readString()
+		if err != nil {
+			return err
+		}
+		items, err := parseIntset([]byte(blob))
+		if err != nil {
+			return err
+		}
+		entry.Value, entry.Elements = items, int64(len(items))
+		return nil
+
+	case rdbTypeListZiplist, rdbTypeZSetZiplist, rdbTypeHashZiplist,
+		rdbTypeHashListpack, rdbTypeZSetListpack, rdbTypeSetListpack:
+		blob, err := rr.readString()
+		if err != nil {
+			return err
+		}
+		var items []string
+		switch valueType {
+		case rdbTypeListZiplist, rdbTypeZSetZiplist, rdbTypeHashZiplist:
+			items, err = parseZiplist([]byte(blob))
+		default:
+			items, err = parseListpack([]byte(blob))
+		}
+		if err != nil {
+			return err
+		}
+		switch entry.Type {
+		case "hash":
+			return setHashValue(entry, items)
+		case "zset":
+			return setZSetValue(entry, items)
+		}
+		entry.Value, entry.Elements = items, int64(len(items))
+		return nil
+
+	case rdbTypeListQuicklist, rdbTypeListQuicklist2:
+		nodes, err := rr.readLength()
+		if err != nil {
+			return err
+		}
+		var items []string
+		for range nodes {
+			container := uint64(2) // quicklist 节点均为 ziplist
+			if valueType == rdbTypeListQuicklist2 {
+				if container, err = rr.readLength(); err != nil {
+					return err
+				}
+			}
+			blob, err := rr.readString()
+			if err != nil {
+				return err
+			}
+			switch {
+			case container == 1: // PLAIN 节点：单个大元素
+				items = append(items, blob)
+				continue
+			case valueType == rdbTypeListQuicklist:
+				node, err := parseZiplist([]byte(blob))
+				if err != nil {
+					return err
+				}
+				items = append(items, node...)
+			case container == 2:
+				node, err := parseListpack([]byte(blob))
+				if err != nil {
+					return err
+				}
+				items = append(items, node...)
+			default:
+				return fmt.Errorf("%w: quicklist 节点类型 %d 不正确", ErrRDBFormat, container)
+			}
+		}
+		entry.Value, entry.Elements = items, int64(len(items))
+		return nil
+
+	case rdbTypeStreamListpacks, rdbTypeStreamListpack2, rdbTypeStreamListpack3:
+		entries, err := rr.readStream(valueType)
+		if err != nil {
+			return err
+		}
+		entry.Value, entry.Elements = entries, int64(len(entries))
+		return nil
+	}
+	return fmt.Errorf("%w: 值类型 %d", ErrRDBUnsupported, valueType)
+}
+
+// setHashValue 将字段与值交替排列的列表转换为哈希的值
+func setHashValue(entry *RDBEntry, items []string) error {
+	if len(items)%2 != 0 {
+		return fmt.Errorf("%w: 哈希的元素数量 %d 不是偶数", ErrRDBFormat, len(items))
+	}
+	fields := make([]HashField, len(items)/2)
+	for i := range fields {
+		fields[i] = HashField{Field: items[2*i], Value: items[2*i+1]}
+	}
+	entry.Value, entry.Elements = fields, int64(len(fields))
+	return nil
+}
+
+// setZSetValue 将成员与分数交替排列的列表转换为有序集合的值
+func setZSetValue(entry *RDBEntry, items []string) error {
+	if len(items)%2 != 0 {
+		return fmt.Errorf("%w: 有序集合的元素数量 %d 不是偶数", ErrRDBFormat, len(items))
+	}
+	members := make([]redis.Z, len(items)/2)
+	for i := range members {
+		score, err := strconv.ParseFloat(items[2*i+1], 64)
+		if err != nil {
+			return fmt.Errorf("%w: 成员 %q 的分数 %q 不正确", ErrRDBFormat, items[2*i], items[2*i+1])
+		}
+		members[i] = redis.Z{Member: items[2*i], Score: score}
+	}
+	entry.Value, entry.Elements = members, int64(len(members))
+	return nil
+}
+
+// readStream 解析 Stream：消息保存在以主 ID 为键的 listpack 中，之后是元数据与消费者组
+func (rr *RDBReader) readStream(valueType byte) ([]JSONStreamEntry, error) {
+	nodes, err := rr.readLength()
+	if err != nil {
+		return nil, err
+	}
+	var entries []JSONStreamEntry
+	for range nodes {
+		masterID, err := rr.readString()
+		if err != nil {
+			return nil, err
+		}
+		if len(masterID) != 16 {
+			return nil, fmt.Errorf("%w: Stream 节点 ID 长度 %d 不正确", ErrRDBFormat, len(masterID))
+		}
+		blob, err := rr.readString()
+		if err != nil {
+			return nil, err
+		}
+		items, err := parseListpack([]byte(blob))
+		if err != nil {
+			return nil, err
+		}
+		ms := binary.BigEndian.Uint64([]byte(masterID[:8]))
+		seq := binary.BigEndian.Uint64([]byte(masterID[8:]))
+		node, err := parseStreamNode(items, ms, seq)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, node...)
+	}
+
+	// 长度与最后的 ID；v2 起还有第一个 ID、最大删除 ID 与累计添加数
+	meta := 3
+	if valueType >= rdbTypeStreamListpack2 {
+		meta += 5
+	}
+	if err := rr.skipLengths(meta); err != nil {
+		return nil, err
+	}
+
+	groups, err := rr.readLength()
+	if err != nil {
+		return nil, err
+	}
+	for range groups {
+		if _, err := rr.readString(); err != nil {
+			return nil, err
+		}
+		groupMeta := 2
+		if valueType >= rdbTypeStreamListpack2 {
+			groupMeta++ // entries_read
+		}
+		if err := rr.skipLengths(groupMeta); err != nil {
+			return nil, err
+		}
+		pending, err := rr.readLength()
+		if err != nil {
+			return nil, err
+		}
+		for range pending {
+			// 消息 ID（16 字节）与投递时间（8 字节），之后是投递次数
+			if _, err := rr.readN(24); err != nil {
+				return nil, err
+			}
+			if err := rr.skipLengths(1); err != nil {
+				return nil, err
+			}
+		}
+		consumers, err := rr.readLength()
+		if err != nil {
+			return nil, err
+		}
+		for range consumers {
+			if _, err := rr.readString(); err != nil {
+				return nil, err
+			}
+			times := 8 // seen_time；v3 起还有 active_time
+			if valueType >= rdbTypeStreamListpack3 {
+				times += 8
+			}
+			if _, err := rr.readN(times); err != nil {
+				return nil, err
+			}
+			pending, err := rr.readLength()
+			if err != nil {
+				return nil, err
+			}
+			if pending > maxBackupRecordBytes/16 {
+				return nil, fmt.Errorf("%w: 消费者待确认消息数量 %d 超出上限", ErrRDBFormat, pending)
+			}
+			if _, err := rr.readN(int(pending) * 16); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return entries, nil
+}
+
+// parseStreamNode 解析 Stream 的一个 listpack 节点
+// 节点结构：count、deleted、主字段数量、主字段...、0，之后每条消息为
+// flags、ms 差值、seq 差值、[字段数量、字段与值... | 使用主字段时只有值...]、lp-count
+func parseStreamNode(items []string, masterMs, masterSeq uint64) ([]JSONStreamEntry, error) {
+	pos := 0
+	next := func() (string, error) {
+		if pos >= len(items) {
+			return "", fmt.Errorf("%w: Stream 节点提前结束", ErrRDBFormat)
+		}
+		pos++
+		return items[pos-1], nil
+	}
+	nextInt := func() (int64, error) {
+		s, err := next()
+		if err != nil {
+			return 0, err
+		}
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("%w: Stream 节点中的整数 %q 不正确", ErrRDBFormat, s)
+		}
+		return n, nil
+	}
+
+	count, err := nextInt()
+	if err != nil {
+		return nil, err
+	}
+	deleted, err := nextInt()
+	if err != nil {
+		return nil, err
+	}
+	numMaster, err := nextInt()
+	if err != nil {
+		return nil, err
+	}
+	masterFields := make([]string, 0, min(numMaster, 1024))
+	for range numMaster {
+		field, err := next()
+		if err != nil {
+			return nil, err
+		}
+		masterFields = append(masterFields, field)
+	}
+	if _, err := next(); err != nil { // 主条目结束标记
+		return nil, err
+	}
+
+	entries := make([]JSONStreamEntry, 0, count)
+	for range count + deleted {
+		flags, err := nextInt()
+		if err != nil {
+			return nil, err
+		}
+		msDiff, err := nextInt()
+		if err != nil {
+			return nil, err
+		}
+		seqDiff, err := nextInt()
+		if err != nil {
+			return nil, err
+		}
+		var values []string
+		if flags&2 != 0 { // STREAM_ITEM_FLAG_SAMEFIELDS
+			values = make([]string, 0, 2*len(masterFields))
+			for _, field := range masterFields {
+				value, err := next()
+				if err != nil {
+					return nil, err
+				}
+				values = append(values, field, value)
+			}
+		} else {
+			numFields, err := nextInt()
+			if err != nil {
+				return nil, err
+			}
+			values = make([]string, 0, min(2*numFields, 2048))
+			for range 2 * numFields {
+				item, err := next()
+				if err != nil {
+					return nil, err
+				}
+				values = append(values, item)
+			}
+		}
+		if _, err := next(); err != nil { // lp-count
+			return nil, err
+		}
+		if flags&1 != 0 { // STREAM_ITEM_FLAG_DELETED
+			continue
+		}
+		id := fmt.Sprintf("%d-%d", masterMs+uint64(msDiff), masterSeq+uint64(seqDiff))
+		entries = append(entries, JSONStreamEntry{ID: id, Values: values})
+	}
+	return entries, nil
+}
+
+// =============================================================================
+// 基础读取
+// =============================================================================
+
+// readN 读取 n 个字节并更新校验和
+func (rr *RDBReader) readN(n int) ([]byte, error) {
+	if n < 0 || n > maxBackupRecordBytes {
+		return nil, fmt.Errorf("%w: 偏移 %d 处的长度 %d 超出上限", ErrRDBFormat, rr.offset, n)
+	}
+	data := make([]byte, n)
+	if _, err := io.ReadFull(rr.r, data); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, fmt.Errorf("%w: 文件在偏移 %d 处意外结束", ErrRDBFormat, rr.offset)
+		}
+		return nil, fmt.Errorf("读取 RDB 文件失败: %w", err)
+	}
+	rr.offset += int64(n)
+	// Go 的 crc64 在计算前后各取反一次，Redis 的实现没有取反
+	rr.crc = ^crc64.Update(^rr.crc, rdbCRCTable, data)
+	return data, nil
+}
+
+// readByte 读取一个字节
+func (rr *RDBReader) readByte() (byte, error) {
+	data, err := rr.readN(1)
+	if err != nil {
+		return 0, err
+	}
+	return data[0], nil
+}
+
+// readMillis 读取 8 字节小端序的毫秒时间戳
+func (rr *RDBReader) readMillis() (int64, error) {
+	data, err := rr.readN(8)
+	if err != nil {
+		return 0, err
+	}
+	return int64(binary.LittleEndian.Uint64(data)), nil
+}
+
+// readLengthOrEncoding 读取长度编码；最高两位为 11 时表示特殊编码的字符串，返回编码类型
+func (rr *RDBReader) readLengthOrEncoding() (length uint64, encoded bool, err error) {
+	b, err := rr.readByte()
+	if err != nil {
+		return 0, false, err
+	}
+	switch b >> 6 {
+	case 0:
+		return uint64(b & 0x3f), false, nil
+	case 1:
+		next, err := rr.readByte()
+		if err != nil {
+			return 0, false, err
+		}
+		return uint64(b&0x3f)<<8 | uint64(next), false, nil
+	case 2:
+		switch b {
+		case 0x80:
+			data, err := rr.readN(4)
+			if err != nil {
+				return 0, false, err
+			}
+			return uint64(binary.BigEndian.Uint32(data)), false, nil
+		case 0x81:
+			data, err := rr.readN(8)
+			if err != nil {
+				return 0, false, err
+			}
+			return binary.BigEndian.Uint64(data), false, nil
+		}
+		return 0, false, fmt.Errorf("%w: 偏移 %d 处的长度编码 %#x 不正确", ErrRDBFormat, rr.offset-1, b)
+	}
+	return uint64(b & 0x3f), true, nil
+}
+
+// readLength 读取长度
+func (rr *RDBReader) readLength() (uint64, error) {
+	n, encoded, err := rr.readLengthOrEncoding()
+	if err != nil {
+		return 0, err
+	}
+	if encoded {
+		return 0, fmt.Errorf("%w: 偏移 %d 处期望长度，实际为字符串编码", ErrRDBFormat, rr.offset-1)
+	}
+	return n, nil
+}
+
+// skipLengths 跳过 n 个长度值
+func (rr *RDBReader) skipLengths(n int) error {
+	for range n {
+		if _, err := rr.readLength(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readString 读取字符串：原始字节、8/16/32 位整数或 LZF 压缩
+func (rr *RDBReader) readString() (string, error) {
+	n, encoded, err := rr.readLengthOrEncoding()
+	if err != nil {
+		return "", err
+	}
+	if !encoded {
+		if n > maxBackupRecordBytes {
+			return "", fmt.Errorf("%w: 偏移 %d 处的字符串长度 %d 超出上限", ErrRDBFormat, rr.offset, n)
+		}
+		data, err := rr.readN(int(n))
+		return string(data), err
+	}
+
+	switch n {
+	case 0, 1, 2: // INT8、INT16、INT32，小端序有符号整数
+		size := 1 << n
+		data, err := rr.readN(size)
+		if err != nil {
+			return "", err
+		}
+		var v int64
+		switch size {
+		case 1:
+			v = int64(int8(data[0]))
+		case 2:
+			v = int64(int16(binary.LittleEndian.Uint16(data)))
+		case 4:
+			v = int64(int32(binary.LittleEndian.Uint32(data)))
+		}
+		return strconv.FormatInt(v, 10), nil
+	case 3: // LZF：压缩后长度、原始长度、压缩数据
+		clen, err := rr.readLength()
+		if err != nil {
+			return "", err
+		}
+		ulen, err := rr.readLength()
+		if err != nil {
+			return "", err
+		}
+		if clen > maxBackupRecordBytes || ulen > maxBackupRecordBytes {
+			return "", fmt.Errorf("%w: LZF 长度超出上限", ErrRDBFormat)
+		}
+		data, err := rr.readN(int(clen))
+		if err != nil {
+			return "", err
+		}
+		out, err := lzfDecompress(data, int(ulen))
+		return string(out), err
+	}
+	return "", fmt.Errorf("%w: 偏移 %d 处的字符串编码 %d 未知", ErrRDBFormat, rr.offset-1, n)
+}
+
+// readStrings 读取元素数量与 n*stride 个字符串
+func (rr *RDBReader) readStrings(stride int) ([]string, error) {
+	n, err := rr.readLength()
+	if err != nil {
+		return nil, err
+	}
+	items := make([]string, 0, min(n*uint64(stride), 1024))
+	for range n * uint64(stride) {
+		s, err := rr.readString()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, s)
+	}
+	return items, nil
+}
+
+// readStringScore 读取旧格式（ZSET）中以字符串保存的分数：首字节为长度，253/254/255 分别表示 NaN、+inf、-inf
+func (rr *RDBReader) readStringScore() (float64, error) {
+	n, err := rr.readByte()
+	if err != nil {
+		return 0, err
+	}
+	switch n {
+	case 253:
+		return math.NaN(), nil
+	case 254:
+		return math.Inf(1), nil
+	case 255:
+		return math.Inf(-1), nil
+	}
+	data, err := rr.readN(int(n))
+	if err != nil {
+		return 0, err
+	}
+	score, err := strconv.ParseFloat(string(data), 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: 分数 %q 不正确", ErrRDBFormat, data)
+	}
+	return score, nil
+}
+
+// skipModuleValues 跳过模块序列化的值（RDB 9 起每个值带类型标记，以 EOF 标记结束）
+func (rr *RDBReader) skipModuleValues() error {
+	for {
+		opcode, err := rr.readLength()
+		if err != nil {
+			return err
+		}
+		switch opcode {
+		case 0: // EOF
+			return nil
+		case 1, 2: // SINT、UINT
+			err = rr.skipLengths(1)
+		case 3: // FLOAT
+			_, err = rr.readN(4)
+		case 4: // DOUBLE
+			_, err = rr.readN(8)
+		case 5: // STRING
+			_, err = rr.readString()
+		default:
+			return fmt.Errorf("%w: 模块值类型标记 %d 未知", ErrRDBFormat, opcode)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// =============================================================================
+// 紧凑编码
+// =============================================================================
+
+// lzfDecompress 解压 LZF 数据
+func lzfDecompress(in []byte, size int) ([]byte, error) {
+	out := make([]byte, 0, size)
+	for i := 0; i < len(in); {
+		ctrl := int(in[i])
+		i++
+		if ctrl < 32 { // 字面量：ctrl+1 个字节
+			n := ctrl + 1
+			if i+n > len(in) {
+				return nil, fmt.Errorf("%w: LZF 字面量越界", ErrRDBFormat)
+			}
+			out = append(out, in[i:i+n]...)
+			i += n
+			continue
+		}
+		// 回溯引用：长度为高 3 位（为 7 时再加下一个字节）+ 2，偏移为低 5 位与下一个字节 + 1
+		n := ctrl >> 5
+		if n == 7 {
+			if i >= len(in) {
+				return nil, fmt.Errorf("%w: LZF 数据不完整", ErrRDBFormat)
+			}
+			n += int(in[i])
+			i++
+		}
+		if i >= len(in) {
+			return nil, fmt.Errorf("%w: LZF 数据不完整", ErrRDBFormat)
+		}
+		ref := len(out) - ((ctrl&0x1f)<<8 | int(in[i])) - 1
+		i++
+		if ref < 0 {
+			return nil, fmt.Errorf("%w: LZF 回溯引用越界", ErrRDBFormat)
+		}
+		// 引用区间可能与输出重叠，逐字节复制
+		for j := range n + 2 {
+			out = append(out, out[ref+j])
+		}
+	}
+	if len(out) != size {
+		return nil, fmt.Errorf("%w: LZF 解压后长度 %d 与期望的 %d 不一致", ErrRDBFormat, len(out), size)
+	}
+	return out, nil
+}
+
+// compactCursor 读取紧凑编码数据的游标
+type compactCursor struct {
+	data []byte
+	pos  int
+	name string
+}
+
+func (c *compactCursor) take(n int) ([]byte, error) {
+	if n < 0 || c.pos+n > len(c.data) {
+		return nil, fmt.Errorf("%w: %s 在偏移 %d 处越界", ErrRDBFormat, c.name, c.pos)
+	}
+	c.pos += n
+	return c.data[c.pos-n : c.pos], nil
+}
+
+func (c *compactCursor) byte() (byte, error) {
+	b, err := c.take(1)
+	if err != nil {
+		return 0, err
+	}
+	return b[0], nil
+}
+
+// leInt 将 n 个小端序字节解析为有符号整数
+func leInt(data []byte) int64 {
+	var v uint64
+	for i := len(data) - 1; i >= 0; i-- {
+		v = v<<8 | uint64(data[i])
+	}
+	shift := 64 - 8*len(data)
+	return int64(v<<shift) >> shift
+}
+
+// parseListpack 解析 listpack，整数元素转换为十进制字符串
+func parseListpack(data []byte) ([]string, error) {
+	c := &compactCursor{data: data, name: "listpack"}
+	if _, err := c.take(6); err != nil { // 总字节数（4）与元素数量（2）
+		return nil, err
+	}
+	var items []string
+	for {
+		b, err := c.byte()
+		if err != nil {
+			return nil, err
+		}
+		if b == 0xFF {
+			return items, nil
+		}
+
+		var item string
+		var size int // 编码与数据的字节数，用于跳过 backlen
+		switch {
+		case b&0x80 == 0: // 7 位无符号整数
+			item, size = strconv.Itoa(int(b)), 1
+		case b&0xC0 == 0x80: // 6 位长度字符串
+			n := int(b & 0x3f)
+			s, err := c.take(n)
+			if err != nil {
+				return nil, err
+			}
+			item, size = string(s), 1+n
+		case b&0xE0 == 0xC0: // 13 位有符号整数
+			next, err := c.byte()
+			if err != nil {
+				return nil, err
+			}
+			v := int(b&0x1f)<<8 | int(next)
+			if v >= 1<<12 {
+				v -= 1 << 13
+			}
+			item, size = strconv.Itoa(v), 2
+		case b&0xF0 == 0xE0: // 12 位长度字符串
+			next, err := c.byte()
+			if err != nil {
+				return nil, err
+			}
+			n := int(b&0x0f)<<8 | int(next)
+			s, err := c.take(n)
+			if err != nil {
+				return nil, err
+			}
+			item, size = string(s), 2+n
+		case b == 0xF0: // 32 位长度字符串
+			lenBytes, err := c.take(4)
+			if err != nil {
+				return nil, err
+			}
+			n := int(binary.LittleEndian.Uint32(lenBytes))
+			s, err := c.take(n)
+			if err != nil {
+				return nil, err
+			}
+			item, size = string(s), 5+n
+		case b >= 0xF1 && b <= 0xF4: // 16/24/32/64 位有符号整数
+			width := map[byte]int{0xF1: 2, 0xF2: 3, 0xF3: 4, 0xF4: 8}[b]
+			v, err := c.take(width)
+			if err != nil {
+				return nil, err
+			}
+			item, size = strconv.FormatInt(leInt(v), 10), 1+width
+		default:
+			return nil, fmt.Errorf("%w: listpack 编码 %#x 未知", ErrRDBFormat, b)
+		}
+
+		backlen := 5
+		switch {
+		case size <= 127:
+			backlen = 1
+		case size < 16383:
+			backlen = 2
+		case size < 2097151:
+			backlen = 3
+		case size < 268435455:
+			backlen = 4
+		}
+		if _, err := c.take(backlen); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+}
+
+// parseZiplist 解析 ziplist（Redis 7.0 之前的紧凑编码），整数元素转换为十进制字符串
+func parseZiplist(data []byte) ([]string, error) {
+	c := &compactCursor{data: data, name: "ziplist"}
+	if _, err := c.take(10); err != nil { // zlbytes、zltail、zllen
+		return nil, err
+	}
+	var items []string
+	for {
+		prevlen, err := c.byte()
+		if err != nil {
+			return nil, err
+		}
+		if prevlen == 0xFF {
+			return items, nil
+		}
+		if prevlen == 0xFE {
+			if _, err := c.take(4); err != nil {
+				return nil, err
+			}
+		}
+
+		b, err := c.byte()
+		if err != nil {
+			return nil, err
+		}
+		var n int // 字符串长度
+		switch b >> 6 {
+		case 0:
+			n = int(b & 0x3f)
+		case 1:
+			next, err := c.byte()
+			if err != nil {
+				return nil, err
+			}
+			n = int(b&0x3f)<<8 | int(next)
+		case 2:
+			lenBytes, err := c.take(4)
+			if err != nil {
+				return nil, err
+			}
+			n = int(binary.BigEndian.Uint32(lenBytes))
+		default:
+			var width int
+			switch b {
+			case 0xC0:
+				width = 2
+			case 0xD0:
+				width = 4
+			case 0xE0:
+				width = 8
+			case 0xF0:
+				width = 3
+			case 0xFE:
+				width = 1
+			default:
+				if b < 0xF1 || b > 0xFD {
+					return nil, fmt.Errorf("%w: ziplist 编码 %#x 未知", ErrRDBFormat, b)
+				}
+				// 立即数：低 4 位减 1，取值 0~12
+				items = append(items, strconv.Itoa(int(b&0x0f)-1))
+				continue
+			}
+			v, err := c.take(width)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, strconv.FormatInt(leInt(v), 10))
+			continue
+		}
+		s, err := c.take(n)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, string(s))
+	}
+}
+
+// parseIntset 解析 intset：编码宽度（4 字节）、元素数量（4 字节）、有序的小端序整数
+func parseIntset(data []byte) ([]string, error) {
+	c := &compactCursor{data: data, name: "intset"}
+	header, err := c.take(8)
+	if err != nil {
+		return nil, err
+	}
+	width := int(binary.LittleEndian.Uint32(header[:4]))
+	if width != 2 && width != 4 && width != 8 {
+		return nil, fmt.Errorf("%w: intset 编码宽度 %d 不正确", ErrRDBFormat, width)
+	}
+	n := int(binary.LittleEndian.Uint32(header[4:]))
+	if n*width != len(data)-8 {
+		return nil, fmt.Errorf("%w: intset 长度 %d 与数据大小不一致", ErrRDBFormat, n)
+	}
+	items := make([]string, n)
+	for i := range items {
+		v, _ := c.take(width)
+		items[i] = strconv.FormatInt(leInt(v), 10)
+	}
+	return items, nil
+}
+
+// parseZipmap 解析 zipmap（Redis 2.6 之前的哈希编码）
+func parseZipmap(data []byte) ([]string, error) {
+	c := &compactCursor{data: data, name: "zipmap"}
+	if _, err := c.byte(); err != nil { // zmlen
+		return nil, err
+	}
+	readLen := func() (int, bool, error) {
+		b, err := c.byte()
+		if err != nil {
+			return 0, false, err
+		}
+		switch b {
+		case 0xFF:
+			return 0, true, nil
+		case 0xFE:
+			v, err := c.take(4)
+			if err != nil {
+				return 0, false, err
+			}
+			return int(binary.LittleEndian.Uint32(v)), false, nil
+		}
+		return int(b), false, nil
+	}
+
+	var items []string
+	for {
+		n, end, err := readLen()
+		if err != nil {
+			return nil, err
+		}
+		if end {
+			return items, nil
+		}
+		field, err := c.take(n)
+		if err != nil {
+			return nil, err
+		}
+		if n, _, err = readLen(); err != nil {
+			return nil, err
+		}
+		free, err := c.byte()
+		if err != nil {
+			return nil, err
+		}
+		value, err := c.take(n)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := c.take(int(free)); err != nil {
+			return nil, err
+		}
+		items = append(items, string(field), string(value))
+	}
+}
+
+// =============================================================================
+// 离线分析与导出
+// =============================================================================
+
+// RDBOptions RDB 分析与导出配置
+type RDBOptions struct {
+	DBs        []int          // 只处理这些数据库，为空时处理全部
+	Match      string         // 只处理匹配的键，为空时不过滤
+	Type       string         // 只处理指定类型的键
+	Report     *ReportOptions // 报告构建配置，仅用于 AnalyzeRDB
+	OnProgress func(entries int64)
+}
+
+// match 判断键是否满足过滤条件
+func (o *RDBOptions) match(entry *RDBEntry) bool {
+	if len(o.DBs) > 0 {
+		found := false
+		for _, db := range o.DBs {
+			found = found || db == entry.DB
+		}
+		if !found {
+			return false
+		}
+	}
+	if o.Type != "" && o.Type != entry.Type {
+		return false
+	}
+	return o.Match == "" || MatchPattern(o.Match, entry.Key)
+}
+
+// eachRDBEntry 依次处理满足过滤条件的键，每处理 10000 个键回调一次进度
+func eachRDBEntry(r io.Reader, opts *RDBOptions, fn func(entry *RDBEntry) error) error {
+	o := RDBOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	rr, err := NewRDBReader(r)
+	if err != nil {
+		return err
+	}
+	var processed int64
+	for {
+		entry, err := rr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		processed++
+		if o.OnProgress != nil && processed%10000 == 0 {
+			o.OnProgress(processed)
+		}
+		if !o.match(entry) {
+			continue
+		}
+		if err := fn(entry); err != nil {
+			return err
+		}
+	}
+	if o.OnProgress != nil {
+		o.OnProgress(processed)
+	}
+	return nil
+}
+
+// AnalyzeRDB 离线分析 RDB 文件，生成大键/热键报告
+// 参数：
+//   - r: RDB 文件内容
+//   - opts: 分析配置，为 nil 时分析全部键
+//
+// 返回：
+//   - *KeyspaceReport: 分析报告，内存为序列化字节数；已过期的键计入 MissingKeys
+//   - error: 文件格式错误时返回错误，此时报告只包含已分析的部分
+func AnalyzeRDB(r io.Reader, opts *RDBOptions) (*KeyspaceReport, error) {
+	var reportOpts *ReportOptions
+	if opts != nil {
+		reportOpts = opts.Report
+	}
+	builder := NewKeyspaceReportBuilder(reportOpts)
+	start := time.Now()
+	now := time.Now()
+	err := eachRDBEntry(r, opts, func(entry *RDBEntry) error {
+		if !entry.Expiry.IsZero() && !entry.Expiry.After(now) {
+			builder.AddMissing()
+			return nil
+		}
+		builder.Add(KeyStat{Key: entry.Key, Type: entry.Type, Memory: entry.Size, Elements: entry.Elements, Freq: entry.Freq})
+		return nil
+	})
+	report := builder.Report()
+	report.Duration = time.Since(start)
+	return report, err
+}
+
+// ExportRDBToJSON 将 RDB 文件中的键导出为 JSON Lines，格式与 ExportJSON 相同
+// 参数：
+//   - r: RDB 文件内容
+//   - w: 目标流
+//   - opts: 导出配置，为 nil 时导出全部键；包含多个数据库时建议通过 DBs 指定，JSON 记录不含数据库编号
+//
+// 返回：
+//   - *ExportResult: 导出结果，已过期的键计入 Missing
+//   - error: 文件格式错误、遇到模块类型或写入失败时返回错误
+func ExportRDBToJSON(r io.Reader, w io.Writer, opts *RDBOptions) (*ExportResult, error) {
+	result := &ExportResult{}
+	bw := bufio.NewWriterSize(w, 64*1024)
+	enc := json.NewEncoder(bw)
+	enc.SetEscapeHTML(false)
+	now := time.Now()
+	err := eachRDBEntry(r, opts, func(entry *RDBEntry) error {
+		ttl := time.Duration(0)
+		if !entry.Expiry.IsZero() {
+			if ttl = entry.Expiry.Sub(now); ttl <= 0 {
+				result.Missing++
+				return nil
+			}
+		}
+		rec := &JSONRecord{Key: entry.Key, Type: entry.Type}
+		if ttl > 0 {
+			rec.TTL = max(ttl.Milliseconds(), 1)
+		}
+		value, strs, err := rdbJSONValue(entry)
+		if err != nil {
+			return err
+		}
+		if err := encodeJSONValue(rec, value, strs); err != nil {
+			return err
+		}
+		if err := enc.Encode(rec); err != nil {
+			return fmt.Errorf("写入键 %s 失败: %w", rec.Key, err)
+		}
+		result.Exported++
+		return nil
+	})
+	if flushErr := bw.Flush(); flushErr != nil && err == nil {
+		err = fmt.Errorf("写入导出数据失败: %w", flushErr)
+	}
+	return result, err
+}
+
+// rdbJSONValue 将 RDB 中的值转换为与 readJSONValue 相同的规范化结构
+func rdbJSONValue(entry *RDBEntry) (any, []string, error) {
+	switch v := entry.Value.(type) {
+	case string:
+		return &v, []string{v}, nil
+	case []string:
+		items := append([]string(nil), v...)
+		if entry.Type == "set" {
+			sort.Strings(items)
+		}
+		return &items, items, nil
+	case []HashField:
+		fields := make(map[string]string, len(v))
+		strs := make([]string, 0, 2*len(v))
+		for _, f := range v {
+			fields[f.Field] = f.Value
+			strs = append(strs, f.Field, f.Value)
+		}
+		return &fields, strs, nil
+	case []redis.Z:
+		members := make([]JSONScoredMember, len(v))
+		strs := make([]string, len(v))
+		for i, z := range v {
+			member, _ := z.Member.(string)
+			members[i] = JSONScoredMember{Member: member, Score: JSONScore(z.Score)}
+			strs[i] = member
+		}
+		sortScoredMembers(members)
+		return &members, strs, nil
+	case []JSONStreamEntry:
+		if v == nil {
+			// 空 Stream 与在线导出一致输出 []，而不是 null
+			v = []JSONStreamEntry{}
+		}
+		var strs []string
+		for _, e := range v {
+			strs = append(strs, e.Values...)
+		}
+		return &v, strs, nil
+	}
+	return nil, nil, fmt.Errorf("%w: 键 %q 的类型 %s 无法导出为 JSON", ErrRDBUnsupported, entry.Key, entry.Type)
+}
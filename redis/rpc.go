@@ -0,0 +1,683 @@
+package redis
+
+// 基于列表的请求/响应 RPC
+//
+// 场景说明：
+//   内部工具之间需要简单的 RPC，又不想额外部署 gRPC。客户端将带有关联 ID 与回复队列的请求推入服务的
+//   请求列表，服务端 worker 通过 BLMOVE 将请求原子地移动到自己的处理中列表后执行，处理完成后把响应推入
+//   客户端的回复列表并从处理中列表移除。客户端通过一个后台循环接收回复，按关联 ID 唤醒等待的调用。
+//
+// 键设计（prefix 默认为 rpc）：
+//   - {prefix:service}:requests                    请求队列（LPUSH 入队，BLMOVE RIGHT 出队）
+//   - {prefix:service}:processing:serverID:worker  每个 worker 的处理中列表，进程崩溃后由下次启动恢复
+//   - {prefix:service}:dead                        死信列表，超过最大投递次数或 panic 的请求
+//   - {prefix:service}:reply:clientID              客户端回复列表，设置过期时间避免残留
+//   同一服务的键使用相同的 hash tag，集群模式下位于同一槽位，BLMOVE 与写回响应的事务不会跨槽位；
+//   代价是一个服务的全部流量集中在一个分片上，需要分散负载时可以拆分为多个服务名。
+//
+// 连接：
+//   BLMOVE/BLPOP 在阻塞期间会独占一个连接，服务端与客户端各自为阻塞命令创建独立的连接池，
+//   避免占满 Redis 管理器的连接池而影响普通命令。
+//
+// 可靠性：
+//   服务端启动时会把同一 ServerID 遗留在处理中列表的请求重新入队（投递次数加一），
+//   超过 MaxAttempts 的请求进入死信列表。已超过截止时间的请求会被直接丢弃，因为客户端已不再等待。
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"runtime/debug"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrRPCTimeout RPC 调用在截止时间前未收到响应
+var ErrRPCTimeout = errors.New("RPC 调用超时")
+
+// ErrRPCClosed RPC 客户端或服务端已关闭
+var ErrRPCClosed = errors.New("RPC 已关闭")
+
+// RPCRequest RPC 请求
+type RPCRequest struct {
+	ID       string          `json:"id"`                 // 关联 ID
+	Method   string          `json:"method"`             // 方法名
+	Params   json.RawMessage `json:"params,omitempty"`   // JSON 编码的参数
+	ReplyTo  string          `json:"reply_to"`           // 回复列表的键
+	Deadline time.Time       `json:"deadline,omitempty"` // 客户端等待的截止时间
+	Attempts int             `json:"attempts,omitempty"` // 此前未完成的投递次数
+}
+
+// Bind 将请求参数解码到 v
+func (r *RPCRequest) Bind(v interface{}) error {
+	if len(r.Params) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(r.Params, v); err != nil {
+		return fmt.Errorf("解码方法 %s 的参数失败: %w", r.Method, err)
+	}
+	return nil
+}
+
+// RPCResponse RPC 响应
+type RPCResponse struct {
+	ID     string          `json:"id"`               // 对应请求的关联 ID
+	Result json.RawMessage `json:"result,omitempty"` // JSON 编码的结果
+	Error  string          `json:"error,omitempty"`  // 处理失败时的错误信息
+}
+
+// RPCError 服务端处理请求失败时客户端收到的错误
+type RPCError struct {
+	Method  string // 方法名
+	Message string // 服务端返回的错误信息
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("RPC 方法 %s 返回错误: %s", e.Method, e.Message)
+}
+
+// RPCDeadLetter 死信记录
+type RPCDeadLetter struct {
+	Raw      string    `json:"raw"`       // 原始请求内容
+	Reason   string    `json:"reason"`    // 进入死信的原因
+	FailedAt time.Time `json:"failed_at"` // 进入死信的时间
+}
+
+// RPCHandler RPC 方法处理函数，返回值会被 JSON 编码后作为结果
+type RPCHandler func(ctx context.Context, req *RPCRequest) (interface{}, error)
+
+// rpcKeys RPC 服务相关的键
+type rpcKeys struct {
+	prefix  string
+	service string
+}
+
+// tag 同一服务的键共用的 hash tag 内容
+func (k rpcKeys) tag() string { return k.prefix + ":" + k.service }
+
+func (k rpcKeys) requests() string { return HashTagKey(k.tag(), "requests") }
+func (k rpcKeys) dead() string     { return HashTagKey(k.tag(), "dead") }
+
+func (k rpcKeys) processing(serverID string, worker int) string {
+	return HashTagKey(k.tag(), "processing", serverID, strconv.Itoa(worker))
+}
+
+func (k rpcKeys) reply(clientID string) string {
+	return HashTagKey(k.tag(), "reply", clientID)
+}
+
+// newBlockingClient 基于管理器的连接配置创建用于阻塞命令的独立客户端
+// 无法复制连接配置的客户端（如 *redis.Ring 或外部包装类型）直接复用管理器的客户端，
+// 此时阻塞命令会占用管理器连接池中的连接，返回值的 Close 不会关闭管理器的客户端
+func (r *RedisManager) newBlockingClient(poolSize int) redis.UniversalClient {
+	switch client := r.client.(type) {
+	case *redis.ClusterClient:
+		opts := *client.Options()
+		opts.PoolSize = poolSize
+		opts.MinIdleConns = 0
+		return redis.NewClusterClient(&opts)
+	case *redis.Client:
+		opts := *client.Options()
+		opts.PoolSize = poolSize
+		opts.MinIdleConns = 0
+		return redis.NewClient(&opts)
+	}
+	return sharedClient{r.client}
+}
+
+// sharedClient 复用的管理器客户端，Close 为空操作
+type sharedClient struct {
+	redis.UniversalClient
+}
+
+func (sharedClient) Close() error { return nil }
+
+// =============================================================================
+// 服务端
+// =============================================================================
+
+// RPCServerOptions RPC 服务端配置
+type RPCServerOptions struct {
+	KeyPrefix   string        // 键前缀，默认为 rpc
+	ServerID    string        // 服务端实例标识，须在运行中的实例间唯一且重启后保持不变，默认为主机名
+	Workers     int           // 并发处理请求的 worker 数，默认为 4
+	PollTimeout time.Duration // BLMOVE 阻塞等待时间（不足 1 秒按 1 秒计），也决定 Close 的最长等待时间，默认为 1 秒
+	MaxAttempts int           // 最大投递次数，超过后进入死信列表，默认为 3
+	ReplyTTL    time.Duration // 回复列表的过期时间，默认为 1 分钟
+	OnError     func(err error)
+}
+
+// RPCServer RPC 服务端
+type RPCServer struct {
+	manager *RedisManager
+	keys    rpcKeys
+	opts    RPCServerOptions
+
+	mu       sync.RWMutex
+	handlers map[string]RPCHandler
+	started  bool
+	blocking redis.UniversalClient // worker 执行 BLMOVE 使用的独立客户端
+
+	stopCtx context.Context
+	stop    context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+// NewRPCServer 创建 RPC 服务端
+// 参数：
+//   - manager: Redis 管理器
+//   - service: 服务名，客户端据此找到请求队列
+//   - opts: 服务端配置，为 nil 时使用默认配置
+//
+// 返回：
+//   - *RPCServer: 服务端实例，注册方法后调用 Start 开始处理
+//   - error: 服务名为空时返回错误
+func NewRPCServer(manager *RedisManager, service string, opts *RPCServerOptions) (*RPCServer, error) {
+	if service == "" {
+		return nil, fmt.Errorf("RPC 服务名不能为空")
+	}
+	o := RPCServerOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.KeyPrefix == "" {
+		o.KeyPrefix = "rpc"
+	}
+	if o.ServerID == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return nil, fmt.Errorf("获取主机名失败，请显式设置 ServerID: %w", err)
+		}
+		o.ServerID = hostname
+	}
+	if o.Workers <= 0 {
+		o.Workers = 4
+	}
+	if o.PollTimeout <= 0 {
+		o.PollTimeout = time.Second
+	}
+	if o.MaxAttempts <= 0 {
+		o.MaxAttempts = 3
+	}
+	if o.ReplyTTL <= 0 {
+		o.ReplyTTL = time.Minute
+	}
+
+	stopCtx, stop := context.WithCancel(context.Background())
+	return &RPCServer{
+		manager:  manager,
+		keys:     rpcKeys{prefix: o.KeyPrefix, service: service},
+		opts:     o,
+		handlers: make(map[string]RPCHandler),
+		stopCtx:  stopCtx,
+		stop:     stop,
+	}, nil
+}
+
+// Handle 注册方法处理函数
+func (s *RPCServer) Handle(method string, handler RPCHandler) error {
+	if method == "" || handler == nil {
+		return fmt.Errorf("方法名和处理函数不能为空")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.handlers[method]; exists {
+		return fmt.Errorf("方法 %s 已注册处理函数", method)
+	}
+	s.handlers[method] = handler
+	return nil
+}
+
+// Start 恢复上次遗留在处理中列表的请求，然后启动 worker
+func (s *RPCServer) Start(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopCtx.Err() != nil {
+		return ErrRPCClosed
+	}
+	if s.started {
+		return fmt.Errorf("RPC 服务端已启动")
+	}
+
+	for i := 0; i < s.opts.Workers; i++ {
+		// worker 的 BLMOVE 与恢复事务都要求请求、处理中与死信列表位于同一槽位
+		processing := s.keys.processing(s.opts.ServerID, i)
+		if err := s.manager.checkSameSlot([]string{s.keys.requests(), processing, s.keys.dead()}); err != nil {
+			return fmt.Errorf("启动 RPC 服务端失败: %w", err)
+		}
+		if err := s.requeueOrphans(ctx, processing); err != nil {
+			return err
+		}
+	}
+
+	s.started = true
+	s.blocking = s.manager.newBlockingClient(s.opts.Workers)
+	for i := 0; i < s.opts.Workers; i++ {
+		s.wg.Add(1)
+		go s.worker(s.keys.processing(s.opts.ServerID, i))
+	}
+	return nil
+}
+
+// Close 停止接收新请求，等待处理中的请求完成
+// 参数：
+//   - ctx: 等待超时控制
+//
+// 返回：
+//   - error: 未能在 ctx 截止前完成时返回 ctx.Err()，未完成的请求会在下次启动时恢复
+func (s *RPCServer) Close(ctx context.Context) error {
+	s.stop()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		s.mu.Lock()
+		if s.blocking != nil {
+			s.blocking.Close()
+			s.blocking = nil
+		}
+		s.mu.Unlock()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("等待 RPC 请求处理完毕超时: %w", ctx.Err())
+	}
+}
+
+// DeadLetters 读取死信列表中最早的 count 条记录
+func (s *RPCServer) DeadLetters(ctx context.Context, count int64) ([]RPCDeadLetter, error) {
+	items, err := s.manager.client.LRange(ctx, s.keys.dead(), 0, count-1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("读取死信列表失败: %w", err)
+	}
+	letters := make([]RPCDeadLetter, 0, len(items))
+	for _, item := range items {
+		var letter RPCDeadLetter
+		if err := json.Unmarshal([]byte(item), &letter); err != nil {
+			return nil, fmt.Errorf("解码死信记录失败: %w", err)
+		}
+		letters = append(letters, letter)
+	}
+	return letters, nil
+}
+
+// requeueOrphans 将处理中列表遗留的请求重新入队，超过最大投递次数的请求进入死信列表
+func (s *RPCServer) requeueOrphans(ctx context.Context, processing string) error {
+	items, err := s.manager.client.LRange(ctx, processing, 0, -1).Result()
+	if err != nil {
+		return fmt.Errorf("读取处理中列表 %s 失败: %w", processing, err)
+	}
+	if len(items) == 0 {
+		return nil
+	}
+
+	keys := []string{s.keys.requests(), s.keys.dead(), processing}
+	_, err = s.manager.txPipelined(ctx, keys, func(pipe redis.Pipeliner) error {
+		// 列表左侧为最新移入的请求，按从新到旧的顺序 RPUSH，最早的请求最先被再次取出
+		for i := range items {
+			var req RPCRequest
+			if err := json.Unmarshal([]byte(items[i]), &req); err != nil {
+				s.pushDeadLetter(ctx, pipe, items[i], fmt.Sprintf("解码请求失败: %v", err))
+				continue
+			}
+			req.Attempts++
+			if req.Attempts >= s.opts.MaxAttempts {
+				s.pushDeadLetter(ctx, pipe, items[i], fmt.Sprintf("投递 %d 次仍未完成", req.Attempts))
+				continue
+			}
+			data, err := json.Marshal(&req)
+			if err != nil {
+				return fmt.Errorf("编码请求失败: %w", err)
+			}
+			pipe.RPush(ctx, s.keys.requests(), data)
+		}
+		pipe.Del(ctx, processing)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("恢复处理中列表 %s 失败: %w", processing, err)
+	}
+	return nil
+}
+
+// worker 循环取出请求并处理
+func (s *RPCServer) worker(processing string) {
+	defer s.wg.Done()
+
+	s.mu.RLock()
+	blocking := s.blocking
+	s.mu.RUnlock()
+
+	backoff := 100 * time.Millisecond
+	for s.stopCtx.Err() == nil {
+		data, err := blocking.BLMove(s.stopCtx, s.keys.requests(), processing, "RIGHT", "LEFT", s.opts.PollTimeout).Result()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			if s.stopCtx.Err() != nil {
+				return
+			}
+			s.reportError(fmt.Errorf("获取 RPC 请求失败，%v 后重试: %w", backoff, err))
+			select {
+			case <-time.After(backoff):
+			case <-s.stopCtx.Done():
+				return
+			}
+			backoff = min(backoff*2, 5*time.Second)
+			continue
+		}
+		backoff = 100 * time.Millisecond
+		s.process(processing, data)
+	}
+}
+
+// process 处理单个请求，写回响应并从处理中列表移除
+func (s *RPCServer) process(processing, data string) {
+	// 使用独立的上下文，保证关闭过程中已取出的请求仍能写回响应
+	ctx := context.Background()
+
+	var req RPCRequest
+	if err := json.Unmarshal([]byte(data), &req); err != nil {
+		s.finish(ctx, processing, data, "", nil, fmt.Sprintf("解码请求失败: %v", err))
+		return
+	}
+	if !req.Deadline.IsZero() && time.Now().After(req.Deadline) {
+		// 客户端已超时放弃等待，不再处理
+		s.finish(ctx, processing, data, "", nil, "")
+		s.reportError(fmt.Errorf("RPC 请求 %s（%s）已超过截止时间，丢弃", req.ID, req.Method))
+		return
+	}
+
+	s.mu.RLock()
+	handler, ok := s.handlers[req.Method]
+	s.mu.RUnlock()
+	if !ok {
+		s.finish(ctx, processing, data, req.ReplyTo, &RPCResponse{ID: req.ID, Error: fmt.Sprintf("未知方法 %s", req.Method)}, "")
+		return
+	}
+
+	resp, panicReason := s.invoke(&req, handler)
+	s.finish(ctx, processing, data, req.ReplyTo, resp, panicReason)
+}
+
+// invoke 调用处理函数并恢复 panic，panic 时返回死信原因
+func (s *RPCServer) invoke(req *RPCRequest, handler RPCHandler) (resp *RPCResponse, deadReason string) {
+	ctx := context.Background()
+	if !req.Deadline.IsZero() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, req.Deadline)
+		defer cancel()
+	}
+
+	resp = &RPCResponse{ID: req.ID}
+	defer func() {
+		if v := recover(); v != nil {
+			s.reportError(&HandlerPanicError{Channel: req.Method, Value: v, Stack: debug.Stack()})
+			resp.Error = fmt.Sprintf("处理方法 %s 时发生 panic: %v", req.Method, v)
+			deadReason = resp.Error
+		}
+	}()
+
+	result, err := handler(ctx, req)
+	if err != nil {
+		resp.Error = err.Error()
+		return resp, ""
+	}
+	if result != nil {
+		encoded, err := json.Marshal(result)
+		if err != nil {
+			resp.Error = fmt.Sprintf("编码方法 %s 的结果失败: %v", req.Method, err)
+			return resp, ""
+		}
+		resp.Result = encoded
+	}
+	return resp, ""
+}
+
+// finish 在一个事务中写回响应、记录死信并将请求从处理中列表移除
+func (s *RPCServer) finish(ctx context.Context, processing, data, replyTo string, resp *RPCResponse, deadReason string) {
+	keys := []string{processing}
+	if replyTo != "" && resp != nil {
+		keys = append(keys, replyTo)
+	}
+	if deadReason != "" {
+		keys = append(keys, s.keys.dead())
+	}
+	_, err := s.manager.txPipelined(ctx, keys, func(pipe redis.Pipeliner) error {
+		if replyTo != "" && resp != nil {
+			encoded, err := json.Marshal(resp)
+			if err != nil {
+				return fmt.Errorf("编码响应失败: %w", err)
+			}
+			pipe.RPush(ctx, replyTo, encoded)
+			pipe.PExpire(ctx, replyTo, s.opts.ReplyTTL)
+		}
+		if deadReason != "" {
+			s.pushDeadLetter(ctx, pipe, data, deadReason)
+		}
+		pipe.LRem(ctx, processing, 1, data)
+		return nil
+	})
+	if err != nil {
+		// 请求仍留在处理中列表，下次启动时会被恢复
+		s.reportError(fmt.Errorf("完成 RPC 请求失败: %w", err))
+	}
+}
+
+// pushDeadLetter 在管道中追加一条死信记录
+func (s *RPCServer) pushDeadLetter(ctx context.Context, pipe redis.Pipeliner, data, reason string) {
+	letter, _ := json.Marshal(&RPCDeadLetter{Raw: data, Reason: reason, FailedAt: time.Now().UTC()})
+	pipe.RPush(ctx, s.keys.dead(), letter)
+}
+
+// reportError 上报错误
+func (s *RPCServer) reportError(err error) {
+	if s.opts.OnError != nil {
+		s.opts.OnError(err)
+	}
+}
+
+// =============================================================================
+// 客户端
+// =============================================================================
+
+// RPCClientOptions RPC 客户端配置
+type RPCClientOptions struct {
+	KeyPrefix   string        // 键前缀，须与服务端一致，默认为 rpc
+	Timeout     time.Duration // ctx 未设置截止时间时的默认调用超时，默认为 5 秒
+	PollTimeout time.Duration // 等待回复时 BLPOP 的阻塞时间（不足 1 秒按 1 秒计），也决定 Close 的最长等待时间，默认为 1 秒
+}
+
+// RPCClient RPC 客户端，可被多个 goroutine 并发使用
+type RPCClient struct {
+	manager  *RedisManager
+	keys     rpcKeys
+	opts     RPCClientOptions
+	replyKey string
+	blocking redis.UniversalClient // 接收回复执行 BLPOP 使用的独立客户端
+
+	mu      sync.Mutex
+	pending map[string]chan *RPCResponse
+
+	stopCtx context.Context
+	stop    context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+// NewRPCClient 创建 RPC 客户端并启动回复接收循环
+// 参数：
+//   - manager: Redis 管理器
+//   - service: 目标服务名
+//   - opts: 客户端配置，为 nil 时使用默认配置
+//
+// 返回：
+//   - *RPCClient: 客户端实例，使用完毕后调用 Close
+//   - error: 创建失败时返回错误
+func NewRPCClient(manager *RedisManager, service string, opts *RPCClientOptions) (*RPCClient, error) {
+	if service == "" {
+		return nil, fmt.Errorf("RPC 服务名不能为空")
+	}
+	o := RPCClientOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.KeyPrefix == "" {
+		o.KeyPrefix = "rpc"
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = 5 * time.Second
+	}
+	if o.PollTimeout <= 0 {
+		o.PollTimeout = time.Second
+	}
+
+	clientID, err := newEventID()
+	if err != nil {
+		return nil, err
+	}
+	keys := rpcKeys{prefix: o.KeyPrefix, service: service}
+	stopCtx, stop := context.WithCancel(context.Background())
+	c := &RPCClient{
+		manager:  manager,
+		keys:     keys,
+		opts:     o,
+		replyKey: keys.reply(clientID),
+		blocking: manager.newBlockingClient(1),
+		pending:  make(map[string]chan *RPCResponse),
+		stopCtx:  stopCtx,
+		stop:     stop,
+	}
+
+	c.wg.Add(1)
+	go c.receive()
+	return c, nil
+}
+
+// Call 调用远程方法并等待响应
+// 参数：
+//   - ctx: 上下文，截止时间会随请求发送给服务端；未设置时使用默认超时
+//   - method: 方法名
+//   - params: 参数，会被 JSON 编码，可以为 nil
+//   - result: 接收结果的指针，为 nil 时忽略结果
+//
+// 返回：
+//   - error: 超时返回 ErrRPCTimeout，服务端处理失败返回 *RPCError
+func (c *RPCClient) Call(ctx context.Context, method string, params, result interface{}) error {
+	if c.stopCtx.Err() != nil {
+		return ErrRPCClosed
+	}
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.opts.Timeout)
+		defer cancel()
+	}
+	deadline, _ := ctx.Deadline()
+
+	id, err := newEventID()
+	if err != nil {
+		return err
+	}
+	req := RPCRequest{ID: id, Method: method, ReplyTo: c.replyKey, Deadline: deadline}
+	if params != nil {
+		if req.Params, err = json.Marshal(params); err != nil {
+			return fmt.Errorf("编码方法 %s 的参数失败: %w", method, err)
+		}
+	}
+	data, err := json.Marshal(&req)
+	if err != nil {
+		return fmt.Errorf("编码 RPC 请求失败: %w", err)
+	}
+
+	replyCh := make(chan *RPCResponse, 1)
+	c.mu.Lock()
+	c.pending[id] = replyCh
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+	}()
+
+	if err := c.manager.client.LPush(ctx, c.keys.requests(), data).Err(); err != nil {
+		return fmt.Errorf("发送 RPC 请求 %s 失败: %w", method, err)
+	}
+
+	select {
+	case resp := <-replyCh:
+		if resp.Error != "" {
+			return &RPCError{Method: method, Message: resp.Error}
+		}
+		if result != nil && len(resp.Result) > 0 {
+			if err := json.Unmarshal(resp.Result, result); err != nil {
+				return fmt.Errorf("解码方法 %s 的结果失败: %w", method, err)
+			}
+		}
+		return nil
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("调用方法 %s: %w", method, ErrRPCTimeout)
+		}
+		return ctx.Err()
+	case <-c.stopCtx.Done():
+		return ErrRPCClosed
+	}
+}
+
+// Close 停止接收回复并删除回复列表，等待中的调用返回 ErrRPCClosed
+func (c *RPCClient) Close(ctx context.Context) error {
+	c.stop()
+	c.wg.Wait()
+	c.blocking.Close()
+	if err := c.manager.client.Del(ctx, c.replyKey).Err(); err != nil {
+		return fmt.Errorf("删除回复列表失败: %w", err)
+	}
+	return nil
+}
+
+// receive 从回复列表接收响应并按关联 ID 唤醒等待的调用
+func (c *RPCClient) receive() {
+	defer c.wg.Done()
+
+	for c.stopCtx.Err() == nil {
+		values, err := c.blocking.BLPop(c.stopCtx, c.opts.PollTimeout, c.replyKey).Result()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			if c.stopCtx.Err() != nil {
+				return
+			}
+			// 连接异常时稍后重试，等待中的调用会按各自的超时返回
+			select {
+			case <-time.After(100 * time.Millisecond):
+			case <-c.stopCtx.Done():
+				return
+			}
+			continue
+		}
+
+		var resp RPCResponse
+		if err := json.Unmarshal([]byte(values[1]), &resp); err != nil {
+			continue
+		}
+		c.mu.Lock()
+		replyCh, ok := c.pending[resp.ID]
+		c.mu.Unlock()
+		if ok {
+			select {
+			case replyCh <- &resp:
+			default:
+				// 请求被恢复后重复处理时可能收到多次响应，只保留第一次
+			}
+		}
+	}
+}
@@ -0,0 +1,619 @@
+package redis
+
+// Saga 多步骤业务流程协调器
+//
+// 场景说明：
+//   部分业务流程同时涉及 Redis 与外部系统（支付、库存服务等），MULTI/EXEC 无法保证跨系统原子性。
+//   Saga 模式将流程拆分为若干步骤，每个步骤提供正向动作与补偿动作：
+//   - 步骤状态与共享数据持久化在 Redis 哈希中，步骤流水记录在 Redis Stream 中；
+//   - 任一步骤失败时，按相反顺序执行已完成步骤的补偿动作；
+//   - 进程崩溃（或上下文取消）后，可通过 Resume 恢复所有未完成的 Saga。
+//
+// 键布局（以默认前缀 saga 为例）：
+//   {saga}:active       集合，记录所有未完成的 Saga ID
+//   {saga}:state:<id>   哈希，记录名称、状态、当前步骤、共享数据以及每个步骤的状态
+//   {saga}:log:<id>     Stream，按时间顺序记录步骤与补偿流水
+//   {saga}:lock:<id>    字符串，执行租约，防止多个进程同时推进同一个 Saga
+//   每类键使用固定的段名，任意 ID（如 "active" 或 "x:log"）都不会与其他键重名；
+//   所有键使用前缀作为 hash tag，集群模式下位于同一槽位，创建、持久化与结束都可以原子地完成；
+//   一个协调器的全部状态因此集中在一个分片上，需要分散负载时可以使用不同的前缀。
+//
+// 执行租约：
+//   每个步骤（及补偿动作）开始前续期租约，每次持久化都会校验租约仍属于当前执行者，
+//   租约被其他进程接管后立即停止推进并返回 ErrSagaLeaseLost，避免同一步骤被并发执行。
+//
+// 注意：崩溃恢复时处于 started 状态的步骤会被重新执行，因此正向动作与补偿动作都必须是幂等的。
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// SagaStatus Saga 整体状态
+type SagaStatus string
+
+const (
+	SagaStatusRunning      SagaStatus = "running"      // 正向执行中
+	SagaStatusCompensating SagaStatus = "compensating" // 补偿执行中
+	SagaStatusCompleted    SagaStatus = "completed"    // 所有步骤执行成功
+	SagaStatusCompensated  SagaStatus = "compensated"  // 步骤失败且补偿全部完成
+)
+
+// SagaStepStatus 单个步骤状态
+type SagaStepStatus string
+
+const (
+	SagaStepPending     SagaStepStatus = "pending"     // 尚未执行
+	SagaStepStarted     SagaStepStatus = "started"     // 已开始但未确认完成（崩溃后需重试）
+	SagaStepCompleted   SagaStepStatus = "completed"   // 正向动作已完成
+	SagaStepFailed      SagaStepStatus = "failed"      // 正向动作失败
+	SagaStepCompensated SagaStepStatus = "compensated" // 补偿动作已完成
+)
+
+// SagaFunc 步骤动作函数，可通过 exec.Data 读写在步骤间共享的数据
+type SagaFunc func(ctx context.Context, exec *SagaExecution) error
+
+// SagaStep Saga 步骤定义
+type SagaStep struct {
+	Name       string   // 步骤名称
+	Action     SagaFunc // 正向动作，必填
+	Compensate SagaFunc // 补偿动作，为 nil 表示无需补偿
+}
+
+// SagaDefinition Saga 流程定义
+type SagaDefinition struct {
+	Name  string     // 流程名称，恢复时据此查找定义
+	Steps []SagaStep // 按顺序执行的步骤
+}
+
+// SagaExecution 步骤执行时的上下文信息
+type SagaExecution struct {
+	ID   string            // Saga 实例 ID
+	Name string            // 流程名称
+	Data map[string]string // 步骤间共享的数据，每个步骤完成后持久化
+}
+
+// SagaState Saga 实例的持久化状态
+type SagaState struct {
+	ID        string            // Saga 实例 ID
+	Name      string            // 流程名称
+	Status    SagaStatus        // 整体状态
+	Step      int               // 下一个待执行的步骤下标
+	Steps     []SagaStepStatus  // 每个步骤的状态
+	Data      map[string]string // 共享数据
+	Error     string            // 导致补偿的错误信息
+	UpdatedAt time.Time         // 最近更新时间
+}
+
+// SagaLogEntry Saga 流水记录
+type SagaLogEntry struct {
+	ID    string    // Stream 消息 ID
+	Step  string    // 步骤名称
+	Event string    // 事件类型，如 started/completed/failed/compensated
+	Error string    // 错误信息
+	Time  time.Time // 记录时间
+}
+
+// SagaError 步骤失败后已完成补偿时返回的错误
+type SagaError struct {
+	ID   string // Saga 实例 ID
+	Step string // 失败的步骤名称
+	Err  error  // 步骤返回的原始错误
+}
+
+func (e *SagaError) Error() string {
+	return fmt.Sprintf("Saga %s 在步骤 %s 失败并已补偿: %v", e.ID, e.Step, e.Err)
+}
+
+func (e *SagaError) Unwrap() error {
+	return e.Err
+}
+
+// ErrSagaLocked Saga 正在被其他进程执行
+var ErrSagaLocked = errors.New("Saga 正在被其他进程执行")
+
+// ErrSagaLeaseLost 执行过程中租约过期并被其他进程接管
+var ErrSagaLeaseLost = errors.New("Saga 执行租约已失效")
+
+// SagaOptions Saga 协调器配置
+type SagaOptions struct {
+	KeyPrefix string        // 键前缀，默认为 "saga"
+	LockTTL   time.Duration // 执行租约时长，每个步骤开始前续期，应大于单个步骤或补偿动作的最长耗时，默认为 30 秒
+	Retention time.Duration // 结束后状态与流水的保留时长，默认为 24 小时
+}
+
+// SagaCoordinator Saga 协调器
+type SagaCoordinator struct {
+	manager *RedisManager
+	opts    SagaOptions
+
+	mu          sync.RWMutex
+	definitions map[string]*SagaDefinition
+}
+
+// releaseSagaLockScript 仅当租约仍属于当前执行者时才释放
+var releaseSagaLockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// renewSagaLockScript 仅当租约仍属于当前执行者时才续期
+var renewSagaLockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// createSagaScript 原子地创建 Saga：写入状态、登记到活跃集合并获取执行租约
+// KEYS: active, state, lock；ARGV: id, token, 租约毫秒数, 字段与值...
+var createSagaScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[2]) == 1 then
+	return 0
+end
+redis.call("HSET", KEYS[2], unpack(ARGV, 4))
+redis.call("SADD", KEYS[1], ARGV[1])
+redis.call("SET", KEYS[3], ARGV[2], "PX", ARGV[3])
+return 1
+`)
+
+// saveSagaScript 校验并续期租约后持久化状态，按需追加流水
+// KEYS: lock, state, log；ARGV: token, 租约毫秒数, 是否追加流水, step, event, error, 字段与值...
+var saveSagaScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) ~= ARGV[1] then
+	return 0
+end
+redis.call("PEXPIRE", KEYS[1], ARGV[2])
+redis.call("HSET", KEYS[2], unpack(ARGV, 7))
+if ARGV[3] == "1" then
+	redis.call("XADD", KEYS[3], "*", "step", ARGV[4], "event", ARGV[5], "error", ARGV[6])
+end
+return 1
+`)
+
+// NewSagaCoordinator 创建 Saga 协调器
+// 参数：
+//   - manager: Redis 管理器
+//   - opts: 协调器配置，为 nil 时使用默认配置
+//
+// 返回：
+//   - *SagaCoordinator: 协调器实例
+func NewSagaCoordinator(manager *RedisManager, opts *SagaOptions) *SagaCoordinator {
+	o := SagaOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.KeyPrefix == "" {
+		o.KeyPrefix = "saga"
+	}
+	if o.LockTTL <= 0 {
+		o.LockTTL = 30 * time.Second
+	}
+	if o.Retention <= 0 {
+		o.Retention = 24 * time.Hour
+	}
+
+	return &SagaCoordinator{
+		manager:     manager,
+		opts:        o,
+		definitions: make(map[string]*SagaDefinition),
+	}
+}
+
+// Register 注册 Saga 流程定义，恢复未完成 Saga 前必须先注册其定义
+func (c *SagaCoordinator) Register(def SagaDefinition) error {
+	if def.Name == "" {
+		return fmt.Errorf("Saga 流程名称不能为空")
+	}
+	if len(def.Steps) == 0 {
+		return fmt.Errorf("Saga 流程 %s 至少需要一个步骤", def.Name)
+	}
+	for i, step := range def.Steps {
+		if step.Name == "" || step.Action == nil {
+			return fmt.Errorf("Saga 流程 %s 第 %d 个步骤缺少名称或动作", def.Name, i)
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, exists := c.definitions[def.Name]; exists {
+		return fmt.Errorf("Saga 流程 %s 已注册", def.Name)
+	}
+	c.definitions[def.Name] = &def
+	return nil
+}
+
+// Start 创建并执行一个新的 Saga 实例
+// 参数：
+//   - ctx: 上下文，取消时停止推进并保留当前状态，之后可通过 Resume 恢复
+//   - name: 已注册的流程名称
+//   - id: Saga 实例 ID，需全局唯一
+//   - data: 初始共享数据
+//
+// 返回：
+//   - *SagaState: 执行结束（或中断）时的状态
+//   - error: 步骤失败并补偿完成时返回 *SagaError，其他情况返回对应错误
+func (c *SagaCoordinator) Start(ctx context.Context, name, id string, data map[string]string) (*SagaState, error) {
+	def, err := c.definition(name)
+	if err != nil {
+		return nil, err
+	}
+	if id == "" {
+		return nil, fmt.Errorf("Saga 实例 ID 不能为空")
+	}
+	if data == nil {
+		data = make(map[string]string)
+	}
+
+	state := &SagaState{
+		ID:     id,
+		Name:   name,
+		Status: SagaStatusRunning,
+		Steps:  make([]SagaStepStatus, len(def.Steps)),
+		Data:   data,
+	}
+	for i := range state.Steps {
+		state.Steps[i] = SagaStepPending
+	}
+
+	// 状态、活跃集合与租约在一个脚本中写入，同一 ID 只能创建一次，崩溃也不会留下无法恢复的实例
+	fields, err := c.stateFields(state)
+	if err != nil {
+		return nil, err
+	}
+	// 租约持有者标识使用随机 ID，保证跨进程唯一
+	token, err := newEventID()
+	if err != nil {
+		return nil, err
+	}
+	keys := []string{c.activeKey(), c.stateKey(id), c.lockKey(id)}
+	args := append([]interface{}{id, token, c.opts.LockTTL.Milliseconds()}, fields...)
+	created, err := c.manager.runScript(ctx, createSagaScript, keys, args...).Int()
+	if err != nil {
+		return nil, fmt.Errorf("创建 Saga %s 失败: %w", id, err)
+	}
+	if created == 0 {
+		return nil, fmt.Errorf("Saga %s 已存在", id)
+	}
+
+	return c.runWithLease(ctx, def, state, token)
+}
+
+// Resume 恢复所有未完成的 Saga（通常在进程启动时调用）
+// 参数：
+//   - ctx: 上下文，用于控制超时和取消
+//
+// 返回：
+//   - []*SagaState: 本次推进过的 Saga 的最终状态
+//   - error: 任一 Saga 恢复失败时返回聚合错误，步骤失败并补偿完成不视为错误
+func (c *SagaCoordinator) Resume(ctx context.Context) ([]*SagaState, error) {
+	ids, err := c.manager.client.SMembers(ctx, c.activeKey()).Result()
+	if err != nil {
+		return nil, fmt.Errorf("获取未完成 Saga 列表失败: %w", err)
+	}
+
+	var (
+		states []*SagaState
+		errs   []error
+	)
+	for _, id := range ids {
+		state, err := c.ResumeOne(ctx, id)
+		var sagaErr *SagaError
+		switch {
+		case errors.Is(err, ErrSagaLocked), errors.Is(err, ErrSagaLeaseLost):
+			continue
+		case err != nil && !errors.As(err, &sagaErr):
+			errs = append(errs, err)
+		}
+		if state != nil {
+			states = append(states, state)
+		}
+	}
+	return states, errors.Join(errs...)
+}
+
+// ResumeOne 恢复指定的 Saga 实例
+func (c *SagaCoordinator) ResumeOne(ctx context.Context, id string) (*SagaState, error) {
+	state, err := c.Load(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if state.Status == SagaStatusCompleted || state.Status == SagaStatusCompensated {
+		// 状态已结束但仍在活跃集合中，直接清理
+		c.manager.client.SRem(ctx, c.activeKey(), id)
+		return state, nil
+	}
+
+	def, err := c.definition(state.Name)
+	if err != nil {
+		return nil, err
+	}
+	if len(def.Steps) != len(state.Steps) {
+		return nil, fmt.Errorf("Saga %s 的步骤数与流程 %s 当前定义不一致", id, state.Name)
+	}
+	return c.runLocked(ctx, def, state)
+}
+
+// Load 读取 Saga 实例的持久化状态
+func (c *SagaCoordinator) Load(ctx context.Context, id string) (*SagaState, error) {
+	fields, err := c.manager.client.HGetAll(ctx, c.stateKey(id)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("读取 Saga %s 失败: %w", id, err)
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("Saga %s 不存在", id)
+	}
+
+	state := &SagaState{
+		ID:     id,
+		Name:   fields["name"],
+		Status: SagaStatus(fields["status"]),
+		Error:  fields["error"],
+	}
+	if state.Step, err = strconv.Atoi(fields["step"]); err != nil {
+		return nil, fmt.Errorf("解析 Saga %s 当前步骤失败: %w", id, err)
+	}
+	total, err := strconv.Atoi(fields["steps"])
+	if err != nil {
+		return nil, fmt.Errorf("解析 Saga %s 步骤数失败: %w", id, err)
+	}
+	state.Steps = make([]SagaStepStatus, total)
+	for i := range state.Steps {
+		state.Steps[i] = SagaStepStatus(fields["step:"+strconv.Itoa(i)])
+	}
+	if err := json.Unmarshal([]byte(fields["data"]), &state.Data); err != nil {
+		return nil, fmt.Errorf("解析 Saga %s 共享数据失败: %w", id, err)
+	}
+	if ms, err := strconv.ParseInt(fields["updated_at"], 10, 64); err == nil {
+		state.UpdatedAt = time.UnixMilli(ms)
+	}
+	return state, nil
+}
+
+// Logs 读取 Saga 实例的全部流水记录
+func (c *SagaCoordinator) Logs(ctx context.Context, id string) ([]SagaLogEntry, error) {
+	msgs, err := c.manager.client.XRange(ctx, c.logKey(id), "-", "+").Result()
+	if err != nil {
+		return nil, fmt.Errorf("读取 Saga %s 流水失败: %w", id, err)
+	}
+
+	entries := make([]SagaLogEntry, 0, len(msgs))
+	for _, msg := range msgs {
+		entry := SagaLogEntry{ID: msg.ID}
+		entry.Step, _ = msg.Values["step"].(string)
+		entry.Event, _ = msg.Values["event"].(string)
+		entry.Error, _ = msg.Values["error"].(string)
+		// Stream 消息 ID 的格式为 <毫秒时间戳>-<序号>
+		millis, _, _ := strings.Cut(msg.ID, "-")
+		if ms, err := strconv.ParseInt(millis, 10, 64); err == nil {
+			entry.Time = time.UnixMilli(ms)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// runLocked 获取执行租约后推进 Saga
+func (c *SagaCoordinator) runLocked(ctx context.Context, def *SagaDefinition, state *SagaState) (*SagaState, error) {
+	token, err := newEventID()
+	if err != nil {
+		return nil, err
+	}
+	acquired, err := c.manager.client.SetNX(ctx, c.lockKey(state.ID), token, c.opts.LockTTL).Result()
+	if err != nil {
+		return nil, fmt.Errorf("获取 Saga %s 执行租约失败: %w", state.ID, err)
+	}
+	if !acquired {
+		return state, fmt.Errorf("Saga %s: %w", state.ID, ErrSagaLocked)
+	}
+	return c.runWithLease(ctx, def, state, token)
+}
+
+// runWithLease 持有租约推进 Saga，结束后释放租约
+func (c *SagaCoordinator) runWithLease(ctx context.Context, def *SagaDefinition, state *SagaState, token string) (*SagaState, error) {
+	defer func() {
+		// 上下文可能已被取消，释放租约使用独立的超时上下文
+		releaseCtx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+		c.manager.runScript(releaseCtx, releaseSagaLockScript, []string{c.lockKey(state.ID)}, token)
+	}()
+
+	return c.run(ctx, def, state, token)
+}
+
+// renew 续期执行租约，租约已被其他进程接管时返回 ErrSagaLeaseLost
+func (c *SagaCoordinator) renew(ctx context.Context, id, token string) error {
+	renewed, err := c.manager.runScript(ctx, renewSagaLockScript, []string{c.lockKey(id)}, token, c.opts.LockTTL.Milliseconds()).Int()
+	if err != nil {
+		return fmt.Errorf("续期 Saga %s 执行租约失败: %w", id, err)
+	}
+	if renewed == 0 {
+		return fmt.Errorf("Saga %s: %w", id, ErrSagaLeaseLost)
+	}
+	return nil
+}
+
+// run 按当前状态推进 Saga：正向执行或继续补偿
+// 每次持久化都会校验并续期租约，正向动作开始前的 started 记录即为该步骤的续期
+func (c *SagaCoordinator) run(ctx context.Context, def *SagaDefinition, state *SagaState, token string) (*SagaState, error) {
+	exec := &SagaExecution{ID: state.ID, Name: state.Name, Data: state.Data}
+
+	if state.Status == SagaStatusRunning {
+		for state.Step < len(def.Steps) {
+			i := state.Step
+			step := def.Steps[i]
+
+			state.Steps[i] = SagaStepStarted
+			if err := c.save(ctx, token, state, &SagaLogEntry{Step: step.Name, Event: "started"}); err != nil {
+				return state, err
+			}
+
+			if err := step.Action(ctx, exec); err != nil {
+				if ctx.Err() != nil {
+					// 上下文取消视为进程中断，保留 started 状态等待恢复
+					return state, fmt.Errorf("Saga %s 在步骤 %s 中断: %w", state.ID, step.Name, ctx.Err())
+				}
+				state.Steps[i] = SagaStepFailed
+				state.Status = SagaStatusCompensating
+				state.Error = err.Error()
+				if saveErr := c.save(ctx, token, state, &SagaLogEntry{Step: step.Name, Event: "failed", Error: err.Error()}); saveErr != nil {
+					return state, saveErr
+				}
+				break
+			}
+
+			state.Steps[i] = SagaStepCompleted
+			state.Step++
+			if err := c.save(ctx, token, state, &SagaLogEntry{Step: step.Name, Event: "completed"}); err != nil {
+				return state, err
+			}
+		}
+
+		if state.Status == SagaStatusRunning {
+			state.Status = SagaStatusCompleted
+			if err := c.finish(ctx, token, state); err != nil {
+				return state, err
+			}
+			return state, nil
+		}
+	}
+
+	// 逆序执行已完成步骤的补偿动作
+	for i := len(def.Steps) - 1; i >= 0; i-- {
+		if state.Steps[i] != SagaStepCompleted {
+			continue
+		}
+		step := def.Steps[i]
+		if step.Compensate != nil {
+			if err := c.renew(ctx, state.ID, token); err != nil {
+				return state, err
+			}
+			if err := step.Compensate(ctx, exec); err != nil {
+				c.save(ctx, token, state, &SagaLogEntry{Step: step.Name, Event: "compensation_failed", Error: err.Error()})
+				// 保持 compensating 状态，后续 Resume 会重试补偿
+				return state, fmt.Errorf("Saga %s 补偿步骤 %s 失败: %w", state.ID, step.Name, err)
+			}
+		}
+		state.Steps[i] = SagaStepCompensated
+		if err := c.save(ctx, token, state, &SagaLogEntry{Step: step.Name, Event: "compensated"}); err != nil {
+			return state, err
+		}
+	}
+
+	state.Status = SagaStatusCompensated
+	if err := c.finish(ctx, token, state); err != nil {
+		return state, err
+	}
+
+	failedStep := ""
+	for i, s := range state.Steps {
+		if s == SagaStepFailed {
+			failedStep = def.Steps[i].Name
+		}
+	}
+	return state, &SagaError{ID: state.ID, Step: failedStep, Err: errors.New(state.Error)}
+}
+
+// stateFields 将状态转换为字段与值交替排列的 HSET 参数
+func (c *SagaCoordinator) stateFields(state *SagaState) ([]interface{}, error) {
+	data, err := json.Marshal(state.Data)
+	if err != nil {
+		return nil, fmt.Errorf("序列化 Saga %s 共享数据失败: %w", state.ID, err)
+	}
+	state.UpdatedAt = time.Now()
+
+	fields := []interface{}{
+		"name", state.Name,
+		"status", string(state.Status),
+		"step", state.Step,
+		"steps", len(state.Steps),
+		"data", string(data),
+		"error", state.Error,
+		"updated_at", state.UpdatedAt.UnixMilli(),
+	}
+	for i, s := range state.Steps {
+		fields = append(fields, "step:"+strconv.Itoa(i), string(s))
+	}
+	return fields, nil
+}
+
+// save 校验并续期租约后原子地持久化状态并追加流水，租约已失效时返回 ErrSagaLeaseLost
+func (c *SagaCoordinator) save(ctx context.Context, token string, state *SagaState, entry *SagaLogEntry) error {
+	fields, err := c.stateFields(state)
+	if err != nil {
+		return err
+	}
+
+	args := []interface{}{token, c.opts.LockTTL.Milliseconds(), "0", "", "", ""}
+	if entry != nil {
+		args[2], args[3], args[4], args[5] = "1", entry.Step, entry.Event, entry.Error
+	}
+	keys := []string{c.lockKey(state.ID), c.stateKey(state.ID), c.logKey(state.ID)}
+	saved, err := c.manager.runScript(ctx, saveSagaScript, keys, append(args, fields...)...).Int()
+	if err != nil {
+		return fmt.Errorf("持久化 Saga %s 状态失败: %w", state.ID, err)
+	}
+	if saved == 0 {
+		return fmt.Errorf("Saga %s: %w", state.ID, ErrSagaLeaseLost)
+	}
+	return nil
+}
+
+// finish 持久化最终状态，移出活跃集合并设置保留期
+func (c *SagaCoordinator) finish(ctx context.Context, token string, state *SagaState) error {
+	if err := c.save(ctx, token, state, &SagaLogEntry{Event: string(state.Status)}); err != nil {
+		return err
+	}
+	keys := []string{c.activeKey(), c.stateKey(state.ID), c.logKey(state.ID)}
+	_, err := c.manager.txPipelined(ctx, keys, func(pipe redis.Pipeliner) error {
+		pipe.SRem(ctx, c.activeKey(), state.ID)
+		pipe.Expire(ctx, c.stateKey(state.ID), c.opts.Retention)
+		pipe.Expire(ctx, c.logKey(state.ID), c.opts.Retention)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("结束 Saga %s 失败: %w", state.ID, err)
+	}
+	return nil
+}
+
+// definition 查找已注册的流程定义
+func (c *SagaCoordinator) definition(name string) (*SagaDefinition, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	def, ok := c.definitions[name]
+	if !ok {
+		return nil, fmt.Errorf("Saga 流程 %s 未注册", name)
+	}
+	return def, nil
+}
+
+// activeKey 未完成 Saga ID 的集合
+func (c *SagaCoordinator) activeKey() string {
+	return HashTagKey(c.opts.KeyPrefix, "active")
+}
+
+// stateKey Saga 状态哈希
+func (c *SagaCoordinator) stateKey(id string) string {
+	return HashTagKey(c.opts.KeyPrefix, "state", id)
+}
+
+// logKey Saga 流水 Stream
+func (c *SagaCoordinator) logKey(id string) string {
+	return HashTagKey(c.opts.KeyPrefix, "log", id)
+}
+
+// lockKey Saga 执行租约
+func (c *SagaCoordinator) lockKey(id string) string {
+	return HashTagKey(c.opts.KeyPrefix, "lock", id)
+}
@@ -0,0 +1,150 @@
+package redis
+
+// Redis Cluster 哈希槽计算
+//
+// 场景说明：
+//   Redis Cluster 将键空间划分为 16384 个哈希槽，槽位 = CRC16(key) mod 16384。
+//   键中包含 {hash tag} 时只对花括号内的内容计算 CRC16，从而让相关的键落在同一个槽位。
+//   多键命令、事务与 Lua 脚本涉及的键必须位于同一槽位，否则集群返回 CROSSSLOT 错误。
+//   这里的工具函数可以在发出请求之前完成槽位计算与校验，并给出具体是哪些键不在同一槽位。
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ClusterSlots Redis Cluster 哈希槽总数
+const ClusterSlots = 16384
+
+// crc16Table CRC16-CCITT（XMODEM，多项式 0x1021）查找表
+var crc16Table = func() [256]uint16 {
+	var table [256]uint16
+	for i := 0; i < 256; i++ {
+		crc := uint16(i) << 8
+		for j := 0; j < 8; j++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+		table[i] = crc
+	}
+	return table
+}()
+
+// crc16 计算 CRC16-CCITT（XMODEM）校验值
+func crc16(data string) uint16 {
+	var crc uint16
+	for i := 0; i < len(data); i++ {
+		crc = crc<<8 ^ crc16Table[byte(crc>>8)^data[i]]
+	}
+	return crc
+}
+
+// HashTag 返回键中参与槽位计算的部分：存在非空 {hash tag} 时返回花括号内的内容，否则返回整个键
+func HashTag(key string) string {
+	start := strings.IndexByte(key, '{')
+	if start < 0 {
+		return key
+	}
+	end := strings.IndexByte(key[start+1:], '}')
+	if end <= 0 {
+		// 没有右花括号或花括号内为空（如 "{}"）时使用整个键
+		return key
+	}
+	return key[start+1 : start+1+end]
+}
+
+// HashTagKey 构造带 hash tag 的键，如 HashTagKey("user:1", "profile") 返回 "{user:1}:profile"
+// 使用相同 tag 构造的键总是位于同一槽位
+func HashTagKey(tag string, parts ...string) string {
+	var b strings.Builder
+	b.WriteString("{")
+	b.WriteString(tag)
+	b.WriteString("}")
+	for _, part := range parts {
+		b.WriteString(":")
+		b.WriteString(part)
+	}
+	return b.String()
+}
+
+// KeySlot 计算键（或分片频道）所在的哈希槽
+func KeySlot(key string) int {
+	return int(crc16(HashTag(key)) % ClusterSlots)
+}
+
+// GroupKeysBySlot 按槽位对键分组，组内保持键的原始顺序
+func GroupKeysBySlot(keys []string) map[int][]string {
+	groups := make(map[int][]string)
+	for _, key := range keys {
+		slot := KeySlot(key)
+		groups[slot] = append(groups[slot], key)
+	}
+	return groups
+}
+
+// CrossSlotError 多键操作的键分布在多个槽位
+type CrossSlotError struct {
+	Slots map[int][]string // 槽位 -> 位于该槽位的键
+}
+
+func (e *CrossSlotError) Error() string {
+	slots := make([]int, 0, len(e.Slots))
+	for slot := range e.Slots {
+		slots = append(slots, slot)
+	}
+	sort.Ints(slots)
+
+	groups := make([]string, 0, len(slots))
+	for _, slot := range slots {
+		groups = append(groups, fmt.Sprintf("槽位 %d: %s", slot, strings.Join(e.Slots[slot], ", ")))
+	}
+	return fmt.Sprintf("键不在同一哈希槽（CROSSSLOT），可使用 {hash tag} 归入同一槽位: %s", strings.Join(groups, "; "))
+}
+
+// ValidateSameSlot 校验所有键位于同一槽位，用于多键命令、事务与 Lua 脚本执行前的检查
+// 返回：
+//   - error: 键分布在多个槽位时返回 *CrossSlotError，列出每个槽位上的键
+func ValidateSameSlot(keys ...string) error {
+	if len(keys) < 2 {
+		return nil
+	}
+	groups := GroupKeysBySlot(keys)
+	if len(groups) == 1 {
+		return nil
+	}
+	return &CrossSlotError{Slots: groups}
+}
+
+// checkSameSlot 集群模式下校验键位于同一槽位，单机模式不做限制
+func (r *RedisManager) checkSameSlot(keys []string) error {
+	if r.clusterClient() == nil {
+		return nil
+	}
+	return ValidateSameSlot(keys...)
+}
+
+// runScript 集群模式下校验脚本的键位于同一槽位后再执行，跨槽位时不发送任何命令
+func (r *RedisManager) runScript(ctx context.Context, script *redis.Script, keys []string, args ...interface{}) *redis.Cmd {
+	if err := r.checkSameSlot(keys); err != nil {
+		return errorCmd(ctx, err)
+	}
+	return script.Run(ctx, r.client, keys, args...)
+}
+
+// txPipelined 集群模式下校验事务涉及的键位于同一槽位后再执行，跨槽位时不发送任何命令
+// 参数：
+//   - keys: fn 中所有命令涉及的键
+//   - fn: 向事务中追加命令
+func (r *RedisManager) txPipelined(ctx context.Context, keys []string, fn func(redis.Pipeliner) error) ([]redis.Cmder, error) {
+	if err := r.checkSameSlot(keys); err != nil {
+		return nil, err
+	}
+	return r.client.TxPipelined(ctx, fn)
+}
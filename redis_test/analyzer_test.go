@@ -0,0 +1,184 @@
+package redis_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	redisops "github.com/yann0917/redis-usage/redis"
+)
+
+func TestKeyPrefix(t *testing.T) {
+	tests := []struct {
+		key       string
+		separator string
+		depth     int
+		want      string
+	}{
+		{"cache:user:1", ":", 1, "cache"},
+		{"cache:user:1", ":", 2, "cache:user"},
+		{"cache:user:1", ":", 3, "cache:user:1"},
+		{"cache:user:1", ":", 5, "cache:user:1"},
+		{"plain", ":", 1, "plain"},
+		{"a::b::c", "::", 2, "a::b"},
+		{"cache:user:1", ":", 0, "cache:user:1"},
+		{"cache:user:1", ":", -1, "cache:user:1"},
+		{"cache:user:1", "", 2, "cache:user:1"},
+	}
+	for _, tt := range tests {
+		if got := redisops.KeyPrefix(tt.key, tt.separator, tt.depth); got != tt.want {
+			t.Errorf("KeyPrefix(%q, %q, %d) = %q，期望 %q", tt.key, tt.separator, tt.depth, got, tt.want)
+		}
+	}
+}
+
+func TestKeyspaceReportBuilder(t *testing.T) {
+	builder := redisops.NewKeyspaceReportBuilder(&redisops.ReportOptions{TopN: 3, MaxPrefixes: 2})
+	for i := range 10 {
+		builder.Add(redisops.KeyStat{Key: fmt.Sprintf("user:%d", i), Type: "hash", Memory: int64(i * 100), Elements: int64(10 - i), Freq: int64(i % 4)})
+	}
+	builder.Add(redisops.KeyStat{Key: "order:1", Type: "string", Memory: 5000, Elements: 4000})
+	builder.Add(redisops.KeyStat{Key: "session:1", Type: "string", Memory: 50, Elements: 10})
+	builder.AddMissing()
+
+	report := builder.Report()
+	if report.Keys != 12 || report.MissingKeys != 1 || report.TotalMemory != 4500+5050 {
+		t.Errorf("汇总统计不正确: keys=%d missing=%d memory=%d", report.Keys, report.MissingKeys, report.TotalMemory)
+	}
+	if ts := report.Types["hash"]; ts == nil || ts.Keys != 10 || ts.Elements != 55 {
+		t.Errorf("哈希类型统计不正确: %+v", ts)
+	}
+
+	var byMemory []string
+	for _, s := range report.TopByMemory {
+		byMemory = append(byMemory, s.Key)
+	}
+	if strings.Join(byMemory, ",") != "order:1,user:9,user:8" {
+		t.Errorf("按内存排序不正确: %v", byMemory)
+	}
+	if hashes := report.TopByElements["hash"]; len(hashes) != 3 || hashes[0].Key != "user:0" || hashes[2].Key != "user:2" {
+		t.Errorf("按元素数量排序不正确: %+v", hashes)
+	}
+	// 频率相同时按键名排序
+	if len(report.HotKeys) != 3 || report.HotKeys[0].Key != "user:3" || report.HotKeys[1].Key != "user:7" {
+		t.Errorf("热键排序不正确: %+v", report.HotKeys)
+	}
+
+	if !report.PrefixesTruncated || len(report.TopPrefixes) != 2 {
+		t.Fatalf("期望前缀数量超过上限后截断，实际为 %+v", report.TopPrefixes)
+	}
+	if report.TopPrefixes[0].Prefix != "order" || report.TopPrefixes[1].Prefix != "user" || report.TopPrefixes[1].Keys != 10 {
+		t.Errorf("前缀聚合不正确: %+v", report.TopPrefixes)
+	}
+
+	data, err := report.JSON()
+	if err != nil {
+		t.Fatalf("序列化报告失败: %v", err)
+	}
+	var decoded redisops.KeyspaceReport
+	if err := json.Unmarshal(data, &decoded); err != nil || decoded.Keys != 12 || len(decoded.TopByMemory) != 3 {
+		t.Errorf("报告 JSON 反序列化结果不正确: %+v, %v", decoded, err)
+	}
+}
+
+func TestRedisManager_AnalyzeKeyspace(t *testing.T) {
+	ctx, prefix := setupTest(t, "analyze_keyspace")
+
+	for i := range 20 {
+		globalManager.Set(ctx, testKey(prefix, fmt.Sprintf("small:%d", i)), "v", 0)
+	}
+	globalManager.Set(ctx, testKey(prefix, "big:string"), strings.Repeat("x", 10000), 0)
+	fields := make(map[string]interface{})
+	for i := range 500 {
+		fields[fmt.Sprintf("f%d", i)] = i
+	}
+	globalManager.HMSet(ctx, testKey(prefix, "big:hash"), fields)
+	globalManager.RPush(ctx, testKey(prefix, "list"), "a", "b", "c")
+	globalManager.SAdd(ctx, testKey(prefix, "set"), "a", "b")
+	globalManager.ZAdd(ctx, testKey(prefix, "zset"), redis.Z{Score: 1, Member: "a"})
+
+	var progress []int64
+	report, err := globalManager.AnalyzeKeyspace(ctx, &redisops.AnalyzerOptions{
+		Match:      prefix + "*",
+		BatchSize:  10,
+		Report:     &redisops.ReportOptions{TopN: 5, PrefixDepth: 3},
+		OnProgress: func(n int64) { progress = append(progress, n) },
+	})
+	if err != nil {
+		t.Fatalf("分析键空间失败: %v", err)
+	}
+	if report.Keys != 25 {
+		t.Errorf("期望分析 25 个键，实际为 %d", report.Keys)
+	}
+	if len(progress) != 3 || progress[2] != 25 {
+		t.Errorf("期望分 3 批回调进度，实际为 %v", progress)
+	}
+	if len(report.TopByMemory) != 5 || report.TopByMemory[0].Memory <= report.TopByMemory[4].Memory {
+		t.Errorf("按内存排序的结果不正确: %+v", report.TopByMemory)
+	}
+
+	want := map[string]int64{"string": 10000, "hash": 500, "list": 3, "set": 2, "zset": 1}
+	for typ, elements := range want {
+		top := report.TopByElements[typ]
+		if len(top) == 0 || top[0].Elements != elements {
+			t.Errorf("期望 %s 类型最大元素数量为 %d，实际为 %+v", typ, elements, top)
+		}
+	}
+
+	// 前缀深度 3：test:analyze_keyspace:small:N 聚合为 test:analyze_keyspace:small
+	var small *redisops.PrefixStat
+	for i := range report.TopPrefixes {
+		if report.TopPrefixes[i].Prefix == prefix+"small" {
+			small = &report.TopPrefixes[i]
+		}
+	}
+	if small == nil || small.Keys != 20 {
+		t.Errorf("期望前缀 %ssmall 聚合 20 个键，实际为 %+v", prefix, report.TopPrefixes)
+	}
+
+	typed, err := globalManager.AnalyzeKeyspace(ctx, &redisops.AnalyzerOptions{Match: prefix + "*", Type: "hash"})
+	if err != nil || typed.Keys != 1 || typed.Types["hash"] == nil {
+		t.Errorf("期望按类型只分析哈希键，实际为 %+v, %v", typed, err)
+	}
+}
+
+func TestRedisManager_AnalyzeHotKeys(t *testing.T) {
+	ctx, prefix := setupTest(t, "analyze_hot_keys")
+	globalManager.Set(ctx, testKey(prefix, "hot"), "v", time.Minute)
+
+	report, err := globalManager.AnalyzeKeyspace(ctx, &redisops.AnalyzerOptions{Match: prefix + "*", HotKeys: true})
+	if err != nil {
+		// 未开启 LFU 淘汰策略时 OBJECT FREQ 返回错误，错误信息需要提示原因
+		if !strings.Contains(err.Error(), "LFU") {
+			t.Errorf("期望错误信息提示需要 LFU 策略，实际为 %v", err)
+		}
+		return
+	}
+	if report.Keys != 1 {
+		t.Errorf("期望分析 1 个键，实际为 %d", report.Keys)
+	}
+}
+
+func TestClusterManager_AnalyzeKeyspace(t *testing.T) {
+	ctx, prefix := setupTest(t, "cluster_analyze_keyspace")
+	manager := newTestClusterManager(t)
+
+	var keys []string
+	for i := range 30 {
+		key := testKey(prefix, fmt.Sprintf("%d", i))
+		keys = append(keys, key)
+		manager.Set(ctx, key, strings.Repeat("x", i+1), time.Minute)
+	}
+	defer manager.Del(ctx, keys...)
+
+	report, err := manager.AnalyzeKeyspace(ctx, &redisops.AnalyzerOptions{Match: prefix + "*", BatchSize: 8})
+	if err != nil {
+		t.Fatalf("分析集群键空间失败: %v", err)
+	}
+	if report.Keys != 30 || report.TopByElements["string"][0].Elements != 30 {
+		t.Errorf("期望分析所有主节点上的 30 个键，实际为 %+v", report)
+	}
+}
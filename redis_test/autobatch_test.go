@@ -0,0 +1,231 @@
+package redis_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/yann0917/redis-usage/internal"
+	redisops "github.com/yann0917/redis-usage/redis"
+)
+
+// newTestAutoBatchClient 创建独立连接的自动批处理客户端，避免关闭时影响全局管理器
+func newTestAutoBatchClient(t testing.TB, opts *redisops.AutoBatchOptions) *redisops.AutoBatchClient {
+	t.Helper()
+	manager, err := redisops.NewRedisManager(testConfig)
+	if err != nil {
+		t.Fatalf("创建 Redis 管理器失败: %v", err)
+	}
+	return redisops.NewAutoBatchClient(manager, opts)
+}
+
+func TestAutoBatchClient_ConcurrentCalls(t *testing.T) {
+	ctx, prefix := setupTest(t, "autobatch_concurrent")
+
+	client := newTestAutoBatchClient(t, &redisops.AutoBatchOptions{
+		MaxBatchSize: 50,
+		MaxDelay:     2 * time.Millisecond,
+	})
+	defer client.Close()
+
+	// 通过接口使用，验证可以替换 RedisManager
+	var op internal.RedisOperator = client
+
+	const workers = 200
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			key := testKey(prefix, strconv.Itoa(i))
+			if err := op.Set(ctx, key, strconv.Itoa(i), time.Minute); err != nil {
+				errs <- err
+				return
+			}
+			val, err := op.Get(ctx, key)
+			if err != nil {
+				errs <- err
+				return
+			}
+			if val != strconv.Itoa(i) {
+				errs <- errors.New("读取到其他调用方的结果: " + val)
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("并发调用失败: %v", err)
+	}
+
+	stats := client.Stats()
+	t.Logf("批处理指标: %+v", stats)
+	if stats.Commands != workers*2 {
+		t.Errorf("期望发送 %d 条命令，实际为 %d", workers*2, stats.Commands)
+	}
+	if stats.Batches >= stats.Commands {
+		t.Errorf("期望命令被合并发送，实际批次数 %d，命令数 %d", stats.Batches, stats.Commands)
+	}
+	if stats.MaxBatchSize > 50 {
+		t.Errorf("期望批大小不超过 50，实际为 %d", stats.MaxBatchSize)
+	}
+}
+
+func TestAutoBatchClient_ErrorsPerCaller(t *testing.T) {
+	ctx, prefix := setupTest(t, "autobatch_errors")
+
+	client := newTestAutoBatchClient(t, nil)
+	defer client.Close()
+
+	hashKey := testKey(prefix, "hash")
+	if err := client.HSet(ctx, hashKey, "f", "v"); err != nil {
+		t.Fatalf("准备数据失败: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	var incrErr, getErr error
+	var counter int64
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		_, incrErr = client.Incr(ctx, hashKey) // WRONGTYPE，只影响该调用方
+	}()
+	go func() {
+		defer wg.Done()
+		_, getErr = client.Get(ctx, testKey(prefix, "missing"))
+	}()
+	go func() {
+		defer wg.Done()
+		counter, _ = client.Incr(ctx, testKey(prefix, "counter"))
+	}()
+	wg.Wait()
+
+	if incrErr == nil {
+		t.Error("期望类型错误只返回给对应调用方")
+	}
+	if getErr == nil {
+		t.Error("期望获取不存在的键返回错误")
+	}
+	if counter != 1 {
+		t.Errorf("期望计数器为 1，实际为 %d", counter)
+	}
+}
+
+func TestAutoBatchClient_Close(t *testing.T) {
+	ctx, prefix := setupTest(t, "autobatch_close")
+
+	client := newTestAutoBatchClient(t, nil)
+	if err := client.Set(ctx, testKey(prefix, "k"), "v", time.Minute); err != nil {
+		t.Fatalf("设置键失败: %v", err)
+	}
+	if err := client.Close(); err != nil {
+		t.Fatalf("关闭客户端失败: %v", err)
+	}
+
+	if err := client.Set(ctx, testKey(prefix, "k"), "v", time.Minute); !errors.Is(err, redisops.ErrAutoBatchClosed) {
+		t.Errorf("期望关闭后返回 ErrAutoBatchClosed，实际为 %v", err)
+	}
+	// 重复关闭不应报错
+	if err := client.Close(); err != nil {
+		t.Errorf("重复关闭返回错误: %v", err)
+	}
+}
+
+func TestAutoBatchClient_ContextCanceled(t *testing.T) {
+	_, prefix := setupTest(t, "autobatch_canceled")
+
+	client := newTestAutoBatchClient(t, nil)
+	defer client.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := client.Get(ctx, testKey(prefix, "k")); !errors.Is(err, context.Canceled) {
+		t.Errorf("期望调用方取消后返回 context.Canceled，实际为 %v", err)
+	}
+}
+
+// =============================================================================
+// 性能基准测试：并发单键调用，自动批处理 vs RedisManager
+// =============================================================================
+
+func BenchmarkRedisManager_ParallelSet(b *testing.B) {
+	ctx := context.Background()
+	var n atomic.Int64
+
+	b.SetParallelism(32)
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			key := "bench:parallel:manager:" + strconv.FormatInt(n.Add(1)%1000, 10)
+			if err := globalManager.Set(ctx, key, "benchmark_value", time.Minute); err != nil {
+				b.Errorf("设置键失败: %v", err)
+			}
+		}
+	})
+}
+
+func TestClusterManager_AutoBatchMultiKey(t *testing.T) {
+	ctx, prefix := setupTest(t, "cluster_autobatch_multikey")
+	addrs := os.Getenv("REDIS_CLUSTER_ADDRS")
+	if addrs == "" {
+		t.Skip("未设置 REDIS_CLUSTER_ADDRS，跳过集群测试")
+	}
+	recorder := &commandRecorder{}
+	cluster := redis.NewClusterClient(&redis.ClusterOptions{Addrs: strings.Split(addrs, ",")})
+	cluster.AddHook(recorder)
+	client := redisops.NewAutoBatchClient(redisops.NewRedisManagerWithClient(cluster), nil)
+	defer client.Close()
+
+	keys := []string{testKey(prefix, "a"), testKey(prefix, "b"), testKey(prefix, "c")}
+	if len(redisops.GroupKeysBySlot(keys)) < 2 {
+		t.Fatal("测试数据应分布在多个槽位")
+	}
+	for _, key := range keys {
+		if err := client.Set(ctx, key, "v", time.Minute); err != nil {
+			t.Fatalf("设置键失败: %v", err)
+		}
+	}
+
+	// 跨槽位的多键命令按槽位拆分，不会收到 CROSSSLOT
+	if n, err := client.Exists(ctx, keys...); err != nil || n != 3 {
+		t.Errorf("期望 3 个键存在，实际为 %d, %v", n, err)
+	}
+	if err := client.Del(ctx, keys...); err != nil {
+		t.Errorf("删除跨槽位的键失败: %v", err)
+	}
+	if n, err := client.Exists(ctx, keys...); err != nil || n != 0 {
+		t.Errorf("期望删除后没有键存在，实际为 %d, %v", n, err)
+	}
+	if len(recorder.crossSlot) != 0 {
+		t.Errorf("期望不发出跨槽位的多键命令，实际为 %v", recorder.crossSlot)
+	}
+}
+
+func BenchmarkAutoBatchClient_ParallelSet(b *testing.B) {
+	ctx := context.Background()
+	client := newTestAutoBatchClient(b, nil)
+	defer client.Close()
+	var n atomic.Int64
+
+	b.SetParallelism(32)
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			key := "bench:parallel:autobatch:" + strconv.FormatInt(n.Add(1)%1000, 10)
+			if err := client.Set(ctx, key, "benchmark_value", time.Minute); err != nil {
+				b.Errorf("设置键失败: %v", err)
+			}
+		}
+	})
+	b.StopTimer()
+	b.ReportMetric(client.Stats().AvgBatchSize, "cmds/batch")
+}
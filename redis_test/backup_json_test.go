@@ -0,0 +1,221 @@
+package redis_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	redisops "github.com/yann0917/redis-usage/redis"
+)
+
+// seedAllTypes 写入每种类型的键各一个
+func seedAllTypes(t *testing.T, manager *redisops.RedisManager, prefix string) {
+	t.Helper()
+	ctx := t.Context()
+	client := manager.GetClient()
+	client.Set(ctx, testKey(prefix, "string"), "hello 世界", time.Hour)
+	client.HSet(ctx, testKey(prefix, "hash"), "name", "alice", "age", "30")
+	client.RPush(ctx, testKey(prefix, "list"), "c", "a", "b", "a")
+	client.SAdd(ctx, testKey(prefix, "set"), "x", "y", "z")
+	client.ZAdd(ctx, testKey(prefix, "zset"),
+		redis.Z{Member: "low", Score: math.Inf(-1)},
+		redis.Z{Member: "mid", Score: 1.5},
+		redis.Z{Member: "high", Score: math.Inf(1)})
+	client.XAdd(ctx, &redis.XAddArgs{Stream: testKey(prefix, "stream"), ID: "1-1", Values: []string{"b", "2", "a", "1"}})
+	client.XAdd(ctx, &redis.XAddArgs{Stream: testKey(prefix, "stream"), ID: "2-0", Values: []string{"c", "3"}})
+	client.Set(ctx, testKey(prefix, "binary"), string([]byte{0xff, 0x00, 0xfe}), 0)
+	if err := client.Exists(ctx, testKey(prefix, "stream")).Err(); err != nil {
+		t.Fatalf("写入测试数据失败: %v", err)
+	}
+}
+
+// snapshotKeys 读取键的类型、数据与是否带过期时间，用于比较导入前后的数据
+func snapshotKeys(t *testing.T, manager *redisops.RedisManager, prefix string) map[string]string {
+	t.Helper()
+	ctx := t.Context()
+	client := manager.GetClient()
+	snapshot := make(map[string]string)
+	for key, err := range manager.Scan(ctx, &redisops.ScanOptions{Match: prefix + "*"}) {
+		if err != nil {
+			t.Fatalf("扫描失败: %v", err)
+		}
+		var value interface{}
+		switch typ := client.Type(ctx, key).Val(); typ {
+		case "string":
+			value = client.Get(ctx, key).Val()
+		case "hash":
+			value = client.HGetAll(ctx, key).Val()
+		case "list":
+			value = client.LRange(ctx, key, 0, -1).Val()
+		case "set":
+			members := client.SMembers(ctx, key).Val()
+			sort.Strings(members)
+			value = members
+		case "zset":
+			value = client.ZRangeWithScores(ctx, key, 0, -1).Val()
+		case "stream":
+			value = client.Do(ctx, "XRANGE", key, "-", "+").Val()
+		}
+		snapshot[key] = fmt.Sprintf("%#v ttl=%v", value, client.PTTL(ctx, key).Val() > 0)
+	}
+	return snapshot
+}
+
+func TestRedisManager_ExportImportJSON(t *testing.T) {
+	ctx, prefix := setupTest(t, "export_import_json")
+	seedAllTypes(t, globalManager, prefix)
+	before := snapshotKeys(t, globalManager, prefix)
+
+	var buf bytes.Buffer
+	exported, err := globalManager.ExportJSON(ctx, &buf, &redisops.ExportOptions{Match: prefix + "*", BatchSize: 3})
+	if err != nil {
+		t.Fatalf("导出失败: %v", err)
+	}
+	if exported.Exported != 7 {
+		t.Errorf("期望导出 7 个键，实际为 %+v", exported)
+	}
+
+	records := make(map[string]redisops.JSONRecord)
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var rec redisops.JSONRecord
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			t.Fatalf("导出的行不是合法 JSON: %s", line)
+		}
+		records[rec.Type+":"+rec.Encoding] = rec
+	}
+	if rec := records["string:"]; string(rec.Value) != `"hello 世界"` || rec.TTL <= 0 {
+		t.Errorf("字符串记录不正确: %s ttl=%d", rec.Value, rec.TTL)
+	}
+	if rec := records["list:"]; string(rec.Value) != `["c","a","b","a"]` {
+		t.Errorf("列表记录应保持顺序: %s", rec.Value)
+	}
+	if rec := records["zset:"]; string(rec.Value) != `[{"member":"low","score":"-inf"},{"member":"mid","score":1.5},{"member":"high","score":"inf"}]` {
+		t.Errorf("有序集合记录不正确: %s", rec.Value)
+	}
+	if rec := records["stream:"]; string(rec.Value) != `[{"id":"1-1","values":["b","2","a","1"]},{"id":"2-0","values":["c","3"]}]` {
+		t.Errorf("Stream 记录应保持字段顺序: %s", rec.Value)
+	}
+	if rec := records["string:base64"]; rec.Key == "" || string(rec.Value) != `"/wD+"` {
+		t.Errorf("非 UTF-8 数据应以 Base64 编码: %+v", rec)
+	}
+
+	// 删除后导入，数据应与导出前一致
+	globalManager.DeleteByPattern(ctx, prefix+"*", nil)
+	imported, err := globalManager.ImportJSON(ctx, bytes.NewReader(buf.Bytes()), &redisops.ImportOptions{BatchSize: 4})
+	if err != nil || imported.Restored != 7 {
+		t.Fatalf("期望导入 7 个键，实际为 %+v, %v", imported, err)
+	}
+	if after := snapshotKeys(t, globalManager, prefix); !reflect.DeepEqual(before, after) {
+		t.Errorf("导入后的数据与导出前不一致:\n导出前: %v\n导入后: %v", before, after)
+	}
+
+	// 不覆盖时跳过已存在的键；覆盖时先删除再重建
+	globalManager.RPush(ctx, testKey(prefix, "list"), "extra")
+	imported, err = globalManager.ImportJSON(ctx, bytes.NewReader(buf.Bytes()), nil)
+	if err != nil || imported.Skipped != 7 || imported.Restored != 0 {
+		t.Errorf("期望跳过 7 个已存在的键，实际为 %+v, %v", imported, err)
+	}
+	imported, err = globalManager.ImportJSON(ctx, bytes.NewReader(buf.Bytes()), &redisops.ImportOptions{Replace: true})
+	if err != nil || imported.Restored != 7 {
+		t.Errorf("期望覆盖导入 7 个键，实际为 %+v, %v", imported, err)
+	}
+	if n, _ := globalManager.LLen(ctx, testKey(prefix, "list")); n != 4 {
+		t.Errorf("期望覆盖后列表恢复为 4 个元素，实际为 %d", n)
+	}
+}
+
+func TestRedisManager_ImportJSONFixtures(t *testing.T) {
+	ctx, prefix := setupTest(t, "import_json_fixtures")
+
+	// 手写的测试数据：空行被忽略
+	fixtures := fmt.Sprintf(`{"key":%q,"type":"hash","ttl":60000,"value":{"name":"bob"}}
+
+{"key":%q,"type":"set","value":["a","b"]}
+`, testKey(prefix, "user"), testKey(prefix, "tags"))
+	result, err := globalManager.ImportJSON(ctx, strings.NewReader(fixtures), nil)
+	if err != nil || result.Restored != 2 {
+		t.Fatalf("期望导入 2 个键，实际为 %+v, %v", result, err)
+	}
+	if v, _ := globalManager.HGet(ctx, testKey(prefix, "user"), "name"); v != "bob" {
+		t.Errorf("期望哈希字段为 bob，实际为 %q", v)
+	}
+	if ttl, _ := globalManager.TTL(ctx, testKey(prefix, "user")); ttl <= 0 || ttl > time.Minute {
+		t.Errorf("期望过期时间约为 1 分钟，实际为 %v", ttl)
+	}
+
+	invalid := []string{
+		`not json`,
+		`{"key":"k","type":"unknown","value":1}`,
+		`{"key":"k","type":"list","value":{"a":"b"}}`,
+		`{"key":"k","type":"stream","value":[{"id":"1-0","values":["odd"]}]}`,
+		`{"key":"k","type":"string","encoding":"hex","value":"00"}`,
+	}
+	for _, line := range invalid {
+		if _, err := globalManager.ImportJSON(ctx, strings.NewReader(line), nil); err == nil || !strings.Contains(err.Error(), "第 1 行") {
+			t.Errorf("期望 %s 返回带行号的错误，实际为 %v", line, err)
+		}
+	}
+}
+
+func TestRedisManager_ExportImportJSONEmptyStream(t *testing.T) {
+	ctx, prefix := setupTest(t, "export_import_json_empty_stream")
+	client := globalManager.GetClient()
+	key := testKey(prefix, "stream")
+	// 创建后删除消费者组，得到一个没有消息的 Stream
+	client.XGroupCreateMkStream(ctx, key, "g", "$")
+	client.XGroupDestroy(ctx, key, "g")
+	if typ := client.Type(ctx, key).Val(); typ != "stream" {
+		t.Fatalf("创建空 Stream 失败: %s", typ)
+	}
+
+	var buf bytes.Buffer
+	exported, err := globalManager.ExportJSON(ctx, &buf, &redisops.ExportOptions{Match: prefix + "*"})
+	if err != nil || exported.Exported != 1 || exported.Missing != 0 {
+		t.Fatalf("期望导出 1 个空 Stream，实际为 %+v, %v", exported, err)
+	}
+	var rec redisops.JSONRecord
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil || rec.Type != "stream" || string(rec.Value) != "[]" {
+		t.Fatalf("空 Stream 应导出为 []，实际为 %s, %v", buf.String(), err)
+	}
+
+	client.Del(ctx, key)
+	imported, err := globalManager.ImportJSON(ctx, bytes.NewReader(buf.Bytes()), nil)
+	if err != nil || imported.Restored != 1 {
+		t.Fatalf("期望导入 1 个键，实际为 %+v, %v", imported, err)
+	}
+	if typ := client.Type(ctx, key).Val(); typ != "stream" {
+		t.Errorf("期望导入后创建空 Stream，实际类型为 %s", typ)
+	}
+	if n := client.XLen(ctx, key).Val(); n != 0 {
+		t.Errorf("期望导入后 Stream 为空，实际长度为 %d", n)
+	}
+	if groups := client.XInfoGroups(ctx, key).Val(); len(groups) != 0 {
+		t.Errorf("期望导入时不残留消费者组，实际为 %v", groups)
+	}
+}
+
+func TestClusterManager_ExportImportJSON(t *testing.T) {
+	ctx, prefix := setupTest(t, "cluster_export_import_json")
+	manager := newTestClusterManager(t)
+	seedAllTypes(t, manager.RedisManager, prefix)
+	before := snapshotKeys(t, manager.RedisManager, prefix)
+	defer manager.DeleteByPattern(ctx, prefix+"*", nil)
+
+	var buf bytes.Buffer
+	if result, err := manager.ExportJSON(ctx, &buf, &redisops.ExportOptions{Match: prefix + "*"}); err != nil || result.Exported != 7 {
+		t.Fatalf("期望从所有主节点导出 7 个键，实际为 %+v, %v", result, err)
+	}
+	if result, err := manager.ImportJSON(ctx, &buf, &redisops.ImportOptions{Replace: true}); err != nil || result.Restored != 7 {
+		t.Fatalf("期望导入 7 个键，实际为 %+v, %v", result, err)
+	}
+	if after := snapshotKeys(t, manager.RedisManager, prefix); !reflect.DeepEqual(before, after) {
+		t.Errorf("导入后的数据与导出前不一致")
+	}
+}
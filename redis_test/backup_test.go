@@ -0,0 +1,220 @@
+package redis_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	redisops "github.com/yann0917/redis-usage/redis"
+)
+
+func TestBackupWriterReader(t *testing.T) {
+	createdAt := time.UnixMilli(1700000000000)
+	var buf bytes.Buffer
+	bw, err := redisops.NewBackupWriter(&buf, createdAt)
+	if err != nil {
+		t.Fatalf("创建备份写入器失败: %v", err)
+	}
+	records := []redisops.BackupRecord{
+		{Key: "a", Value: []byte("payload-a")},
+		{Key: "b", TTL: 90 * time.Second, Value: bytes.Repeat([]byte{0, 1, 2}, 1000)},
+		{Key: "", Value: nil},
+	}
+	for _, rec := range records {
+		if err := bw.Write(rec); err != nil {
+			t.Fatalf("写入记录失败: %v", err)
+		}
+	}
+	if err := bw.Close(); err != nil {
+		t.Fatalf("关闭写入器失败: %v", err)
+	}
+	data := buf.Bytes()
+
+	br, err := redisops.NewBackupReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("创建备份读取器失败: %v", err)
+	}
+	if !br.CreatedAt().Equal(createdAt) {
+		t.Errorf("期望导出时间为 %v，实际为 %v", createdAt, br.CreatedAt())
+	}
+	for i, want := range records {
+		got, err := br.Next()
+		if err != nil {
+			t.Fatalf("读取第 %d 条记录失败: %v", i+1, err)
+		}
+		if got.Key != want.Key || got.TTL != want.TTL || !bytes.Equal(got.Value, want.Value) {
+			t.Errorf("第 %d 条记录不一致: %+v", i+1, got)
+		}
+	}
+	if _, err := br.Next(); !errors.Is(err, io.EOF) {
+		t.Errorf("期望读到结束记录后返回 io.EOF，实际为 %v", err)
+	}
+
+	// 截断：去掉结束记录
+	br, _ = redisops.NewBackupReader(bytes.NewReader(data[:len(data)-6]))
+	var readErr error
+	for readErr == nil {
+		_, readErr = br.Next()
+	}
+	if !errors.Is(readErr, redisops.ErrBackupTruncated) {
+		t.Errorf("期望返回 ErrBackupTruncated，实际为 %v", readErr)
+	}
+
+	// 损坏：修改第二条记录中的一个字节
+	corrupted := bytes.Clone(data)
+	corrupted[len(data)/2] ^= 0xff
+	br, _ = redisops.NewBackupReader(bytes.NewReader(corrupted))
+	br.Next()
+	if _, err := br.Next(); !errors.Is(err, redisops.ErrBackupChecksum) {
+		t.Errorf("期望返回 ErrBackupChecksum，实际为 %v", err)
+	}
+
+	if _, err := redisops.NewBackupReader(bytes.NewReader([]byte("not a backup file"))); !errors.Is(err, redisops.ErrBackupFormat) {
+		t.Errorf("期望返回 ErrBackupFormat，实际为 %v", err)
+	}
+}
+
+func TestRedisManager_ExportImportKeys(t *testing.T) {
+	ctx, prefix := setupTest(t, "export_import")
+	for i := range 25 {
+		globalManager.Set(ctx, testKey(prefix, fmt.Sprintf("user:%d", i)), fmt.Sprintf("value:%d", i), 0)
+	}
+	globalManager.Set(ctx, testKey(prefix, "user:ttl"), "ttl", time.Hour)
+	globalManager.Set(ctx, testKey(prefix, "other"), "v", 0)
+
+	var buf bytes.Buffer
+	var progress []int64
+	exported, err := globalManager.ExportKeys(ctx, &buf, &redisops.ExportOptions{
+		Match:      prefix + "user:*",
+		BatchSize:  10,
+		OnProgress: func(n int64) { progress = append(progress, n) },
+	})
+	if err != nil {
+		t.Fatalf("导出失败: %v", err)
+	}
+	if exported.Exported != 26 || len(progress) != 3 {
+		t.Errorf("期望分 3 批导出 26 个键，实际为 %+v, %v", exported, progress)
+	}
+	data := buf.Bytes()
+
+	// 目标键已存在且不覆盖时跳过
+	globalManager.Set(ctx, testKey(prefix, "user:0"), "changed", 0)
+	globalManager.Del(ctx, testKey(prefix, "user:1"), testKey(prefix, "user:ttl"))
+	imported, err := globalManager.ImportKeys(ctx, bytes.NewReader(data), nil)
+	if err != nil {
+		t.Fatalf("恢复失败: %v", err)
+	}
+	if imported.Restored != 2 || imported.Skipped != 24 {
+		t.Errorf("期望恢复 2 个键并跳过 24 个，实际为 %+v", imported)
+	}
+	if v, _ := globalManager.Get(ctx, testKey(prefix, "user:1")); v != "value:1" {
+		t.Errorf("期望恢复被删除的键，实际为 %q", v)
+	}
+	if v, _ := globalManager.Get(ctx, testKey(prefix, "user:0")); v != "changed" {
+		t.Errorf("不覆盖时已存在的键不应被修改，实际为 %q", v)
+	}
+	if ttl, _ := globalManager.TTL(ctx, testKey(prefix, "user:ttl")); ttl <= 0 || ttl > time.Hour {
+		t.Errorf("期望恢复原有的过期时间，实际为 %v", ttl)
+	}
+	if ttl, _ := globalManager.TTL(ctx, testKey(prefix, "user:1")); ttl > 0 {
+		t.Errorf("不过期的键恢复后不应设置过期时间，实际为 %v", ttl)
+	}
+
+	imported, err = globalManager.ImportKeys(ctx, bytes.NewReader(data), &redisops.ImportOptions{Replace: true, BatchSize: 7})
+	if err != nil || imported.Restored != 26 {
+		t.Errorf("期望覆盖恢复 26 个键，实际为 %+v, %v", imported, err)
+	}
+	if v, _ := globalManager.Get(ctx, testKey(prefix, "user:0")); v != "value:0" {
+		t.Errorf("期望覆盖已存在的键，实际为 %q", v)
+	}
+
+	// 截断的备份在读到末尾时报错，之前的批次已恢复
+	globalManager.Del(ctx, testKey(prefix, "user:0"))
+	imported, err = globalManager.ImportKeys(ctx, bytes.NewReader(data[:len(data)-6]), &redisops.ImportOptions{Replace: true})
+	if !errors.Is(err, redisops.ErrBackupTruncated) {
+		t.Errorf("期望返回 ErrBackupTruncated，实际为 %v", err)
+	}
+	if imported.Restored != 0 {
+		t.Errorf("期望不完整的最后一批不恢复，实际为 %+v", imported)
+	}
+}
+
+func TestRedisManager_ImportAdjustTTL(t *testing.T) {
+	ctx, prefix := setupTest(t, "import_adjust_ttl")
+
+	// 构造一份 1 小时前导出的备份
+	var buf bytes.Buffer
+	bw, _ := redisops.NewBackupWriter(&buf, time.Now().Add(-time.Hour))
+	bw.Write(redisops.BackupRecord{Key: testKey(prefix, "expired"), TTL: 30 * time.Minute, Value: []byte("v")})
+	bw.Write(redisops.BackupRecord{Key: testKey(prefix, "alive"), TTL: 2 * time.Hour, Value: []byte("v")})
+	bw.Write(redisops.BackupRecord{Key: testKey(prefix, "persistent"), Value: []byte("v")})
+	bw.Close()
+
+	result, err := globalManager.ImportKeys(ctx, bytes.NewReader(buf.Bytes()), &redisops.ImportOptions{AdjustTTL: true})
+	if err != nil {
+		t.Fatalf("恢复失败: %v", err)
+	}
+	if result.Restored != 2 || result.Expired != 1 {
+		t.Errorf("期望恢复 2 个键并跳过 1 个已过期的键，实际为 %+v", result)
+	}
+	if ttl, _ := globalManager.TTL(ctx, testKey(prefix, "alive")); ttl <= 50*time.Minute || ttl > time.Hour {
+		t.Errorf("期望剩余过期时间约为 1 小时，实际为 %v", ttl)
+	}
+	if n, _ := globalManager.Exists(ctx, testKey(prefix, "expired"), testKey(prefix, "persistent")); n != 1 {
+		t.Errorf("期望只恢复不过期的键，实际存在 %d 个", n)
+	}
+}
+
+func TestRedisManager_ExportKeysToFile(t *testing.T) {
+	ctx, prefix := setupTest(t, "export_file")
+	for i := range 5 {
+		globalManager.Set(ctx, testKey(prefix, fmt.Sprintf("%d", i)), "v", 0)
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "backup.rdsbak")
+	if _, err := globalManager.ExportKeysToFile(ctx, path, &redisops.ExportOptions{Match: prefix + "*"}); err != nil {
+		t.Fatalf("导出到文件失败: %v", err)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("期望只留下备份文件，实际为 %v", entries)
+	}
+
+	globalManager.Del(ctx, testKey(prefix, "0"), testKey(prefix, "1"))
+	result, err := globalManager.ImportKeysFromFile(ctx, path, nil)
+	if err != nil || result.Restored != 2 || result.Skipped != 3 {
+		t.Errorf("期望从文件恢复 2 个键，实际为 %+v, %v", result, err)
+	}
+
+	if _, err := globalManager.ImportKeysFromFile(ctx, filepath.Join(dir, "missing"), nil); err == nil {
+		t.Error("期望文件不存在时返回错误")
+	}
+}
+
+func TestClusterManager_ExportImportKeys(t *testing.T) {
+	ctx, prefix := setupTest(t, "cluster_export_import")
+	manager := newTestClusterManager(t)
+
+	var keys []string
+	for i := range 30 {
+		key := testKey(prefix, fmt.Sprintf("%d", i))
+		keys = append(keys, key)
+		manager.Set(ctx, key, "v", time.Minute)
+	}
+	defer manager.Del(ctx, keys...)
+
+	var buf bytes.Buffer
+	if result, err := manager.ExportKeys(ctx, &buf, &redisops.ExportOptions{Match: prefix + "*", BatchSize: 8}); err != nil || result.Exported != 30 {
+		t.Fatalf("期望从所有主节点导出 30 个键，实际为 %+v, %v", result, err)
+	}
+	manager.Del(ctx, keys...)
+	if result, err := manager.ImportKeys(ctx, &buf, nil); err != nil || result.Restored != 30 {
+		t.Errorf("期望恢复 30 个键，实际为 %+v, %v", result, err)
+	}
+}
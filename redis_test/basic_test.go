@@ -150,6 +150,24 @@ func TestRedisManager_Get_NotExists(t *testing.T) {
 	}
 }
 
+func TestRedisManager_MGet(t *testing.T) {
+	ctx, prefix := setupTest(t, "mget")
+
+	key1 := testKey(prefix, "key1")
+	key2 := testKey(prefix, "key2")
+	missing := testKey(prefix, "missing")
+	globalManager.Set(ctx, key1, "value1", time.Minute)
+	globalManager.Set(ctx, key2, "value2", time.Minute)
+
+	vals, err := globalManager.MGet(ctx, key1, missing, key2)
+	if err != nil {
+		t.Fatalf("MGet 操作失败: %v", err)
+	}
+	if len(vals) != 3 || vals[0] != "value1" || vals[1] != nil || vals[2] != "value2" {
+		t.Errorf("期望按输入顺序返回 [value1 <nil> value2]，实际为 %v", vals)
+	}
+}
+
 func TestRedisManager_Incr(t *testing.T) {
 	ctx, prefix := setupTest(t, "incr")
 
@@ -0,0 +1,130 @@
+package redis_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	redisops "github.com/yann0917/redis-usage/redis"
+)
+
+// seedKeys 写入 n 个匹配 prefix+"cache:*" 的键与一个不匹配的键
+func seedKeys(t *testing.T, ctx context.Context, prefix string, n int) {
+	t.Helper()
+	for i := range n {
+		if err := globalManager.Set(ctx, testKey(prefix, fmt.Sprintf("cache:%d", i)), "v", 0); err != nil {
+			t.Fatalf("写入测试数据失败: %v", err)
+		}
+	}
+	globalManager.Set(ctx, testKey(prefix, "keep"), "v", 0)
+}
+
+func TestRedisManager_DeleteByPattern(t *testing.T) {
+	ctx, prefix := setupTest(t, "delete_by_pattern")
+	seedKeys(t, ctx, prefix, 120)
+	pattern := prefix + "cache:*"
+
+	dry, err := globalManager.DeleteByPattern(ctx, pattern, &redisops.PatternOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("演练删除失败: %v", err)
+	}
+	if !dry.DryRun || dry.Matched != 120 || dry.Affected != 0 {
+		t.Errorf("期望演练匹配 120 个键且不删除，实际为 %+v", dry)
+	}
+	if n, _ := globalManager.Exists(ctx, testKey(prefix, "cache:0")); n != 1 {
+		t.Error("演练模式不应删除键")
+	}
+
+	var progress []redisops.PatternProgress
+	result, err := globalManager.DeleteByPattern(ctx, pattern, &redisops.PatternOptions{
+		BatchSize:  50,
+		OnProgress: func(p redisops.PatternProgress) { progress = append(progress, p) },
+	})
+	if err != nil {
+		t.Fatalf("按模式删除失败: %v", err)
+	}
+	if result.Matched != 120 || result.Affected != 120 || len(result.FailedKeys) != 0 {
+		t.Errorf("期望删除 120 个键，实际为 %+v", result)
+	}
+	if len(progress) != 3 || progress[2].Affected != 120 {
+		t.Errorf("期望分 3 批回调进度，实际为 %+v", progress)
+	}
+
+	if keys := collect(t, globalManager.Scan(ctx, &redisops.ScanOptions{Match: pattern})); len(keys) != 0 {
+		t.Errorf("期望匹配的键全部被删除，剩余 %v", keys)
+	}
+	if n, _ := globalManager.Exists(ctx, testKey(prefix, "keep")); n != 1 {
+		t.Error("不匹配的键不应被删除")
+	}
+
+	if _, err := globalManager.DeleteByPattern(ctx, "", nil); err == nil {
+		t.Error("期望空模式返回错误")
+	}
+}
+
+func TestRedisManager_ExpireByPattern(t *testing.T) {
+	ctx, prefix := setupTest(t, "expire_by_pattern")
+	seedKeys(t, ctx, prefix, 30)
+	pattern := prefix + "cache:*"
+
+	result, err := globalManager.ExpireByPattern(ctx, pattern, time.Hour, &redisops.PatternOptions{BatchSize: 7})
+	if err != nil {
+		t.Fatalf("按模式设置过期时间失败: %v", err)
+	}
+	if result.Matched != 30 || result.Affected != 30 {
+		t.Errorf("期望为 30 个键设置过期时间，实际为 %+v", result)
+	}
+	if ttl, _ := globalManager.TTL(ctx, testKey(prefix, "cache:3")); ttl <= 0 || ttl > time.Hour {
+		t.Errorf("期望过期时间约为 1 小时，实际为 %v", ttl)
+	}
+	if ttl, _ := globalManager.TTL(ctx, testKey(prefix, "keep")); ttl > 0 {
+		t.Errorf("不匹配的键不应设置过期时间，实际为 %v", ttl)
+	}
+
+	if _, err := globalManager.ExpireByPattern(ctx, pattern, 0, nil); err == nil {
+		t.Error("期望过期时间为 0 时返回错误")
+	}
+}
+
+func TestRedisManager_PatternRateLimit(t *testing.T) {
+	ctx, prefix := setupTest(t, "pattern_rate_limit")
+	seedKeys(t, ctx, prefix, 40)
+	pattern := prefix + "cache:*"
+
+	// 每秒 100 个键，40 个键至少需要 0.4 秒
+	start := time.Now()
+	result, err := globalManager.DeleteByPattern(ctx, pattern, &redisops.PatternOptions{BatchSize: 10, MaxKeysPerSecond: 100})
+	if err != nil || result.Affected != 40 {
+		t.Fatalf("限速删除失败: %+v, %v", result, err)
+	}
+	if elapsed := time.Since(start); elapsed < 350*time.Millisecond {
+		t.Errorf("期望限速生效，实际耗时 %v", elapsed)
+	}
+
+	// 限速等待期间取消
+	seedKeys(t, ctx, prefix, 40)
+	cancelCtx, cancel := context.WithTimeout(ctx, 150*time.Millisecond)
+	defer cancel()
+	result, err = globalManager.DeleteByPattern(cancelCtx, pattern, &redisops.PatternOptions{BatchSize: 10, MaxKeysPerSecond: 20})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("期望返回超时错误，实际为 %v", err)
+	}
+	if result.Affected == 0 || result.Affected == 40 {
+		t.Errorf("期望超时前只完成部分删除，实际为 %+v", result)
+	}
+}
+
+func TestClusterManager_DeleteByPattern(t *testing.T) {
+	ctx, prefix := setupTest(t, "cluster_delete_by_pattern")
+	manager := newTestClusterManager(t)
+
+	for i := range 50 {
+		manager.Set(ctx, testKey(prefix, fmt.Sprintf("cache:%d", i)), "v", time.Minute)
+	}
+	result, err := manager.DeleteByPattern(ctx, prefix+"cache:*", &redisops.PatternOptions{BatchSize: 20})
+	if err != nil || result.Affected != 50 {
+		t.Errorf("期望在所有主节点上删除 50 个键，实际为 %+v, %v", result, err)
+	}
+}
@@ -0,0 +1,255 @@
+package redis_test
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/yann0917/redis-usage/internal"
+	redisops "github.com/yann0917/redis-usage/redis"
+)
+
+// startLocalCluster 使用本机 redis-server 启动 n 个主节点组成的临时集群，槽位平均分配
+// 未安装 redis-server 时跳过测试
+func startLocalCluster(t *testing.T, n int) *redisops.ClusterManager {
+	t.Helper()
+	bin, err := exec.LookPath("redis-server")
+	if err != nil {
+		t.Skip("未安装 redis-server，跳过本地集群测试")
+	}
+
+	ctx := context.Background()
+	dir := t.TempDir()
+	addrs := make([]string, n)
+	clients := make([]*redis.Client, n)
+	for i := range n {
+		port := freePort(t)
+		cmd := exec.Command(bin,
+			"--port", strconv.Itoa(port),
+			"--cluster-enabled", "yes",
+			"--cluster-config-file", fmt.Sprintf("%s/nodes-%d.conf", dir, port),
+			"--cluster-node-timeout", "2000",
+			"--dir", dir,
+			"--save", "",
+			"--appendonly", "no",
+		)
+		if err := cmd.Start(); err != nil {
+			t.Fatalf("启动 redis-server 失败: %v", err)
+		}
+		t.Cleanup(func() {
+			cmd.Process.Kill()
+			cmd.Wait()
+		})
+
+		addrs[i] = fmt.Sprintf("127.0.0.1:%d", port)
+		clients[i] = redis.NewClient(&redis.Options{Addr: addrs[i]})
+		t.Cleanup(func() { clients[i].Close() })
+		if !waitFor(t, 5*time.Second, func() bool { return clients[i].Ping(ctx).Err() == nil }) {
+			t.Fatalf("等待节点 %s 启动超时", addrs[i])
+		}
+	}
+
+	per := redisops.ClusterSlots / n
+	for i, client := range clients {
+		start, end := i*per, (i+1)*per-1
+		if i == n-1 {
+			end = redisops.ClusterSlots - 1
+		}
+		if err := client.ClusterAddSlotsRange(ctx, start, end).Err(); err != nil {
+			t.Fatalf("分配槽位 %d-%d 失败: %v", start, end, err)
+		}
+		if i > 0 {
+			host, port, _ := net.SplitHostPort(addrs[0])
+			if err := client.ClusterMeet(ctx, host, port).Err(); err != nil {
+				t.Fatalf("节点握手失败: %v", err)
+			}
+		}
+	}
+	ready := waitFor(t, 10*time.Second, func() bool {
+		for _, client := range clients {
+			info, err := client.ClusterInfo(ctx).Result()
+			if err != nil || !strings.Contains(info, "cluster_state:ok") {
+				return false
+			}
+		}
+		return true
+	})
+	if !ready {
+		t.Fatal("等待集群状态变为 ok 超时")
+	}
+
+	config := internal.DefaultClusterConfig()
+	config.Addrs = addrs
+	manager, err := redisops.NewClusterManager(config)
+	if err != nil {
+		t.Fatalf("创建集群管理器失败: %v", err)
+	}
+	t.Cleanup(func() { manager.Close() })
+	return manager
+}
+
+// freePort 返回一个空闲端口，集群总线端口为其加 10000
+func freePort(t *testing.T) int {
+	t.Helper()
+	for range 20 {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("获取空闲端口失败: %v", err)
+		}
+		port := l.Addr().(*net.TCPAddr).Port
+		l.Close()
+		if port+10000 <= 65535 {
+			return port
+		}
+	}
+	t.Fatal("没有可用的端口")
+	return 0
+}
+
+// slotOwnerAddr 返回槽位当前归属的主节点地址
+func slotOwnerAddr(t *testing.T, ctx context.Context, manager *redisops.ClusterManager, slot int) string {
+	t.Helper()
+	topology, err := manager.Topology(ctx)
+	if err != nil {
+		t.Fatalf("查询集群拓扑失败: %v", err)
+	}
+	for _, node := range topology.Masters() {
+		for _, r := range node.Slots {
+			if slot >= r.Start && slot <= r.End {
+				return node.Addr
+			}
+		}
+	}
+	return ""
+}
+
+func TestSlotMigrator_InvalidArguments(t *testing.T) {
+	ctx, _ := setupTest(t, "slot_migrate_invalid")
+	manager := newTestClusterManager(t)
+	migrator := redisops.NewSlotMigrator(manager, nil)
+
+	for _, slots := range []redisops.SlotRange{{Start: -1, End: 10}, {Start: 10, End: 5}, {Start: 0, End: redisops.ClusterSlots}} {
+		if _, err := migrator.Migrate(ctx, slots, "any"); err == nil {
+			t.Errorf("期望槽位区间 %s 返回错误", slots)
+		}
+	}
+}
+
+func TestSlotMigrator_LocalCluster(t *testing.T) {
+	ctx, prefix := setupTest(t, "slot_migrate")
+	manager := startLocalCluster(t, 2)
+
+	tag := prefix + "tag"
+	slot := redisops.KeySlot(tag)
+	keys := make([]string, 25)
+	for i := range keys {
+		keys[i] = redisops.HashTagKey(tag, strconv.Itoa(i))
+		if err := manager.Set(ctx, keys[i], strconv.Itoa(i), time.Minute); err != nil {
+			t.Fatalf("写入键 %s 失败: %v", keys[i], err)
+		}
+	}
+
+	source := slotOwnerAddr(t, ctx, manager, slot)
+	var target string
+	topology, err := manager.Topology(ctx)
+	if err != nil {
+		t.Fatalf("查询集群拓扑失败: %v", err)
+	}
+	for _, node := range topology.Masters() {
+		if node.Addr != source {
+			target = node.Addr
+		}
+	}
+	slots := redisops.SlotRange{Start: slot, End: slot}
+
+	t.Run("unknown target", func(t *testing.T) {
+		if _, err := redisops.NewSlotMigrator(manager, nil).Migrate(ctx, slots, "127.0.0.1:1"); err == nil {
+			t.Error("期望目标节点不存在时返回错误")
+		}
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		migrator := redisops.NewSlotMigrator(manager, &redisops.SlotMigrationOptions{DryRun: true})
+		result, err := migrator.Migrate(ctx, slots, target)
+		if err != nil {
+			t.Fatalf("演练迁移失败: %v", err)
+		}
+		if !result.DryRun || result.KeysMoved != int64(len(keys)) || len(result.Migrated) != 1 {
+			t.Errorf("期望演练统计 %d 个键，实际为 %+v", len(keys), result)
+		}
+		if owner := slotOwnerAddr(t, ctx, manager, slot); owner != source {
+			t.Errorf("演练模式不应修改槽位归属，实际归属 %s", owner)
+		}
+	})
+
+	t.Run("pause and migrate", func(t *testing.T) {
+		var mu sync.Mutex
+		var batches []int64
+		var migrator *redisops.SlotMigrator
+		migrator = redisops.NewSlotMigrator(manager, &redisops.SlotMigrationOptions{
+			BatchSize: 10,
+			OnProgress: func(p redisops.SlotMigrationProgress) {
+				mu.Lock()
+				defer mu.Unlock()
+				if !p.SlotDone {
+					batches = append(batches, p.SlotKeysMoved)
+				}
+				// 第一批完成后暂停
+				if len(batches) == 1 && !p.SlotDone {
+					migrator.Pause()
+				}
+			},
+		})
+
+		done := make(chan error, 1)
+		var result *redisops.SlotMigrationResult
+		go func() {
+			var err error
+			result, err = migrator.Migrate(ctx, slots, target)
+			done <- err
+		}()
+
+		if !waitFor(t, 5*time.Second, migrator.Paused) {
+			t.Fatal("等待迁移暂停超时")
+		}
+		time.Sleep(200 * time.Millisecond)
+		mu.Lock()
+		if len(batches) != 1 {
+			t.Errorf("期望暂停后停留在第一批，实际已完成 %d 批", len(batches))
+		}
+		mu.Unlock()
+
+		migrator.Resume()
+		if err := <-done; err != nil {
+			t.Fatalf("迁移槽位失败: %v", err)
+		}
+		if result.KeysMoved != int64(len(keys)) || len(result.Migrated) != 1 {
+			t.Errorf("期望迁移 %d 个键，实际为 %+v", len(keys), result)
+		}
+		if len(batches) != 3 || batches[2] != int64(len(keys)) {
+			t.Errorf("期望分 3 批迁移，实际进度为 %v", batches)
+		}
+
+		if owner := slotOwnerAddr(t, ctx, manager, slot); owner != target {
+			t.Errorf("期望槽位 %d 归属 %s，实际为 %s", slot, target, owner)
+		}
+		for i, key := range keys {
+			if got, err := manager.Get(ctx, key); err != nil || got != strconv.Itoa(i) {
+				t.Errorf("迁移后读取键 %s 失败: %s, %v", key, got, err)
+			}
+		}
+
+		// 已归属目标节点的槽位再次迁移时跳过
+		again, err := redisops.NewSlotMigrator(manager, nil).Migrate(ctx, slots, target)
+		if err != nil || len(again.Skipped) != 1 || again.KeysMoved != 0 {
+			t.Errorf("期望重复迁移时跳过槽位，实际为 %+v, %v", again, err)
+		}
+	})
+}
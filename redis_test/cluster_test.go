@@ -1 +1,277 @@
 package redis_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/yann0917/redis-usage/internal"
+	redisops "github.com/yann0917/redis-usage/redis"
+)
+
+// newTestClusterManager 连接 REDIS_CLUSTER_ADDRS（逗号分隔）指定的集群，未设置时跳过测试
+func newTestClusterManager(t *testing.T) *redisops.ClusterManager {
+	t.Helper()
+	addrs := os.Getenv("REDIS_CLUSTER_ADDRS")
+	if addrs == "" {
+		t.Skip("未设置 REDIS_CLUSTER_ADDRS，跳过集群测试")
+	}
+
+	config := internal.DefaultClusterConfig()
+	config.Addrs = strings.Split(addrs, ",")
+	manager, err := redisops.NewClusterManager(config)
+	if err != nil {
+		t.Fatalf("创建集群管理器失败: %v", err)
+	}
+	t.Cleanup(func() { manager.Close() })
+	return manager
+}
+
+// exerciseOperator 通过接口执行一组基本操作，验证不同拓扑的行为一致
+func exerciseOperator(t *testing.T, ctx context.Context, op internal.RedisOperator, prefix string) {
+	t.Helper()
+
+	// 不同的键会分布在不同的槽位上
+	for _, name := range []string{"a", "b", "c", "d"} {
+		key := testKey(prefix, name)
+		if err := op.Set(ctx, key, name, time.Minute); err != nil {
+			t.Fatalf("设置键 %s 失败: %v", key, err)
+		}
+		if got, err := op.Get(ctx, key); err != nil || got != name {
+			t.Errorf("期望键 %s 的值为 %s，实际为 %s, %v", key, name, got, err)
+		}
+	}
+
+	hashKey := testKey(prefix, "hash")
+	if err := op.HSet(ctx, hashKey, "field", "value"); err != nil {
+		t.Fatalf("设置哈希字段失败: %v", err)
+	}
+	if got, err := op.HGet(ctx, hashKey, "field"); err != nil || got != "value" {
+		t.Errorf("期望哈希字段值为 value，实际为 %s, %v", got, err)
+	}
+
+	counter := testKey(prefix, "counter")
+	op.Del(ctx, counter)
+	if n, err := op.Incr(ctx, counter); err != nil || n != 1 {
+		t.Errorf("期望计数器为 1，实际为 %d, %v", n, err)
+	}
+
+	// 同一 hash tag 的多个键可以在一条命令中删除
+	tagged := []string{testKey(prefix, "{user:1}:profile"), testKey(prefix, "{user:1}:settings")}
+	for _, key := range tagged {
+		op.Set(ctx, key, "x", time.Minute)
+	}
+	if n, err := op.Exists(ctx, tagged...); err != nil || n != 2 {
+		t.Errorf("期望 2 个键存在，实际为 %d, %v", n, err)
+	}
+	if err := op.Del(ctx, tagged...); err != nil {
+		t.Errorf("删除同槽位的键失败: %v", err)
+	}
+}
+
+func TestClusterManager_SharedInterface(t *testing.T) {
+	ctx, prefix := setupTest(t, "cluster_interface")
+
+	t.Run("standalone", func(t *testing.T) {
+		exerciseOperator(t, ctx, globalManager, prefix+"standalone:")
+	})
+	t.Run("cluster", func(t *testing.T) {
+		exerciseOperator(t, ctx, newTestClusterManager(t), prefix+"cluster:")
+	})
+}
+
+func TestClusterManager_ConnectionManagement(t *testing.T) {
+	ctx, _ := setupTest(t, "cluster_connection")
+	manager := newTestClusterManager(t)
+
+	if err := manager.Ping(ctx); err != nil {
+		t.Fatalf("Ping 集群失败: %v", err)
+	}
+
+	info, err := manager.Info(ctx)
+	if err != nil {
+		t.Fatalf("获取集群信息失败: %v", err)
+	}
+	if len(info) < 2 || !strings.Contains(info["raw"], "# Node ") {
+		t.Errorf("期望包含每个主节点的信息，实际为 %v", info)
+	}
+
+	if manager.GetClient() == nil {
+		t.Error("期望返回底层集群客户端")
+	}
+	if manager.GetConfig() == nil || len(manager.GetConfig().Addrs) == 0 {
+		t.Error("期望返回集群配置")
+	}
+}
+
+func TestClusterManager_MultiKeyAcrossSlots(t *testing.T) {
+	ctx, prefix := setupTest(t, "cluster_multikey")
+	manager := newTestClusterManager(t)
+
+	keys := make([]string, 20)
+	for i := range keys {
+		keys[i] = testKey(prefix, fmt.Sprintf("key:%d", i))
+	}
+	if len(redisops.GroupKeysBySlot(keys)) < 2 {
+		t.Fatal("测试数据应分布在多个槽位")
+	}
+	manager.Del(ctx, keys...)
+
+	// 偶数下标的键存在，奇数下标的键不存在
+	for i := 0; i < len(keys); i += 2 {
+		if err := manager.Set(ctx, keys[i], fmt.Sprintf("v%d", i), time.Minute); err != nil {
+			t.Fatalf("设置键失败: %v", err)
+		}
+	}
+
+	vals, err := manager.MGet(ctx, keys...)
+	if err != nil {
+		t.Fatalf("跨槽位 MGET 失败: %v", err)
+	}
+	for i, val := range vals {
+		if i%2 == 0 && val != fmt.Sprintf("v%d", i) {
+			t.Errorf("期望第 %d 个值为 v%d，实际为 %v", i, i, val)
+		}
+		if i%2 == 1 && val != nil {
+			t.Errorf("期望第 %d 个值为 nil，实际为 %v", i, val)
+		}
+	}
+
+	if n, err := manager.Exists(ctx, keys...); err != nil || n != 10 {
+		t.Errorf("期望 10 个键存在，实际为 %d, %v", n, err)
+	}
+	if n, err := manager.DelCount(ctx, keys...); err != nil || n != 10 {
+		t.Errorf("期望删除 10 个键，实际为 %d, %v", n, err)
+	}
+	if n, err := manager.Exists(ctx, keys...); err != nil || n != 0 {
+		t.Errorf("期望删除后没有键存在，实际为 %d, %v", n, err)
+	}
+}
+
+func TestNewRedisManager_ClusterConfigValidation(t *testing.T) {
+	config := internal.DefaultRedisConfig()
+	config.Cluster = &internal.ClusterConfig{}
+	if _, err := redisops.NewRedisManager(config); err == nil {
+		t.Error("期望未配置集群种子节点时返回错误")
+	}
+
+	config.Cluster = internal.DefaultClusterConfig()
+	config.DB = 2
+	if _, err := redisops.NewRedisManager(config); err == nil || !strings.Contains(err.Error(), "0 号数据库") {
+		t.Errorf("期望集群模式下 DB 非 0 时返回错误，实际为 %v", err)
+	}
+}
+
+func TestRedisManager_UniversalClient(t *testing.T) {
+	ctx, prefix := setupTest(t, "universal_client")
+
+	t.Run("standalone", func(t *testing.T) {
+		if _, ok := globalManager.GetClient().(*redis.Client); !ok {
+			t.Errorf("期望单机模式的底层客户端为 *redis.Client，实际为 %T", globalManager.GetClient())
+		}
+	})
+
+	t.Run("wrapped", func(t *testing.T) {
+		// 包装类型无法复制连接配置，RPC 的阻塞命令复用管理器的客户端
+		client := redis.NewClient(&redis.Options{Addr: testConfig.Addr, DB: testConfig.DB})
+		manager := redisops.NewRedisManagerWithClient(wrappedClient{client})
+		defer manager.Close()
+
+		server, err := redisops.NewRPCServer(manager, "calc", &redisops.RPCServerOptions{KeyPrefix: prefix + "rpc", Workers: 1})
+		if err != nil {
+			t.Fatalf("创建 RPC 服务端失败: %v", err)
+		}
+		server.Handle("echo", func(ctx context.Context, req *redisops.RPCRequest) (interface{}, error) { return "ok", nil })
+		if err := server.Start(ctx); err != nil {
+			t.Fatalf("启动 RPC 服务端失败: %v", err)
+		}
+		rpcClient, err := redisops.NewRPCClient(manager, "calc", &redisops.RPCClientOptions{KeyPrefix: prefix + "rpc"})
+		if err != nil {
+			t.Fatalf("创建 RPC 客户端失败: %v", err)
+		}
+		var reply string
+		if err := rpcClient.Call(ctx, "echo", nil, &reply); err != nil || reply != "ok" {
+			t.Errorf("期望通过包装的客户端完成调用，实际为 %q, %v", reply, err)
+		}
+		rpcClient.Close(ctx)
+		server.Close(ctx)
+
+		// 关闭 RPC 不会关闭管理器的客户端
+		if err := manager.Ping(ctx); err != nil {
+			t.Errorf("期望管理器的客户端仍可用，实际为 %v", err)
+		}
+	})
+
+	t.Run("cluster", func(t *testing.T) {
+		addrs := os.Getenv("REDIS_CLUSTER_ADDRS")
+		if addrs == "" {
+			t.Skip("未设置 REDIS_CLUSTER_ADDRS，跳过集群测试")
+		}
+		config := internal.DefaultRedisConfig()
+		config.Cluster = internal.DefaultClusterConfig()
+		config.Cluster.Addrs = strings.Split(addrs, ",")
+		manager, err := redisops.NewRedisManager(config)
+		if err != nil {
+			t.Fatalf("以集群模式创建管理器失败: %v", err)
+		}
+		defer manager.Close()
+
+		if _, ok := manager.GetClient().(*redis.ClusterClient); !ok {
+			t.Errorf("期望集群模式的底层客户端为 *redis.ClusterClient，实际为 %T", manager.GetClient())
+		}
+		exerciseOperator(t, ctx, manager, prefix+"cluster:")
+
+		// 跨槽位的多键操作与 ClusterManager 行为一致
+		keys := []string{testKey(prefix, "a"), testKey(prefix, "b"), testKey(prefix, "c")}
+		if len(redisops.GroupKeysBySlot(keys)) < 2 {
+			t.Fatal("测试数据应分布在多个槽位")
+		}
+		for _, key := range keys {
+			manager.Set(ctx, key, key, time.Minute)
+		}
+		vals, err := manager.MGet(ctx, keys...)
+		if err != nil || len(vals) != 3 || vals[2] != keys[2] {
+			t.Errorf("跨槽位 MGET 结果不正确: %v, %v", vals, err)
+		}
+		if n, err := manager.DelCount(ctx, keys...); err != nil || n != 3 {
+			t.Errorf("期望删除 3 个键，实际为 %d, %v", n, err)
+		}
+
+		info, err := manager.Info(ctx)
+		if err != nil || !strings.Contains(info["raw"], "# Node ") {
+			t.Errorf("期望返回每个主节点的信息，实际为 %v, %v", info, err)
+		}
+	})
+}
+
+// wrappedClient 包装的客户端，模拟外部传入的 UniversalClient 实现
+type wrappedClient struct {
+	redis.UniversalClient
+}
+
+func TestMultiKeyError(t *testing.T) {
+	cause := errors.New("连接被拒绝")
+	err := &redisops.MultiKeyError{
+		Command: "DEL",
+		Total:   5,
+		Failures: []redisops.SlotFailure{
+			{Slot: 100, Node: "10.0.0.1:7000", Keys: []string{"a", "b"}, Err: cause},
+			{Slot: 200, Keys: []string{"c"}, Err: cause},
+		},
+	}
+
+	if got := err.FailedKeys(); strings.Join(got, ",") != "a,b,c" {
+		t.Errorf("期望失败的键为 a,b,c，实际为 %v", got)
+	}
+	if !errors.Is(err, cause) {
+		t.Error("期望可以通过 errors.Is 判断原始错误")
+	}
+	if msg := err.Error(); !strings.Contains(msg, "3/5") || !strings.Contains(msg, "10.0.0.1:7000") {
+		t.Errorf("错误信息应包含失败数量与节点地址，实际为 %s", msg)
+	}
+}
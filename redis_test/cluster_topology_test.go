@@ -0,0 +1,180 @@
+package redis_test
+
+import (
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+	redisops "github.com/yann0917/redis-usage/redis"
+)
+
+// 录制的 CLUSTER NODES 输出：三主三从，30004 对应的主节点 30002 已故障，30003 正在迁移槽位 5461
+const recordedClusterNodes = `
+07c37dfeb235213a872192d90877d0cd55635b91 127.0.0.1:30004@31004,host-4 slave e7d1eecce10fd6bb5eb35b9f99a514335d9ba9ca 0 1426238317239 4 connected
+67ed2db8d677e59ec4a4cefb06858cf2a1a89fa1 127.0.0.1:30002@31002,host-2 master,fail - 1426238316232 1426238315000 2 disconnected 5461-10922
+292f8b365bb7edb5e285caf0b7e6ddc7265d2f4f 127.0.0.1:30003@31003,host-3 master - 0 1426238318243 3 connected 10923-16383 [5461->-67ed2db8d677e59ec4a4cefb06858cf2a1a89fa1]
+6ec23923021cf3ffec47632106199cb7f496ce01 127.0.0.1:30005@31005,host-5 slave 67ed2db8d677e59ec4a4cefb06858cf2a1a89fa1 0 1426238316232 5 connected
+824fe116063bc5fcf9f4ffd895bc17aee7731ac3 127.0.0.1:30006@31006,host-6 slave 292f8b365bb7edb5e285caf0b7e6ddc7265d2f4f 0 1426238317741 6 connected
+e7d1eecce10fd6bb5eb35b9f99a514335d9ba9ca 127.0.0.1:30001@31001,host-1 myself,master - 0 0 1 connected 0-5460
+`
+
+func TestParseClusterNodes(t *testing.T) {
+	topology, err := redisops.ParseClusterNodes(recordedClusterNodes)
+	if err != nil {
+		t.Fatalf("解析 CLUSTER NODES 失败: %v", err)
+	}
+	if len(topology.Nodes) != 6 || len(topology.Masters()) != 3 {
+		t.Fatalf("期望 6 个节点、3 个主节点，实际为 %d、%d", len(topology.Nodes), len(topology.Masters()))
+	}
+
+	self := topology.Nodes[5]
+	if self.Addr != "127.0.0.1:30001" || self.Role != redisops.NodeRoleMaster || self.SlotCount() != 5461 {
+		t.Errorf("主节点解析不正确: %+v", self)
+	}
+	if self.ReplicationOffset != -1 {
+		t.Errorf("CLUSTER NODES 不提供复制偏移量，期望为 -1，实际为 %d", self.ReplicationOffset)
+	}
+
+	failed := topology.Nodes[1]
+	if !failed.Failed() || failed.LinkState != "disconnected" {
+		t.Errorf("期望节点 30002 处于故障状态: %+v", failed)
+	}
+
+	migrating := topology.Nodes[2]
+	if len(migrating.Slots) != 1 || migrating.Slots[0] != (redisops.SlotRange{Start: 10923, End: 16383}) {
+		t.Errorf("迁移中的槽位不应计入节点槽位，实际为 %v", migrating.Slots)
+	}
+
+	replicas := topology.ReplicasOf(self.ID)
+	if len(replicas) != 1 || replicas[0].Addr != "127.0.0.1:30004" {
+		t.Errorf("期望 30001 有一个从节点 30004，实际为 %+v", replicas)
+	}
+
+	if _, err := redisops.ParseClusterNodes("bad line"); err == nil {
+		t.Error("期望格式错误时返回错误")
+	}
+}
+
+func TestClusterTopology_ReportUncoveredSlots(t *testing.T) {
+	topology, err := redisops.ParseClusterNodes(recordedClusterNodes)
+	if err != nil {
+		t.Fatalf("解析 CLUSTER NODES 失败: %v", err)
+	}
+
+	report := topology.Report(nil)
+	if report.Healthy() {
+		t.Error("存在故障主节点时报告不应为健康")
+	}
+	if len(report.UncoveredSlots) != 1 || report.UncoveredSlots[0] != (redisops.SlotRange{Start: 5461, End: 10922}) {
+		t.Errorf("期望未覆盖的槽位为 5461-10922，实际为 %v", report.UncoveredSlots)
+	}
+	if report.CoveredSlots != redisops.ClusterSlots-5462 {
+		t.Errorf("覆盖的槽位数量不正确: %d", report.CoveredSlots)
+	}
+	if len(report.FailedNodes) != 1 || report.FailedNodes[0].Addr != "127.0.0.1:30002" {
+		t.Errorf("期望故障节点为 30002，实际为 %+v", report.FailedNodes)
+	}
+	if len(report.Masters) != 2 {
+		t.Errorf("期望统计 2 个健康主节点，实际为 %+v", report.Masters)
+	}
+}
+
+func TestClusterTopology_ReportFromShards(t *testing.T) {
+	// 录制的 CLUSTER SHARDS 结果：两个分片槽位失衡，其中一个从节点复制延迟
+	shards := []redis.ClusterShard{
+		{
+			Slots: []redis.SlotRange{{Start: 0, End: 12000}},
+			Nodes: []redis.Node{
+				{ID: "m1", Endpoint: "10.0.0.1", Port: 6379, Role: "master", ReplicationOffset: 50_000_000, Health: "online"},
+				{ID: "r1", Endpoint: "10.0.0.2", Port: 6379, Role: "replica", ReplicationOffset: 40_000_000, Health: "online"},
+			},
+		},
+		{
+			Slots: []redis.SlotRange{{Start: 12001, End: 16383}},
+			Nodes: []redis.Node{
+				{ID: "m2", Endpoint: "10.0.0.3", Port: 6379, Role: "master", ReplicationOffset: 8_000_000, Health: "online"},
+				{ID: "r2", Endpoint: "10.0.0.4", Port: 6379, Role: "replica", ReplicationOffset: 8_000_000, Health: "failed"},
+			},
+		},
+	}
+
+	report := redisops.TopologyFromShards(shards).Report(nil)
+	if len(report.UncoveredSlots) != 0 || report.CoveredSlots != redisops.ClusterSlots {
+		t.Errorf("期望所有槽位被覆盖，实际未覆盖 %v", report.UncoveredSlots)
+	}
+	if len(report.Imbalanced) != 2 {
+		t.Errorf("期望两个主节点均被判定为失衡，实际为 %+v", report.Imbalanced)
+	}
+	if len(report.LaggingReplicas) != 1 || report.LaggingReplicas[0].ID != "r1" || report.LaggingReplicas[0].Lag != 10_000_000 {
+		t.Errorf("期望 r1 复制延迟 10000000 字节，实际为 %+v", report.LaggingReplicas)
+	}
+	if len(report.OrphanedMasters) != 1 || report.OrphanedMasters[0].ID != "m2" {
+		t.Errorf("期望 m2 没有健康的从节点，实际为 %+v", report.OrphanedMasters)
+	}
+	if len(report.FailedNodes) != 1 || report.FailedNodes[0].Addr != "10.0.0.4:6379" {
+		t.Errorf("期望故障节点为 10.0.0.4:6379，实际为 %+v", report.FailedNodes)
+	}
+}
+
+func TestClusterTopology_ReportSuspectedNodes(t *testing.T) {
+	// 录制的 CLUSTER NODES 输出：主节点 30002 被当前节点标记为 fail?，从节点 30005 链路断开，集群尚未确认故障
+	topology, err := redisops.ParseClusterNodes(`
+e7d1eecce10fd6bb5eb35b9f99a514335d9ba9ca 127.0.0.1:30001@31001 myself,master - 0 0 1 connected 0-8191
+67ed2db8d677e59ec4a4cefb06858cf2a1a89fa1 127.0.0.1:30002@31002 master,fail? - 1426238316232 1426238315000 2 disconnected 8192-16383
+6ec23923021cf3ffec47632106199cb7f496ce01 127.0.0.1:30005@31005 slave 67ed2db8d677e59ec4a4cefb06858cf2a1a89fa1 0 1426238316232 5 disconnected
+`)
+	if err != nil {
+		t.Fatalf("解析 CLUSTER NODES 失败: %v", err)
+	}
+
+	pfail := topology.Nodes[1]
+	if pfail.Failed() || !pfail.Suspected() {
+		t.Errorf("期望 fail? 节点为疑似故障而不是故障: %+v", pfail)
+	}
+
+	report := topology.Report(nil)
+	if !report.Healthy() || len(report.FailedNodes) != 0 {
+		t.Errorf("疑似故障不应计为故障，实际为 %+v", report.FailedNodes)
+	}
+	if report.CoveredSlots != redisops.ClusterSlots {
+		t.Errorf("疑似故障的主节点仍应覆盖槽位，实际未覆盖 %v", report.UncoveredSlots)
+	}
+	if len(report.SuspectedNodes) != 2 || report.SuspectedNodes[0].Addr != "127.0.0.1:30002" || report.SuspectedNodes[1].Addr != "127.0.0.1:30005" {
+		t.Errorf("期望疑似故障节点为 30002 与 30005，实际为 %+v", report.SuspectedNodes)
+	}
+	if len(report.Masters) != 2 {
+		t.Errorf("期望统计 2 个主节点，实际为 %+v", report.Masters)
+	}
+}
+
+func TestTopologyFromSlots(t *testing.T) {
+	slots := []redis.ClusterSlot{
+		{Start: 0, End: 8191, Nodes: []redis.ClusterNode{{ID: "m1", Addr: "10.0.0.1:6379"}, {ID: "r1", Addr: "10.0.0.2:6379"}}},
+		{Start: 8192, End: 16000, Nodes: []redis.ClusterNode{{ID: "m2", Addr: "10.0.0.3:6379"}}},
+	}
+
+	topology := redisops.TopologyFromSlots(slots)
+	if len(topology.Masters()) != 2 || len(topology.ReplicasOf("m1")) != 1 {
+		t.Fatalf("拓扑解析不正确: %+v", topology.Nodes)
+	}
+
+	report := topology.Report(nil)
+	if len(report.UncoveredSlots) != 1 || report.UncoveredSlots[0] != (redisops.SlotRange{Start: 16001, End: 16383}) {
+		t.Errorf("期望未覆盖的槽位为 16001-16383，实际为 %v", report.UncoveredSlots)
+	}
+}
+
+func TestClusterManager_Topology(t *testing.T) {
+	ctx, _ := setupTest(t, "cluster_topology")
+	manager := newTestClusterManager(t)
+
+	topology, err := manager.Topology(ctx)
+	if err != nil {
+		t.Fatalf("查询集群拓扑失败: %v", err)
+	}
+	if len(topology.Masters()) == 0 {
+		t.Fatal("期望至少一个主节点")
+	}
+	if report := topology.Report(nil); len(report.UncoveredSlots) != 0 {
+		t.Errorf("期望健康集群所有槽位被覆盖，实际未覆盖 %v", report.UncoveredSlots)
+	}
+}
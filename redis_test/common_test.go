@@ -1 +1,200 @@
 package redis_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	redisops "github.com/yann0917/redis-usage/redis"
+)
+
+// scanHook 模拟 SCAN 的异常行为：第一次调用返回 invalid cursor，之后每页重复返回本页的第一个键
+type scanHook struct {
+	failed bool
+}
+
+func (h *scanHook) DialHook(next redis.DialHook) redis.DialHook { return next }
+
+func (h *scanHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		scan, ok := cmd.(*redis.ScanCmd)
+		if !ok {
+			return next(ctx, cmd)
+		}
+		if !h.failed {
+			h.failed = true
+			err := errors.New("ERR invalid cursor")
+			cmd.SetErr(err)
+			return err
+		}
+		if err := next(ctx, cmd); err != nil {
+			return err
+		}
+		page, cursor := scan.Val()
+		if len(page) > 0 {
+			page = append(page, page[0])
+		}
+		scan.SetVal(page, cursor)
+		return nil
+	}
+}
+
+func (h *scanHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return next
+}
+
+// collect 收集迭代器产出的全部元素
+func collect[T any](t *testing.T, seq func(yield func(T, error) bool)) []T {
+	t.Helper()
+	var items []T
+	for item, err := range seq {
+		if err != nil {
+			t.Fatalf("扫描失败: %v", err)
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+func TestRedisManager_Scan(t *testing.T) {
+	ctx, prefix := setupTest(t, "scan")
+
+	for i := range 250 {
+		globalManager.Set(ctx, testKey(prefix, fmt.Sprintf("str:%d", i)), "v", 0)
+	}
+	globalManager.LPush(ctx, testKey(prefix, "list:1"), "a")
+	globalManager.SAdd(ctx, testKey(prefix, "set:1"), "a")
+
+	keys := collect(t, globalManager.Scan(ctx, &redisops.ScanOptions{Match: prefix + "*", Count: 20}))
+	if len(keys) != 252 {
+		t.Errorf("期望扫描到 252 个键，实际为 %d", len(keys))
+	}
+	seen := make(map[string]bool)
+	for _, key := range keys {
+		if seen[key] {
+			t.Errorf("键 %s 重复返回", key)
+		}
+		seen[key] = true
+	}
+
+	lists := collect(t, globalManager.Scan(ctx, &redisops.ScanOptions{Match: prefix + "*", Type: "list"}))
+	if len(lists) != 1 || lists[0] != testKey(prefix, "list:1") {
+		t.Errorf("期望按类型只扫描到列表键，实际为 %v", lists)
+	}
+
+	// 中途 break 后不再继续扫描
+	count := 0
+	for range globalManager.Scan(ctx, &redisops.ScanOptions{Match: prefix + "*", Count: 10}) {
+		count++
+		if count == 5 {
+			break
+		}
+	}
+	if count != 5 {
+		t.Errorf("期望 break 后停止，实际遍历 %d 个", count)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	var scanErr error
+	for _, err := range globalManager.Scan(cancelled, nil) {
+		scanErr = err
+	}
+	if !errors.Is(scanErr, context.Canceled) {
+		t.Errorf("期望返回 context.Canceled，实际为 %v", scanErr)
+	}
+}
+
+func TestRedisManager_ScanRestartAndDedup(t *testing.T) {
+	ctx, prefix := setupTest(t, "scan_dedup")
+	for i := range 100 {
+		globalManager.Set(ctx, testKey(prefix, fmt.Sprintf("%d", i)), "v", 0)
+	}
+
+	newManager := func() *redisops.RedisManager {
+		client := redis.NewClient(&redis.Options{Addr: testConfig.Addr, DB: testConfig.DB})
+		client.AddHook(&scanHook{})
+		t.Cleanup(func() { client.Close() })
+		return redisops.NewRedisManagerWithClient(client)
+	}
+
+	opts := &redisops.ScanOptions{Match: prefix + "*", Count: 10}
+	keys := collect(t, newManager().Scan(ctx, opts))
+	if len(keys) != 100 {
+		t.Errorf("期望游标失效后重新扫描并去重得到 100 个键，实际为 %d", len(keys))
+	}
+
+	opts.NoDedup = true
+	if keys := collect(t, newManager().Scan(ctx, opts)); len(keys) <= 100 {
+		t.Errorf("期望关闭去重后包含重复的键，实际为 %d 个", len(keys))
+	}
+}
+
+func TestRedisManager_CollectionScans(t *testing.T) {
+	ctx, prefix := setupTest(t, "collection_scan")
+
+	// 超过 listpack 阈值，确保服务端分多页返回
+	hashKey, setKey, zsetKey := testKey(prefix, "hash"), testKey(prefix, "set"), testKey(prefix, "zset")
+	fields := make(map[string]interface{})
+	var members []interface{}
+	var zs []redis.Z
+	for i := range 300 {
+		fields[fmt.Sprintf("field:%d", i)] = fmt.Sprintf("value:%d", i)
+		members = append(members, fmt.Sprintf("member:%d", i))
+		zs = append(zs, redis.Z{Member: fmt.Sprintf("member:%d", i), Score: float64(i)})
+	}
+	globalManager.HMSet(ctx, hashKey, fields)
+	globalManager.SAdd(ctx, setKey, members...)
+	globalManager.ZAdd(ctx, zsetKey, zs...)
+
+	hashFields := collect(t, globalManager.HScan(ctx, hashKey, &redisops.ScanOptions{Count: 50}))
+	if len(hashFields) != 300 {
+		t.Errorf("期望扫描到 300 个哈希字段，实际为 %d", len(hashFields))
+	}
+	for _, f := range hashFields {
+		if "value:"+strings.TrimPrefix(f.Field, "field:") != f.Value {
+			t.Errorf("字段 %s 的值不正确: %s", f.Field, f.Value)
+		}
+	}
+
+	setMembers := collect(t, globalManager.SScan(ctx, setKey, &redisops.ScanOptions{Match: "member:1?", Count: 50}))
+	if len(setMembers) != 10 {
+		t.Errorf("期望匹配 member:1? 的成员有 10 个，实际为 %v", setMembers)
+	}
+
+	zsetMembers := collect(t, globalManager.ZScan(ctx, zsetKey, &redisops.ScanOptions{Match: "member:29?"}))
+	if len(zsetMembers) != 10 {
+		t.Fatalf("期望匹配 member:29? 的成员有 10 个，实际为 %v", zsetMembers)
+	}
+	for _, z := range zsetMembers {
+		if fmt.Sprintf("member:%d", int(z.Score)) != z.Member {
+			t.Errorf("成员 %v 的分数不正确: %v", z.Member, z.Score)
+		}
+	}
+
+	if items := collect(t, globalManager.HScan(ctx, testKey(prefix, "missing"), nil)); len(items) != 0 {
+		t.Errorf("期望不存在的键没有字段，实际为 %v", items)
+	}
+}
+
+func TestClusterManager_Scan(t *testing.T) {
+	ctx, prefix := setupTest(t, "cluster_scan")
+	manager := newTestClusterManager(t)
+
+	var keys []string
+	for i := range 50 {
+		key := testKey(prefix, fmt.Sprintf("%d", i))
+		keys = append(keys, key)
+		manager.Set(ctx, key, "v", time.Minute)
+	}
+	defer manager.Del(ctx, keys...)
+
+	// 键分布在多个槽位，需要遍历所有主节点
+	if got := collect(t, manager.Scan(ctx, &redisops.ScanOptions{Match: prefix + "*"})); len(got) != len(keys) {
+		t.Errorf("期望扫描到 %d 个键，实际为 %d", len(keys), len(got))
+	}
+}
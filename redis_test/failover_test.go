@@ -0,0 +1,211 @@
+package redis_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	redisops "github.com/yann0917/redis-usage/redis"
+)
+
+// 三主三从的健康集群
+const clusterNodesBefore = `
+e7d1eecce10fd6bb5eb35b9f99a514335d9ba9ca 127.0.0.1:30001@31001 myself,master - 0 0 1 connected 0-5460
+67ed2db8d677e59ec4a4cefb06858cf2a1a89fa1 127.0.0.1:30002@31002 master - 0 1426238316232 2 connected 5461-10922
+292f8b365bb7edb5e285caf0b7e6ddc7265d2f4f 127.0.0.1:30003@31003 master - 0 1426238318243 3 connected 10923-16383
+07c37dfeb235213a872192d90877d0cd55635b91 127.0.0.1:30004@31004 slave e7d1eecce10fd6bb5eb35b9f99a514335d9ba9ca 0 1426238317239 4 connected
+6ec23923021cf3ffec47632106199cb7f496ce01 127.0.0.1:30005@31005 slave 67ed2db8d677e59ec4a4cefb06858cf2a1a89fa1 0 1426238316232 5 connected
+824fe116063bc5fcf9f4ffd895bc17aee7731ac3 127.0.0.1:30006@31006 slave 292f8b365bb7edb5e285caf0b7e6ddc7265d2f4f 0 1426238317741 6 connected
+`
+
+// 30002 故障后 30005 晋升为主节点，同时槽位 0-99 被迁移到 30003
+const clusterNodesAfter = `
+e7d1eecce10fd6bb5eb35b9f99a514335d9ba9ca 127.0.0.1:30001@31001 myself,master - 0 0 1 connected 100-5460
+67ed2db8d677e59ec4a4cefb06858cf2a1a89fa1 127.0.0.1:30002@31002 master,fail - 1426238316232 1426238315000 2 disconnected
+292f8b365bb7edb5e285caf0b7e6ddc7265d2f4f 127.0.0.1:30003@31003 master - 0 1426238318243 3 connected 0-99 10923-16383
+07c37dfeb235213a872192d90877d0cd55635b91 127.0.0.1:30004@31004 slave e7d1eecce10fd6bb5eb35b9f99a514335d9ba9ca 0 1426238317239 4 connected
+6ec23923021cf3ffec47632106199cb7f496ce01 127.0.0.1:30005@31005 master - 0 1426238316232 7 connected 5461-10922
+824fe116063bc5fcf9f4ffd895bc17aee7731ac3 127.0.0.1:30006@31006 slave 292f8b365bb7edb5e285caf0b7e6ddc7265d2f4f 0 1426238317741 6 connected
+`
+
+func TestDiffTopology(t *testing.T) {
+	before, err := redisops.ParseClusterNodes(clusterNodesBefore)
+	if err != nil {
+		t.Fatalf("解析切换前拓扑失败: %v", err)
+	}
+	after, err := redisops.ParseClusterNodes(clusterNodesAfter)
+	if err != nil {
+		t.Fatalf("解析切换后拓扑失败: %v", err)
+	}
+
+	if events := redisops.DiffTopology(before, before); len(events) != 0 {
+		t.Errorf("拓扑未变化时不应产生事件，实际为 %+v", events)
+	}
+	// 首次比较时还没有上一次的快照
+	if events := redisops.DiffTopology(nil, after); events != nil {
+		t.Errorf("没有上一次的拓扑时不应产生事件，实际为 %+v", events)
+	}
+	if events := redisops.DiffTopology(before, nil); events != nil {
+		t.Errorf("本次拓扑为空时不应产生事件，实际为 %+v", events)
+	}
+
+	events := redisops.DiffTopology(before, after)
+	if len(events) != 3 {
+		t.Fatalf("期望 3 个事件，实际为 %+v", events)
+	}
+
+	failed, promoted, moved := events[0], events[1], events[2]
+	if failed.Type != redisops.FailoverNodeFailed || failed.NewAddr != "127.0.0.1:30002" {
+		t.Errorf("期望 30002 故障事件，实际为 %+v", failed)
+	}
+	if promoted.Type != redisops.FailoverMasterChanged || promoted.OldAddr != "127.0.0.1:30002" || promoted.NewAddr != "127.0.0.1:30005" {
+		t.Errorf("期望 30005 接替 30002，实际为 %+v", promoted)
+	}
+	if len(promoted.Slots) != 1 || promoted.Slots[0] != (redisops.SlotRange{Start: 5461, End: 10922}) {
+		t.Errorf("期望晋升节点接管 5461-10922，实际为 %v", promoted.Slots)
+	}
+	if moved.Type != redisops.FailoverSlotsMoved || moved.OldAddr != "127.0.0.1:30001" || moved.NewAddr != "127.0.0.1:30003" {
+		t.Errorf("期望槽位从 30001 迁移到 30003，实际为 %+v", moved)
+	}
+	if len(moved.Slots) != 1 || moved.Slots[0] != (redisops.SlotRange{Start: 0, End: 99}) {
+		t.Errorf("期望迁移的槽位为 0-99，实际为 %v", moved.Slots)
+	}
+
+	// 反向比较：故障节点恢复
+	var recovered bool
+	for _, event := range redisops.DiffTopology(after, before) {
+		if event.Type == redisops.FailoverNodeRecovered && event.NewAddr == "127.0.0.1:30002" {
+			recovered = true
+		}
+	}
+	if !recovered {
+		t.Error("期望产生 30002 恢复事件")
+	}
+}
+
+func TestParseSentinelEvent(t *testing.T) {
+	tests := []struct {
+		channel, payload string
+		want             *redisops.FailoverEvent
+	}{
+		{"+switch-master", "mymaster 10.0.0.1 6379 10.0.0.2 6380", &redisops.FailoverEvent{
+			Type: redisops.FailoverMasterChanged, MasterName: "mymaster", OldAddr: "10.0.0.1:6379", NewAddr: "10.0.0.2:6380",
+		}},
+		{"+sdown", "master mymaster 10.0.0.1 6379", &redisops.FailoverEvent{
+			Type: redisops.FailoverNodeFailed, MasterName: "mymaster", NewAddr: "10.0.0.1:6379",
+		}},
+		{"-sdown", "slave 10.0.0.3:6379 10.0.0.3 6379 @ mymaster 10.0.0.2 6380", &redisops.FailoverEvent{
+			Type: redisops.FailoverNodeRecovered, MasterName: "mymaster", NewAddr: "10.0.0.3:6379",
+		}},
+		{"+sdown", "sentinel 5f1c 10.0.0.9 26379 @ mymaster 10.0.0.2 6380", nil},
+	}
+	for _, tt := range tests {
+		got, err := redisops.ParseSentinelEvent(tt.channel, tt.payload)
+		if err != nil {
+			t.Errorf("解析 %s %q 失败: %v", tt.channel, tt.payload, err)
+			continue
+		}
+		if tt.want == nil {
+			if got != nil {
+				t.Errorf("期望忽略 %q，实际为 %+v", tt.payload, got)
+			}
+			continue
+		}
+		if got == nil || got.Type != tt.want.Type || got.MasterName != tt.want.MasterName ||
+			got.OldAddr != tt.want.OldAddr || got.NewAddr != tt.want.NewAddr || got.Source != redisops.FailoverSourceSentinel {
+			t.Errorf("解析 %s %q 期望 %+v，实际为 %+v", tt.channel, tt.payload, tt.want, got)
+		}
+	}
+
+	for _, bad := range [][2]string{{"+switch-master", "mymaster 10.0.0.1"}, {"+sdown", "slave a b c"}, {"+odown", "master m 1 2"}} {
+		if _, err := redisops.ParseSentinelEvent(bad[0], bad[1]); err == nil {
+			t.Errorf("期望 %s %q 返回错误", bad[0], bad[1])
+		}
+	}
+}
+
+func TestFailoverWatcher_Sentinel(t *testing.T) {
+	ctx, _ := setupTest(t, "failover_sentinel")
+
+	if _, err := redisops.NewFailoverWatcher(redisops.FailoverWatcherOptions{}); err == nil {
+		t.Error("期望未配置集群或 Sentinel 时返回错误")
+	}
+
+	// Sentinel 事件本质上是普通的 Pub/Sub 消息，这里直接向测试 Redis 发布来模拟 Sentinel
+	watcher, err := redisops.NewFailoverWatcher(redisops.FailoverWatcherOptions{
+		SentinelAddrs: []string{"127.0.0.1:1", testConfig.Addr}, // 第一个地址不可用，应自动尝试下一个
+		MasterName:    "mymaster",
+	})
+	if err != nil {
+		t.Fatalf("创建故障转移监听器失败: %v", err)
+	}
+
+	var mu sync.Mutex
+	var hooked []redisops.FailoverEvent
+	watcher.OnFailover(func(ctx context.Context, event redisops.FailoverEvent) {
+		mu.Lock()
+		defer mu.Unlock()
+		hooked = append(hooked, event)
+	})
+	if err := watcher.Start(ctx); err != nil {
+		t.Fatalf("启动故障转移监听器失败: %v", err)
+	}
+	defer watcher.Close()
+
+	globalManager.Publish(ctx, "+sdown", "master othermaster 10.0.0.5 6379")
+	globalManager.Publish(ctx, "+sdown", "master mymaster 10.0.0.1 6379")
+	globalManager.Publish(ctx, "+switch-master", "mymaster 10.0.0.1 6379 10.0.0.2 6379")
+
+	var got []redisops.FailoverEvent
+	timeout := time.After(2 * time.Second)
+	for len(got) < 2 {
+		select {
+		case event := <-watcher.Events():
+			got = append(got, event)
+		case <-timeout:
+			t.Fatalf("等待故障转移事件超时，已收到 %+v", got)
+		}
+	}
+	if got[0].Type != redisops.FailoverNodeFailed || got[1].Type != redisops.FailoverMasterChanged || got[1].NewAddr != "10.0.0.2:6379" {
+		t.Errorf("事件不符合预期: %+v", got)
+	}
+
+	mu.Lock()
+	if len(hooked) != 1 || hooked[0].OldAddr != "10.0.0.1:6379" {
+		t.Errorf("期望钩子只在主节点切换时调用一次，实际为 %+v", hooked)
+	}
+	mu.Unlock()
+
+	watcher.Close()
+	if _, ok := <-watcher.Events(); ok {
+		t.Error("期望关闭后事件通道被关闭")
+	}
+}
+
+func TestFailoverWatcher_Cluster(t *testing.T) {
+	ctx, _ := setupTest(t, "failover_cluster")
+	manager := newTestClusterManager(t)
+
+	watcher, err := redisops.NewFailoverWatcher(redisops.FailoverWatcherOptions{
+		Cluster:      manager,
+		PollInterval: 100 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("创建故障转移监听器失败: %v", err)
+	}
+	if err := watcher.Start(ctx); err != nil {
+		t.Fatalf("启动故障转移监听器失败: %v", err)
+	}
+	if err := watcher.Start(ctx); err == nil {
+		t.Error("期望重复启动返回错误")
+	}
+
+	// 拓扑稳定时不应产生事件
+	select {
+	case event := <-watcher.Events():
+		t.Errorf("拓扑稳定时收到事件 %+v", event)
+	case <-time.After(500 * time.Millisecond):
+	}
+	watcher.Close()
+}
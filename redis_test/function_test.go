@@ -0,0 +1,217 @@
+package redis_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	redisops "github.com/yann0917/redis-usage/redis"
+)
+
+// 测试用函数库：计数器自增与只读查询
+const counterLibraryV1 = `#!lua name=test_counter
+redis.register_function('counter_incr', function(keys, args)
+  return redis.call('INCRBY', keys[1], args[1])
+end)
+redis.register_function{
+  function_name = 'counter_get',
+  callback = function(keys, args) return redis.call('GET', keys[1]) end,
+  flags = { 'no-writes' }
+}
+`
+
+// 升级后的函数库：自增步长翻倍
+const counterLibraryV2 = `#!lua name=test_counter
+redis.register_function('counter_incr', function(keys, args)
+  return redis.call('INCRBY', keys[1], args[1] * 2)
+end)
+redis.register_function{
+  function_name = 'counter_get',
+  callback = function(keys, args) return redis.call('GET', keys[1]) end,
+  flags = { 'no-writes' }
+}
+`
+
+// 降级脚本（Redis 7 以下使用）
+var counterFallback = map[string]string{
+	"counter_incr": "return redis.call('INCRBY', KEYS[1], ARGV[1])",
+	"counter_get":  "return redis.call('GET', KEYS[1])",
+}
+
+func newCounterFunctionManager(t *testing.T, code, version string) *redisops.FunctionManager {
+	t.Helper()
+	fm, err := redisops.NewFunctionManager(globalManager, redisops.FunctionLibrary{
+		Name:     "test_counter",
+		Version:  version,
+		Code:     code,
+		Fallback: counterFallback,
+	})
+	if err != nil {
+		t.Fatalf("创建函数库管理器失败: %v", err)
+	}
+	return fm
+}
+
+func TestNewFunctionManager_InvalidLibrary(t *testing.T) {
+	tests := []struct {
+		name    string
+		library redisops.FunctionLibrary
+	}{
+		{name: "库名为空", library: redisops.FunctionLibrary{Code: counterLibraryV1}},
+		{name: "源码为空", library: redisops.FunctionLibrary{Name: "test_counter"}},
+		{name: "库名不匹配", library: redisops.FunctionLibrary{Name: "other", Code: counterLibraryV1}},
+		{name: "库名仅为前缀", library: redisops.FunctionLibrary{Name: "test_count", Code: counterLibraryV1}},
+		{name: "缺少 name 参数", library: redisops.FunctionLibrary{Name: "test_counter", Code: "#!lua test_counter\n"}},
+		{name: "引擎不是 lua", library: redisops.FunctionLibrary{Name: "test_counter", Code: "#!luajit name=test_counter\n"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := redisops.NewFunctionManager(globalManager, tt.library); err == nil {
+				t.Error("期望函数库定义不合法时返回错误")
+			}
+		})
+	}
+}
+
+func TestFunctionManager_CallBeforeSync(t *testing.T) {
+	ctx, prefix := setupTest(t, "function_before_sync")
+	fm := newCounterFunctionManager(t, counterLibraryV1, "1.0.0")
+
+	if _, err := fm.FCallInt64(ctx, "counter_incr", []string{testKey(prefix, "counter")}, 1); err == nil {
+		t.Error("期望未同步时调用函数返回错误")
+	}
+}
+
+func TestFunctionManager_SyncAndUpgrade(t *testing.T) {
+	ctx, prefix := setupTest(t, "function_sync")
+	key := testKey(prefix, "counter")
+
+	v1 := newCounterFunctionManager(t, counterLibraryV1, "1.0.0")
+	result, err := v1.Sync(ctx)
+	if err != nil {
+		t.Fatalf("同步函数库失败: %v", err)
+	}
+	t.Logf("同步结果: %+v", result)
+
+	if result.Action == redisops.FunctionActionFallback {
+		// 低版本或不支持 Functions 的服务端只验证降级脚本可用
+		val, err := v1.FCallInt64(ctx, "counter_incr", []string{key}, 5)
+		if err != nil || val != 5 {
+			t.Fatalf("降级调用失败: val=%d err=%v", val, err)
+		}
+		t.Skipf("服务端 %q 不支持 Functions，跳过加载与升级测试", result.ServerVersion)
+	}
+
+	// 删除后重新同步，验证首次加载
+	if err := v1.Delete(ctx); err != nil {
+		t.Fatalf("清理函数库失败: %v", err)
+	}
+	defer v1.Delete(ctx)
+
+	result, err = v1.Sync(ctx)
+	if err != nil {
+		t.Fatalf("同步函数库失败: %v", err)
+	}
+	if result.Action != redisops.FunctionActionLoaded {
+		t.Errorf("期望首次同步动作为 %s，实际为 %s", redisops.FunctionActionLoaded, result.Action)
+	}
+
+	// 再次同步应无变化
+	result, err = v1.Sync(ctx)
+	if err != nil {
+		t.Fatalf("重复同步函数库失败: %v", err)
+	}
+	if result.Action != redisops.FunctionActionUnchanged {
+		t.Errorf("期望重复同步动作为 %s，实际为 %s", redisops.FunctionActionUnchanged, result.Action)
+	}
+
+	val, err := v1.FCallInt64(ctx, "counter_incr", []string{key}, 5)
+	if err != nil {
+		t.Fatalf("调用函数失败: %v", err)
+	}
+	if val != 5 {
+		t.Errorf("期望值 5，实际值 %d", val)
+	}
+
+	// 使用新版本源码检测漂移并升级
+	v2 := newCounterFunctionManager(t, counterLibraryV2, "2.0.0")
+	drift, err := v2.CheckDrift(ctx)
+	if err != nil {
+		t.Fatalf("检测漂移失败: %v", err)
+	}
+	if !drift {
+		t.Error("期望检测到函数库代码漂移")
+	}
+
+	result, err = v2.Sync(ctx)
+	if err != nil {
+		t.Fatalf("升级函数库失败: %v", err)
+	}
+	if result.Action != redisops.FunctionActionUpgraded {
+		t.Errorf("期望升级动作为 %s，实际为 %s", redisops.FunctionActionUpgraded, result.Action)
+	}
+
+	val, err = v2.FCallInt64(ctx, "counter_incr", []string{key}, 5)
+	if err != nil {
+		t.Fatalf("调用升级后的函数失败: %v", err)
+	}
+	if val != 15 {
+		t.Errorf("期望值 15，实际值 %d", val)
+	}
+
+	// 只读调用
+	got, err := v2.FCallROString(ctx, "counter_get", []string{key})
+	if err != nil {
+		t.Fatalf("只读调用函数失败: %v", err)
+	}
+	if got != "15" {
+		t.Errorf("期望值 15，实际值 %s", got)
+	}
+
+	// 只读调用写函数应失败
+	if _, err := v2.FCallROInt64(ctx, "counter_incr", []string{key}, 1); err == nil {
+		t.Error("期望只读方式调用写函数返回错误")
+	} else if !strings.Contains(err.Error(), "counter_incr") {
+		t.Errorf("期望错误信息包含函数名，实际为: %v", err)
+	}
+}
+
+func TestClusterManager_FunctionAcrossSlots(t *testing.T) {
+	ctx, prefix := setupTest(t, "cluster_function")
+	manager := newTestClusterManager(t)
+
+	fm, err := redisops.NewFunctionManager(manager.RedisManager, redisops.FunctionLibrary{
+		Name:     "test_counter",
+		Version:  "1.0.0",
+		Code:     counterLibraryV1,
+		Fallback: counterFallback,
+	})
+	if err != nil {
+		t.Fatalf("创建函数库管理器失败: %v", err)
+	}
+	if _, err := fm.Sync(ctx); err != nil {
+		t.Fatalf("同步函数库失败: %v", err)
+	}
+	defer fm.Delete(ctx)
+
+	// 函数库需要在每个主节点上可用，分布在不同槽位的键都能调用成功
+	keys := make([]string, 8)
+	for i := range keys {
+		keys[i] = testKey(prefix, fmt.Sprintf("counter:%d", i))
+	}
+	if len(redisops.GroupKeysBySlot(keys)) < 2 {
+		t.Fatal("测试数据应分布在多个槽位")
+	}
+	manager.Del(ctx, keys...)
+	defer manager.Del(ctx, keys...)
+	for _, key := range keys {
+		if val, err := fm.FCallInt64(ctx, "counter_incr", []string{key}, 3); err != nil || val != 3 {
+			t.Errorf("期望键 %s 调用后为 3，实际为 %d, %v", key, val, err)
+		}
+	}
+
+	if drift, err := fm.CheckDrift(ctx); err == nil && drift {
+		t.Error("同步后所有主节点的函数库应与源码一致")
+	}
+}
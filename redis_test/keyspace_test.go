@@ -0,0 +1,210 @@
+package redis_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	redisops "github.com/yann0917/redis-usage/redis"
+)
+
+func TestMatchPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		s       string
+		want    bool
+	}{
+		{"session:*", "session:42", true},
+		{"session:*", "cache:42", false},
+		{"*", "", true},
+		{"h?llo", "hello", true},
+		{"h?llo", "hllo", false},
+		{"h[ae]llo", "hallo", true},
+		{"h[ae]llo", "hillo", false},
+		{"h[^e]llo", "hallo", true},
+		{"h[^e]llo", "hello", false},
+		{"h[a-b]llo", "hbllo", true},
+		{"user:\\*", "user:*", true},
+		{"user:\\*", "user:1", false},
+		{"a*b*c", "a/x/b/y/c", true},
+		{"a*b*c", "a/x/b/y", false},
+		{"*c", "abcbc", true},
+		{"a**", "a", true},
+		{"*?", "", false},
+		{"a*[0-9]", "abc7", true},
+		{"a*[0-9]", "abc7x", false},
+		{"*\\*", "ab*", true},
+	}
+	for _, tt := range tests {
+		if got := redisops.MatchPattern(tt.pattern, tt.s); got != tt.want {
+			t.Errorf("MatchPattern(%q, %q) = %v，期望 %v", tt.pattern, tt.s, got, tt.want)
+		}
+	}
+
+	// 多个 * 且最终不匹配时，朴素递归会指数级回溯
+	pattern := strings.Repeat("a*", 30) + "b"
+	s := strings.Repeat("a", 200)
+	done := make(chan bool, 1)
+	go func() { done <- redisops.MatchPattern(pattern, s) }()
+	select {
+	case got := <-done:
+		if got {
+			t.Error("期望不匹配")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("多个 * 的模式匹配耗时过长")
+	}
+}
+
+func TestParseKeyEvent(t *testing.T) {
+	event, err := redisops.ParseKeyEvent(&redis.Message{Channel: "__keyevent@15__:expired", Payload: "session:1"})
+	if err != nil {
+		t.Fatalf("解析键事件失败: %v", err)
+	}
+	if event.Key != "session:1" || event.Type != redisops.KeyEventExpired || event.DB != 15 {
+		t.Errorf("解析结果不正确: %+v", event)
+	}
+
+	for _, channel := range []string{"orders", "__keyevent@x__:del", "__keyevent@0__:"} {
+		if _, err := redisops.ParseKeyEvent(&redis.Message{Channel: channel}); err == nil {
+			t.Errorf("期望频道 %s 解析失败", channel)
+		}
+	}
+}
+
+func TestKeyspaceListener_FilterByPattern(t *testing.T) {
+	ctx, prefix := setupTest(t, "keyspace_filter")
+
+	listener := redisops.NewKeyspaceListener(globalManager, &redisops.KeyspaceListenerOptions{
+		Events:  []redisops.KeyEventType{redisops.KeyEventExpired, redisops.KeyEventEvicted},
+		Pattern: prefix + "session:*",
+	})
+	defer listener.Close(ctx)
+
+	events := make(chan *redisops.KeyEvent, 10)
+	err := listener.Start(ctx, func(ctx context.Context, event *redisops.KeyEvent) error {
+		events <- event
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("启动监听失败: %v", err)
+	}
+
+	// 直接向键事件频道发布消息，验证订阅与过滤逻辑（不依赖服务端开启通知）
+	db := testConfig.DB
+	publish := func(event, key string) {
+		channel := fmt.Sprintf("__keyevent@%d__:%s", db, event)
+		if _, err := globalManager.Publish(ctx, channel, key); err != nil {
+			t.Fatalf("发布键事件失败: %v", err)
+		}
+	}
+	publish("expired", prefix+"cache:1")
+	publish("del", prefix+"session:1")
+	publish("evicted", prefix+"session:2")
+
+	select {
+	case event := <-events:
+		if event.Key != prefix+"session:2" || event.Type != redisops.KeyEventEvicted || event.DB != db {
+			t.Errorf("收到的事件不正确: %+v", event)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("等待键事件超时")
+	}
+
+	select {
+	case event := <-events:
+		t.Errorf("不应收到未匹配的事件: %+v", event)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestKeyspaceListener_Expired(t *testing.T) {
+	ctx, prefix := setupTest(t, "keyspace_expired")
+
+	listener := redisops.NewKeyspaceListener(globalManager, &redisops.KeyspaceListenerOptions{
+		Events:              []redisops.KeyEventType{redisops.KeyEventExpired},
+		Pattern:             prefix + "session:*",
+		EnableNotifications: true,
+	})
+	defer listener.Close(ctx)
+
+	expired := make(chan string, 10)
+	err := listener.Start(ctx, func(ctx context.Context, event *redisops.KeyEvent) error {
+		expired <- event.Key
+		return nil
+	})
+	if err != nil {
+		t.Skipf("服务端不支持开启键空间通知: %v", err)
+	}
+
+	key := testKey(prefix, "session:1")
+	if err := globalManager.Set(ctx, key, "user-1", 100*time.Millisecond); err != nil {
+		t.Fatalf("设置会话键失败: %v", err)
+	}
+
+	// 过期事件在键被删除时触发，主动访问以触发惰性删除
+	deadline := time.After(3 * time.Second)
+	tick := time.NewTicker(50 * time.Millisecond)
+	defer tick.Stop()
+	for {
+		select {
+		case got := <-expired:
+			if got != key {
+				t.Errorf("期望过期键 %s，实际为 %s", key, got)
+			}
+			return
+		case <-tick.C:
+			globalManager.Exists(ctx, key)
+		case <-deadline:
+			t.Fatal("等待过期事件超时")
+		}
+	}
+}
+
+func TestClusterManager_KeyspaceListener(t *testing.T) {
+	ctx, prefix := setupTest(t, "cluster_keyspace")
+	manager := newTestClusterManager(t)
+
+	listener := redisops.NewKeyspaceListener(manager.RedisManager, &redisops.KeyspaceListenerOptions{
+		Events:  []redisops.KeyEventType{redisops.KeyEventExpired},
+		Pattern: prefix + "*",
+	})
+	defer listener.Close(ctx)
+
+	events := make(chan *redisops.KeyEvent, 10)
+	err := listener.Start(ctx, func(ctx context.Context, event *redisops.KeyEvent) error {
+		events <- event
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("启动监听失败: %v", err)
+	}
+	if err := listener.Start(ctx, func(ctx context.Context, event *redisops.KeyEvent) error { return nil }); err == nil {
+		t.Error("期望重复启动返回错误")
+	}
+
+	// 键事件只在键所在节点发布，每个主节点上发布的事件都应被收到
+	var masters atomic.Int32
+	err = manager.GetClient().ForEachMaster(ctx, func(ctx context.Context, node *redis.Client) error {
+		masters.Add(1)
+		return node.Publish(ctx, "__keyevent@0__:expired", prefix+node.Options().Addr).Err()
+	})
+	if err != nil {
+		t.Fatalf("发布键事件失败: %v", err)
+	}
+
+	received := make(map[string]bool)
+	timeout := time.After(2 * time.Second)
+	for len(received) < int(masters.Load()) {
+		select {
+		case event := <-events:
+			received[strings.TrimPrefix(event.Key, prefix)] = true
+		case <-timeout:
+			t.Fatalf("期望收到 %d 个主节点的事件，实际为 %v", masters.Load(), received)
+		}
+	}
+}
@@ -0,0 +1,262 @@
+package redis_test
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	redisops "github.com/yann0917/redis-usage/redis"
+)
+
+// newTargetManager 创建连接数据库 14 的管理器作为迁移目标，测试结束时清理 prefix 下的键
+func newTargetManager(t *testing.T, prefix string) *redisops.RedisManager {
+	t.Helper()
+	config := *testConfig
+	config.DB = 14
+	manager, err := redisops.NewRedisManager(&config)
+	if err != nil {
+		t.Fatalf("创建目标管理器失败: %v", err)
+	}
+	cleanup := func() { manager.DeleteByPattern(context.Background(), prefix+"*", nil) }
+	cleanup()
+	t.Cleanup(func() {
+		cleanup()
+		manager.Close()
+	})
+	return manager
+}
+
+func TestNewKeyMigrator_InvalidOptions(t *testing.T) {
+	_, prefix := setupTest(t, "key_migrator_options")
+	target := newTargetManager(t, prefix)
+
+	invalid := []*redisops.KeyMigratorOptions{
+		nil,
+		{Match: prefix + "*", Method: "unknown"},
+		// MIGRATE 只在允许覆盖时使用
+		{Match: prefix + "*", Method: redisops.KeyMigrationMigrate},
+	}
+	for _, opts := range invalid {
+		if _, err := redisops.NewKeyMigrator(globalManager, target, opts); err == nil {
+			t.Errorf("期望配置 %+v 返回错误", opts)
+		}
+	}
+	if _, err := redisops.NewKeyMigrator(nil, target, &redisops.KeyMigratorOptions{Match: "*"}); err == nil {
+		t.Error("期望源端为空时返回错误")
+	}
+}
+
+func TestKeyMigrator_CopyAndVerify(t *testing.T) {
+	ctx, prefix := setupTest(t, "key_migrator_copy")
+	target := newTargetManager(t, prefix)
+	globalManager.Set(ctx, testKey(prefix, "other"), "v", 0)
+	for i := range 30 {
+		globalManager.Set(ctx, testKey(prefix, fmt.Sprintf("tenant:%d", i)), strconv.Itoa(i), 0)
+	}
+	// 目标端已存在的键
+	target.Set(ctx, testKey(prefix, "tenant:0"), "old", 0)
+
+	// 测试服务不支持 MIGRATE，Auto 模式应回退为 DUMP/RESTORE
+	var progress []redisops.KeyMigrationStats
+	migrator, err := redisops.NewKeyMigrator(globalManager, target, &redisops.KeyMigratorOptions{
+		Match:      prefix + "tenant:*",
+		BatchSize:  10,
+		Replace:    true,
+		OnProgress: func(s redisops.KeyMigrationStats) { progress = append(progress, s) },
+	})
+	if err != nil {
+		t.Fatalf("创建迁移器失败: %v", err)
+	}
+	stats, err := migrator.Copy(ctx)
+	if err != nil {
+		t.Fatalf("复制失败: %v", err)
+	}
+	if stats.Scanned != 30 || stats.Copied != 30 || stats.Method != redisops.KeyMigrationDumpRestore {
+		t.Errorf("期望回退为 DUMP/RESTORE 并复制 30 个键，实际为 %+v", stats)
+	}
+	if len(progress) != 3 {
+		t.Errorf("期望分 3 批回调进度，实际为 %d 次", len(progress))
+	}
+	if v, _ := target.Get(ctx, testKey(prefix, "tenant:0")); v != "0" {
+		t.Errorf("期望覆盖目标端已存在的键，实际为 %q", v)
+	}
+
+	verification, err := migrator.Verify(ctx)
+	if err != nil {
+		t.Fatalf("校验失败: %v", err)
+	}
+	if !verification.OK() || verification.SourceKeys != 30 || verification.TargetKeys != 30 ||
+		verification.SourceChecksum != verification.TargetChecksum {
+		t.Errorf("期望两端一致，实际为 %+v", verification)
+	}
+
+	// 目标端被修改、缺失或多出键时校验不通过
+	target.Set(ctx, testKey(prefix, "tenant:1"), "changed", 0)
+	target.Del(ctx, testKey(prefix, "tenant:2"))
+	target.Set(ctx, testKey(prefix, "tenant:extra"), "v", 0)
+	verification, err = migrator.Verify(ctx)
+	if err != nil {
+		t.Fatalf("校验失败: %v", err)
+	}
+	if verification.OK() || verification.Mismatched != 1 || verification.Missing != 1 || verification.Extra != 1 ||
+		len(verification.Samples) != 3 || verification.SourceChecksum == verification.TargetChecksum {
+		t.Errorf("期望发现 3 个不一致的键，实际为 %+v", verification)
+	}
+}
+
+func TestKeyMigrator_WithoutReplace(t *testing.T) {
+	ctx, prefix := setupTest(t, "key_migrator_no_replace")
+	target := newTargetManager(t, prefix)
+	globalManager.Set(ctx, testKey(prefix, "a"), "1", time.Hour)
+	globalManager.Set(ctx, testKey(prefix, "b"), "2", 0)
+	target.Set(ctx, testKey(prefix, "b"), "existing", 0)
+
+	migrator, err := redisops.NewKeyMigrator(globalManager, target, &redisops.KeyMigratorOptions{Match: prefix + "*"})
+	if err != nil {
+		t.Fatalf("创建迁移器失败: %v", err)
+	}
+	stats, err := migrator.Copy(ctx)
+	if err != nil || stats.Copied != 1 || stats.Skipped != 1 {
+		t.Errorf("期望复制 1 个键并跳过 1 个，实际为 %+v, %v", stats, err)
+	}
+	if ttl, _ := target.TTL(ctx, testKey(prefix, "a")); ttl <= 0 {
+		t.Errorf("期望保留过期时间，实际为 %v", ttl)
+	}
+	if v, _ := target.Get(ctx, testKey(prefix, "b")); v != "existing" {
+		t.Errorf("不覆盖时目标端的键不应被修改，实际为 %q", v)
+	}
+	verification, _ := migrator.Verify(ctx)
+	if verification.Mismatched != 1 || verification.Samples[0] != testKey(prefix, "b") {
+		t.Errorf("期望发现 1 个内容不一致的键，实际为 %+v", verification)
+	}
+}
+
+func TestKeyMigrator_KeysDeletedDuringCopy(t *testing.T) {
+	ctx, prefix := setupTest(t, "key_migrator_vanished")
+	target := newTargetManager(t, prefix)
+	keys := make([]string, 10)
+	for i := range keys {
+		keys[i] = testKey(prefix, fmt.Sprintf("k:%d", i))
+		globalManager.Set(ctx, keys[i], strconv.Itoa(i), 0)
+	}
+
+	// 第一批复制完成后删除源端剩余的键，后续批次中的键已被扫描但不再存在
+	var once sync.Once
+	migrator, err := redisops.NewKeyMigrator(globalManager, target, &redisops.KeyMigratorOptions{
+		Match:     prefix + "k:*",
+		BatchSize: 2,
+		Replace:   true,
+		OnProgress: func(s redisops.KeyMigrationStats) {
+			once.Do(func() { globalManager.DeleteByPattern(ctx, prefix+"k:*", nil) })
+		},
+	})
+	if err != nil {
+		t.Fatalf("创建迁移器失败: %v", err)
+	}
+	stats, err := migrator.Copy(ctx)
+	if err != nil {
+		t.Fatalf("复制失败: %v", err)
+	}
+	if stats.Copied != 2 || stats.Missing != stats.Scanned-2 {
+		t.Errorf("期望只复制第一批并把其余键计为缺失，实际为 %+v", stats)
+	}
+	if n, _ := target.DelCount(ctx, keys...); n != stats.Copied {
+		t.Errorf("期望目标端有 %d 个键，实际为 %d", stats.Copied, n)
+	}
+}
+
+func TestKeyMigrator_SyncChanges(t *testing.T) {
+	ctx, prefix := setupTest(t, "key_migrator_sync")
+	target := newTargetManager(t, prefix)
+	for i := range 5 {
+		globalManager.Set(ctx, testKey(prefix, fmt.Sprintf("%d", i)), "v1", 0)
+	}
+
+	migrator, err := redisops.NewKeyMigrator(globalManager, target, &redisops.KeyMigratorOptions{
+		Match:       prefix + "*",
+		Replace:     true,
+		SyncChanges: true,
+		QuietPeriod: 100 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("创建迁移器失败: %v", err)
+	}
+	defer migrator.Close(ctx)
+	if _, err := migrator.Copy(ctx); err != nil {
+		t.Fatalf("复制失败: %v", err)
+	}
+
+	// 复制完成后源端继续写入；测试服务不发送键空间通知，手动发布对应的事件
+	notify := func(event, key string) {
+		channel := fmt.Sprintf("__keyevent@%d__:%s", testConfig.DB, event)
+		if _, err := globalManager.Publish(ctx, channel, key); err != nil {
+			t.Fatalf("发布键事件失败: %v", err)
+		}
+	}
+	globalManager.Set(ctx, testKey(prefix, "0"), "v2", 0)
+	notify("set", testKey(prefix, "0"))
+	globalManager.Del(ctx, testKey(prefix, "1"))
+	notify("del", testKey(prefix, "1"))
+	globalManager.Set(ctx, testKey(prefix, "new"), "v1", 0)
+	notify("set", testKey(prefix, "new"))
+	notify("set", "other:key") // 不匹配的键不同步
+
+	if !waitFor(t, 2*time.Second, func() bool { return migrator.Stats().Pending == 3 }) {
+		t.Fatalf("期望记录 3 个脏键，实际为 %+v", migrator.Stats())
+	}
+	synced, err := migrator.Sync(ctx)
+	if err != nil || synced != 3 {
+		t.Fatalf("期望同步 3 个键，实际为 %d, %v", synced, err)
+	}
+	if v, _ := target.Get(ctx, testKey(prefix, "0")); v != "v2" {
+		t.Errorf("期望同步修改后的值，实际为 %q", v)
+	}
+	if n, _ := target.Exists(ctx, testKey(prefix, "1")); n != 0 {
+		t.Error("期望同步删除源端已删除的键")
+	}
+
+	// 停写前的最后一次变更由 Finish 同步
+	globalManager.Set(ctx, testKey(prefix, "2"), "v3", 0)
+	notify("set", testKey(prefix, "2"))
+	verification, err := migrator.Finish(ctx)
+	if err != nil {
+		t.Fatalf("完成迁移失败: %v", err)
+	}
+	if !verification.OK() || verification.TargetKeys != 5 {
+		t.Errorf("期望两端一致且各有 5 个键，实际为 %+v", verification)
+	}
+	if stats := migrator.Stats(); stats.Synced != 4 || stats.Pending != 0 {
+		t.Errorf("期望累计同步 4 个键，实际为 %+v", stats)
+	}
+}
+
+func TestClusterManager_KeyMigrator(t *testing.T) {
+	ctx, prefix := setupTest(t, "cluster_key_migrator")
+	manager := newTestClusterManager(t)
+	target := newTargetManager(t, prefix)
+
+	var keys []string
+	for i := range 20 {
+		key := testKey(prefix, fmt.Sprintf("%d", i))
+		keys = append(keys, key)
+		manager.Set(ctx, key, "v", time.Minute)
+	}
+	defer manager.Del(ctx, keys...)
+
+	if _, err := redisops.NewKeyMigrator(manager.RedisManager, target, &redisops.KeyMigratorOptions{Match: prefix + "*", SyncChanges: true}); err == nil {
+		t.Error("期望源端为集群时不支持同步键空间通知")
+	}
+	migrator, err := redisops.NewKeyMigrator(manager.RedisManager, target, &redisops.KeyMigratorOptions{Match: prefix + "*", Replace: true})
+	if err != nil {
+		t.Fatalf("创建迁移器失败: %v", err)
+	}
+	if stats, err := migrator.Copy(ctx); err != nil || stats.Copied != 20 || stats.Method != redisops.KeyMigrationDumpRestore {
+		t.Errorf("期望从集群复制 20 个键，实际为 %+v, %v", stats, err)
+	}
+	if verification, err := migrator.Verify(ctx); err != nil || !verification.OK() {
+		t.Errorf("期望两端一致，实际为 %+v, %v", verification, err)
+	}
+}
@@ -1 +1,263 @@
 package redis_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	redisops "github.com/yann0917/redis-usage/redis"
+)
+
+func TestRedisManager_ExecBatch(t *testing.T) {
+	ctx, prefix := setupTest(t, "exec_batch")
+
+	const total = 1234
+	ops := make([]redisops.BatchOperation, total)
+	for i := 0; i < total; i++ {
+		ops[i] = redisops.SetOp(testKey(prefix, strconv.Itoa(i)), strconv.Itoa(i), time.Minute)
+	}
+
+	results, err := globalManager.ExecBatch(ctx, ops, &redisops.BatchOptions{ChunkSize: 100})
+	if err != nil {
+		t.Fatalf("批量写入失败: %v", err)
+	}
+	if len(results) != total {
+		t.Fatalf("期望 %d 条结果，实际为 %d", total, len(results))
+	}
+
+	// 读取时混入不存在的键，结果应与输入顺序对齐
+	reads := []redisops.BatchOperation{
+		redisops.GetOp(testKey(prefix, "10")),
+		redisops.GetOp(testKey(prefix, "missing")),
+		redisops.GetOp(testKey(prefix, "1000")),
+	}
+	results, err = globalManager.ExecBatch(ctx, reads, nil)
+	if err != nil {
+		t.Fatalf("键不存在不应视为失败: %v", err)
+	}
+	if v := results[0].Cmd.(*redis.StringCmd).Val(); v != "10" {
+		t.Errorf("期望值 10，实际值 %s", v)
+	}
+	if !errors.Is(results[1].Err, redis.Nil) {
+		t.Errorf("期望不存在的键返回 redis.Nil，实际为 %v", results[1].Err)
+	}
+	if v := results[2].Cmd.(*redis.StringCmd).Val(); v != "1000" {
+		t.Errorf("期望值 1000，实际值 %s", v)
+	}
+}
+
+func TestRedisManager_ExecBatch_PartialFailure(t *testing.T) {
+	ctx, prefix := setupTest(t, "exec_batch_partial")
+
+	hashKey := testKey(prefix, "hash")
+	if err := globalManager.HSet(ctx, hashKey, "f", "v"); err != nil {
+		t.Fatalf("准备数据失败: %v", err)
+	}
+
+	ops := []redisops.BatchOperation{
+		redisops.IncrOp(testKey(prefix, "counter")),
+		redisops.IncrOp(hashKey), // WRONGTYPE
+		redisops.SetOp(testKey(prefix, "ok"), "v", time.Minute),
+		redisops.IncrOp(hashKey), // WRONGTYPE
+	}
+
+	results, err := globalManager.ExecBatch(ctx, ops, &redisops.BatchOptions{ChunkSize: 3})
+	var batchErr *redisops.BatchError
+	if !errors.As(err, &batchErr) {
+		t.Fatalf("期望返回 BatchError，实际为 %v", err)
+	}
+
+	indices := batchErr.FailedIndices()
+	if len(indices) != 2 || indices[0] != 1 || indices[1] != 3 {
+		t.Errorf("期望失败下标 [1 3]，实际为 %v", indices)
+	}
+	if results[0].Err != nil || results[2].Err != nil {
+		t.Errorf("期望其余操作成功，实际为 %v / %v", results[0].Err, results[2].Err)
+	}
+}
+
+func TestRedisManager_ExecBatch_ContextCanceled(t *testing.T) {
+	_, prefix := setupTest(t, "exec_batch_canceled")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	ops := []redisops.BatchOperation{
+		redisops.SetOp(testKey(prefix, "a"), "1", time.Minute),
+		redisops.SetOp(testKey(prefix, "b"), "2", time.Minute),
+	}
+	results, err := globalManager.ExecBatch(ctx, ops, nil)
+	if err == nil {
+		t.Fatal("期望上下文取消时返回错误")
+	}
+	for _, res := range results {
+		if !errors.Is(res.Err, context.Canceled) {
+			t.Errorf("期望操作 %d 因上下文取消失败，实际为 %v", res.Index, res.Err)
+		}
+	}
+}
+
+func TestRedisManager_ExecBatchParallel(t *testing.T) {
+	ctx, prefix := setupTest(t, "exec_batch_parallel")
+
+	const total = 2000
+	ops := make([]redisops.BatchOperation, total)
+	for i := 0; i < total; i++ {
+		ops[i] = redisops.SetOp(testKey(prefix, strconv.Itoa(i)), strconv.Itoa(i), time.Minute)
+	}
+	if _, err := globalManager.ExecBatchParallel(ctx, ops, &redisops.ParallelBatchOptions{ChunkSize: 64, Workers: 4}); err != nil {
+		t.Fatalf("并行批量写入失败: %v", err)
+	}
+
+	reads := make([]redisops.BatchOperation, total)
+	for i := 0; i < total; i++ {
+		reads[i] = redisops.GetOp(testKey(prefix, strconv.Itoa(i)))
+	}
+	results, err := globalManager.ExecBatchParallel(ctx, reads, &redisops.ParallelBatchOptions{ChunkSize: 64, Workers: 4})
+	if err != nil {
+		t.Fatalf("并行批量读取失败: %v", err)
+	}
+
+	// 结果必须与输入顺序一致
+	for i, res := range results {
+		if res.Index != i {
+			t.Fatalf("期望结果下标 %d，实际为 %d", i, res.Index)
+		}
+		if v := res.Cmd.(*redis.StringCmd).Val(); v != strconv.Itoa(i) {
+			t.Fatalf("期望第 %d 个结果为 %d，实际为 %s", i, i, v)
+		}
+	}
+}
+
+func TestRedisManager_ExecBatchParallel_ErrorBudget(t *testing.T) {
+	ctx, prefix := setupTest(t, "exec_batch_parallel_budget")
+
+	hashKey := testKey(prefix, "hash")
+	if err := globalManager.HSet(ctx, hashKey, "f", "v"); err != nil {
+		t.Fatalf("准备数据失败: %v", err)
+	}
+
+	// 所有操作都会失败，错误预算为 5 时应提前停止
+	const total = 1000
+	ops := make([]redisops.BatchOperation, total)
+	for i := range ops {
+		ops[i] = redisops.IncrOp(hashKey)
+	}
+	results, err := globalManager.ExecBatchParallel(ctx, ops, &redisops.ParallelBatchOptions{
+		ChunkSize:   10,
+		Workers:     2,
+		ErrorBudget: 5,
+	})
+	if !errors.Is(err, redisops.ErrErrorBudgetExceeded) {
+		t.Fatalf("期望返回 ErrErrorBudgetExceeded，实际为 %v", err)
+	}
+	if !errors.Is(results[total-1].Err, redisops.ErrErrorBudgetExceeded) {
+		t.Errorf("期望最后一个操作未执行，实际为 %v", results[total-1].Err)
+	}
+}
+
+func TestRedisManager_ExecBatchParallel_ContextCanceled(t *testing.T) {
+	_, prefix := setupTest(t, "exec_batch_parallel_canceled")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	ops := make([]redisops.BatchOperation, 100)
+	for i := range ops {
+		ops[i] = redisops.SetOp(testKey(prefix, strconv.Itoa(i)), "v", time.Minute)
+	}
+	results, err := globalManager.ExecBatchParallel(ctx, ops, &redisops.ParallelBatchOptions{ChunkSize: 10})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("期望返回 context.Canceled，实际为 %v", err)
+	}
+	for _, res := range results {
+		if res.Err == nil {
+			t.Errorf("期望操作 %d 因上下文取消失败", res.Index)
+		}
+	}
+}
+
+func TestRedisManager_ExecBatchParallel_StopsDispatch(t *testing.T) {
+	ctx, prefix := setupTest(t, "exec_batch_parallel_stop")
+
+	hashKey := testKey(prefix, "hash")
+	if err := globalManager.HSet(ctx, hashKey, "f", "v"); err != nil {
+		t.Fatalf("准备数据失败: %v", err)
+	}
+
+	// 单个 worker 时，超出预算或取消后不应再执行任何分块
+	var executed atomic.Int64
+	failing := func(ctx context.Context, pipe redis.Pipeliner) redis.Cmder {
+		executed.Add(1)
+		return pipe.Incr(ctx, hashKey)
+	}
+	ops := make([]redisops.BatchOperation, 50)
+	for i := range ops {
+		ops[i] = failing
+	}
+	results, err := globalManager.ExecBatchParallel(ctx, ops, &redisops.ParallelBatchOptions{ChunkSize: 1, Workers: 1, ErrorBudget: 1})
+	if !errors.Is(err, redisops.ErrErrorBudgetExceeded) {
+		t.Fatalf("期望返回 ErrErrorBudgetExceeded，实际为 %v", err)
+	}
+	if n := executed.Load(); n != 2 {
+		t.Errorf("期望超出预算后停止执行，实际执行了 %d 个操作", n)
+	}
+	if !errors.Is(results[2].Err, redisops.ErrErrorBudgetExceeded) {
+		t.Errorf("期望第 3 个操作未执行，实际为 %v", results[2].Err)
+	}
+
+	executed.Store(0)
+	cancelCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	for i := range ops {
+		ops[i] = func(ctx context.Context, pipe redis.Pipeliner) redis.Cmder {
+			executed.Add(1)
+			cancel()
+			return pipe.Set(ctx, testKey(prefix, strconv.Itoa(i)), "v", time.Minute)
+		}
+	}
+	results, err = globalManager.ExecBatchParallel(cancelCtx, ops, &redisops.ParallelBatchOptions{ChunkSize: 1, Workers: 1})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("期望返回 context.Canceled，实际为 %v", err)
+	}
+	if n := executed.Load(); n != 1 {
+		t.Errorf("期望取消后停止执行，实际执行了 %d 个操作", n)
+	}
+	if !errors.Is(results[len(ops)-1].Err, context.Canceled) {
+		t.Errorf("期望最后一个操作因取消未执行，实际为 %v", results[len(ops)-1].Err)
+	}
+}
+
+func BenchmarkRedisManager_ExecBatch(b *testing.B) {
+	ctx := context.Background()
+	ops := make([]redisops.BatchOperation, 1000)
+	for i := range ops {
+		ops[i] = redisops.SetOp("bench:batch:"+strconv.Itoa(i), "benchmark_value", time.Minute)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := globalManager.ExecBatch(ctx, ops, nil); err != nil {
+			b.Errorf("批量写入失败: %v", err)
+		}
+	}
+}
+
+func BenchmarkRedisManager_ExecBatchParallel(b *testing.B) {
+	ctx := context.Background()
+	ops := make([]redisops.BatchOperation, 1000)
+	for i := range ops {
+		ops[i] = redisops.SetOp("bench:batch:"+strconv.Itoa(i), "benchmark_value", time.Minute)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := globalManager.ExecBatchParallel(ctx, ops, &redisops.ParallelBatchOptions{ChunkSize: 100}); err != nil {
+			b.Errorf("并行批量写入失败: %v", err)
+		}
+	}
+}
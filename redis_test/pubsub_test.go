@@ -1 +1,320 @@
 package redis_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	redisops "github.com/yann0917/redis-usage/redis"
+)
+
+// waitFor 轮询等待条件成立
+func waitFor(t *testing.T, timeout time.Duration, cond func() bool) bool {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return true
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return cond()
+}
+
+func TestSubscriber_ChannelAndPattern(t *testing.T) {
+	ctx, prefix := setupTest(t, "pubsub_basic")
+
+	sub := globalManager.NewSubscriber(nil)
+	defer sub.Close(ctx)
+
+	var mu sync.Mutex
+	received := make(map[string][]string)
+	record := func(ctx context.Context, msg *redis.Message) error {
+		mu.Lock()
+		defer mu.Unlock()
+		received[msg.Channel] = append(received[msg.Channel], msg.Payload)
+		return nil
+	}
+
+	if err := sub.Handle(ctx, prefix+"orders", record); err != nil {
+		t.Fatalf("注册频道失败: %v", err)
+	}
+	if err := sub.HandlePattern(ctx, prefix+"events:*", record); err != nil {
+		t.Fatalf("注册模式失败: %v", err)
+	}
+	if err := sub.Handle(ctx, prefix+"orders", record); err == nil {
+		t.Error("期望重复注册同一频道返回错误")
+	}
+	if err := sub.Start(ctx); err != nil {
+		t.Fatalf("启动订阅者失败: %v", err)
+	}
+
+	// 启动后追加的订阅立即生效
+	if err := sub.Handle(ctx, prefix+"late", record); err != nil {
+		t.Fatalf("追加订阅失败: %v", err)
+	}
+
+	for _, ch := range []string{prefix + "orders", prefix + "events:created", prefix + "late"} {
+		if _, err := globalManager.Publish(ctx, ch, "hello"); err != nil {
+			t.Fatalf("发布消息失败: %v", err)
+		}
+	}
+
+	ok := waitFor(t, 2*time.Second, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(received) == 3
+	})
+	if !ok {
+		t.Errorf("期望 3 个频道收到消息，实际为 %v", received)
+	}
+}
+
+func TestSubscriber_HandlerPanicRecovered(t *testing.T) {
+	ctx, prefix := setupTest(t, "pubsub_panic")
+
+	errCh := make(chan error, 10)
+	sub := globalManager.NewSubscriber(&redisops.SubscriberOptions{
+		Workers: 1,
+		OnError: func(err error) { errCh <- err },
+	})
+	defer sub.Close(ctx)
+
+	var handled atomic.Int32
+	err := sub.Handle(ctx, prefix+"ch", func(ctx context.Context, msg *redis.Message) error {
+		if msg.Payload == "boom" {
+			panic("处理失败")
+		}
+		handled.Add(1)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("注册频道失败: %v", err)
+	}
+	if err := sub.Start(ctx); err != nil {
+		t.Fatalf("启动订阅者失败: %v", err)
+	}
+
+	globalManager.Publish(ctx, prefix+"ch", "boom")
+	globalManager.Publish(ctx, prefix+"ch", "ok")
+
+	select {
+	case err := <-errCh:
+		var panicErr *redisops.HandlerPanicError
+		if !errors.As(err, &panicErr) {
+			t.Errorf("期望上报 HandlerPanicError，实际为 %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("期望 panic 被上报")
+	}
+
+	// panic 之后 worker 仍可继续处理消息
+	if !waitFor(t, 2*time.Second, func() bool { return handled.Load() == 1 }) {
+		t.Error("期望 panic 后继续处理后续消息")
+	}
+}
+
+func TestSubscriber_GracefulClose(t *testing.T) {
+	ctx, prefix := setupTest(t, "pubsub_close")
+
+	sub := globalManager.NewSubscriber(&redisops.SubscriberOptions{Workers: 2})
+
+	var done atomic.Int32
+	started := make(chan struct{}, 10)
+	err := sub.Handle(ctx, prefix+"slow", func(ctx context.Context, msg *redis.Message) error {
+		started <- struct{}{}
+		time.Sleep(200 * time.Millisecond)
+		done.Add(1)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("注册频道失败: %v", err)
+	}
+	if err := sub.Start(ctx); err != nil {
+		t.Fatalf("启动订阅者失败: %v", err)
+	}
+
+	globalManager.Publish(ctx, prefix+"slow", "1")
+	globalManager.Publish(ctx, prefix+"slow", "2")
+	<-started
+	<-started
+
+	closeCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	if err := sub.Close(closeCtx); err != nil {
+		t.Fatalf("关闭订阅者失败: %v", err)
+	}
+	if done.Load() != 2 {
+		t.Errorf("期望关闭前处理完 2 条消息，实际为 %d", done.Load())
+	}
+
+	if err := sub.Handle(ctx, prefix+"other", func(ctx context.Context, msg *redis.Message) error { return nil }); !errors.Is(err, redisops.ErrSubscriberClosed) {
+		t.Errorf("期望关闭后注册返回 ErrSubscriberClosed，实际为 %v", err)
+	}
+}
+
+func TestSubscriber_ResubscribeAfterConnectionLoss(t *testing.T) {
+	ctx, prefix := setupTest(t, "pubsub_resubscribe")
+
+	resubscribed := make(chan struct{}, 1)
+	sub := globalManager.NewSubscriber(&redisops.SubscriberOptions{
+		MinBackoff:     10 * time.Millisecond,
+		OnResubscribed: func() { resubscribed <- struct{}{} },
+	})
+	defer sub.Close(ctx)
+
+	var received atomic.Int32
+	err := sub.Handle(ctx, prefix+"ch", func(ctx context.Context, msg *redis.Message) error {
+		received.Add(1)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("注册频道失败: %v", err)
+	}
+	if err := sub.Start(ctx); err != nil {
+		t.Fatalf("启动订阅者失败: %v", err)
+	}
+
+	// 断开所有订阅连接，模拟网络故障
+	if err := globalManager.GetClient().ClientKillByFilter(ctx, "TYPE", "pubsub").Err(); err != nil {
+		t.Skipf("服务端不支持 CLIENT KILL: %v", err)
+	}
+
+	select {
+	case <-resubscribed:
+	case <-time.After(3 * time.Second):
+		t.Fatal("期望断线后重新订阅")
+	}
+
+	globalManager.Publish(ctx, prefix+"ch", "after-reconnect")
+	if !waitFor(t, 2*time.Second, func() bool { return received.Load() == 1 }) {
+		t.Error("期望重新订阅后收到消息")
+	}
+}
+
+func TestSubscriber_ShardChannels(t *testing.T) {
+	ctx, prefix := setupTest(t, "pubsub_shard")
+
+	if err := globalManager.GetClient().SPublish(ctx, prefix+"probe", "x").Err(); err != nil {
+		t.Skipf("服务端不支持 SPUBLISH: %v", err)
+	}
+
+	sub := globalManager.NewSubscriber(nil)
+	defer sub.Close(ctx)
+
+	var mu sync.Mutex
+	received := make(map[string]string)
+	record := func(ctx context.Context, msg *redis.Message) error {
+		mu.Lock()
+		defer mu.Unlock()
+		received[msg.Channel] = msg.Payload
+		return nil
+	}
+
+	// 分片频道与普通频道共用同一套处理函数 API
+	if err := sub.HandleShard(ctx, prefix+"{user:1}:events", record); err != nil {
+		t.Fatalf("注册分片频道失败: %v", err)
+	}
+	if err := sub.Handle(ctx, prefix+"orders", record); err != nil {
+		t.Fatalf("注册频道失败: %v", err)
+	}
+	if err := sub.HandleShard(ctx, prefix+"{user:1}:events", record); err == nil {
+		t.Error("期望重复注册同一分片频道返回错误")
+	}
+	if err := sub.Start(ctx); err != nil {
+		t.Fatalf("启动订阅者失败: %v", err)
+	}
+
+	// 启动后追加的分片频道立即生效
+	if err := sub.HandleShard(ctx, prefix+"{user:2}:events", record); err != nil {
+		t.Fatalf("追加分片订阅失败: %v", err)
+	}
+
+	for _, ch := range []string{prefix + "{user:1}:events", prefix + "{user:2}:events"} {
+		receivers, err := globalManager.SPublish(ctx, ch, "shard")
+		if err != nil {
+			t.Fatalf("发布分片消息失败: %v", err)
+		}
+		if receivers != 1 {
+			t.Errorf("期望分片频道 %s 有 1 个订阅者，实际为 %d", ch, receivers)
+		}
+	}
+	if _, err := globalManager.Publish(ctx, prefix+"orders", "classic"); err != nil {
+		t.Fatalf("发布消息失败: %v", err)
+	}
+
+	ok := waitFor(t, 2*time.Second, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(received) == 3
+	})
+	if !ok {
+		t.Errorf("期望 3 个频道收到消息，实际为 %v", received)
+	}
+}
+
+func TestClusterManager_ShardSubscriberGroupsByNode(t *testing.T) {
+	ctx, prefix := setupTest(t, "cluster_pubsub_shard")
+	manager := newTestClusterManager(t)
+	if err := manager.GetClient().SPublish(ctx, prefix+"probe", "x").Err(); err != nil {
+		t.Skipf("服务端不支持 SPUBLISH: %v", err)
+	}
+
+	sub := manager.NewSubscriber(nil)
+	defer sub.Close(ctx)
+
+	var received atomic.Int32
+	channels := make([]string, 32)
+	for i := range channels {
+		channels[i] = fmt.Sprintf("%sshard:%d", prefix, i)
+		err := sub.HandleShard(ctx, channels[i], func(ctx context.Context, msg *redis.Message) error {
+			received.Add(1)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("注册分片频道失败: %v", err)
+		}
+	}
+	if len(redisops.GroupKeysBySlot(channels)) < 2 {
+		t.Fatal("测试频道应分布在多个槽位")
+	}
+	if err := sub.Start(ctx); err != nil {
+		t.Fatalf("启动订阅者失败: %v", err)
+	}
+
+	// 每个主节点上最多一条分片订阅连接
+	err := manager.GetClient().ForEachMaster(ctx, func(ctx context.Context, node *redis.Client) error {
+		list, err := node.ClientList(ctx).Result()
+		if err != nil {
+			return err
+		}
+		conns := 0
+		for _, line := range strings.Split(list, "\n") {
+			if strings.Contains(line, " ssub=") && !strings.Contains(line, " ssub=0 ") {
+				conns++
+			}
+		}
+		if conns > 1 {
+			t.Errorf("期望节点 %s 只有 1 条分片订阅连接，实际为 %d", node.Options().Addr, conns)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("统计订阅连接失败: %v", err)
+	}
+
+	for _, ch := range channels {
+		if _, err := manager.SPublish(ctx, ch, "shard"); err != nil {
+			t.Fatalf("发布分片消息失败: %v", err)
+		}
+	}
+	if !waitFor(t, 2*time.Second, func() bool { return received.Load() == int32(len(channels)) }) {
+		t.Errorf("期望收到 %d 条消息，实际为 %d", len(channels), received.Load())
+	}
+}
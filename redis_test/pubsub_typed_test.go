@@ -0,0 +1,123 @@
+package redis_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"errors"
+	"testing"
+	"time"
+
+	redisops "github.com/yann0917/redis-usage/redis"
+)
+
+// orderEvent 测试用事件结构
+type orderEvent struct {
+	OrderID string
+	Amount  int64
+}
+
+// gobCodec 自定义编解码器，验证 Codec 可插拔
+type gobCodec struct{}
+
+func (gobCodec) Name() string { return "gob" }
+
+func (gobCodec) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	err := gob.NewEncoder(&buf).Encode(v)
+	return buf.Bytes(), err
+}
+
+func (gobCodec) Unmarshal(data []byte, v interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+func TestTypedPubSub_RoundTrip(t *testing.T) {
+	codecs := []redisops.Codec{redisops.JSONCodec{}, gobCodec{}}
+
+	for _, codec := range codecs {
+		t.Run(codec.Name(), func(t *testing.T) {
+			ctx, prefix := setupTest(t, "typed_pubsub_"+codec.Name())
+			channel := prefix + "orders"
+
+			sub := globalManager.NewSubscriber(nil)
+			defer sub.Close(ctx)
+
+			received := make(chan *redisops.Envelope[orderEvent], 1)
+			_, err := redisops.SubscribeTyped(ctx, sub, channel, codec,
+				func(ctx context.Context, env *redisops.Envelope[orderEvent]) error {
+					received <- env
+					return nil
+				}, nil)
+			if err != nil {
+				t.Fatalf("注册类型化订阅失败: %v", err)
+			}
+			if err := sub.Start(ctx); err != nil {
+				t.Fatalf("启动订阅者失败: %v", err)
+			}
+
+			pub := redisops.NewPublisher[orderEvent](globalManager, channel, "order-service", codec)
+			sent, receivers, err := pub.Publish(ctx, orderEvent{OrderID: "o-1", Amount: 99})
+			if err != nil {
+				t.Fatalf("发布事件失败: %v", err)
+			}
+			if receivers != 1 {
+				t.Errorf("期望 1 个订阅者，实际为 %d", receivers)
+			}
+
+			select {
+			case env := <-received:
+				if env.ID != sent.ID || env.Source != "order-service" {
+					t.Errorf("信封元数据不一致: 发送 %+v，接收 %+v", sent, env)
+				}
+				if env.Payload.OrderID != "o-1" || env.Payload.Amount != 99 {
+					t.Errorf("负载不一致: %+v", env.Payload)
+				}
+				if env.Timestamp.IsZero() {
+					t.Error("期望信封包含时间戳")
+				}
+			case <-time.After(2 * time.Second):
+				t.Fatal("等待事件超时")
+			}
+		})
+	}
+}
+
+func TestTypedPubSub_DecodeErrorCallback(t *testing.T) {
+	ctx, prefix := setupTest(t, "typed_pubsub_decode_error")
+	channel := prefix + "orders"
+
+	sub := globalManager.NewSubscriber(nil)
+	defer sub.Close(ctx)
+
+	decodeErrs := make(chan error, 1)
+	_, err := redisops.SubscribeTyped(ctx, sub, channel, nil,
+		func(ctx context.Context, env *redisops.Envelope[orderEvent]) error {
+			t.Errorf("不应收到无法解码的消息: %+v", env)
+			return nil
+		},
+		func(err error) { decodeErrs <- err })
+	if err != nil {
+		t.Fatalf("注册类型化订阅失败: %v", err)
+	}
+	if err := sub.Start(ctx); err != nil {
+		t.Fatalf("启动订阅者失败: %v", err)
+	}
+
+	if _, err := globalManager.Publish(ctx, channel, "not-json"); err != nil {
+		t.Fatalf("发布消息失败: %v", err)
+	}
+
+	select {
+	case err := <-decodeErrs:
+		var decodeErr *redisops.DecodeError
+		if !errors.As(err, &decodeErr) {
+			t.Fatalf("期望 DecodeError，实际为 %v", err)
+		}
+		if decodeErr.Payload != "not-json" {
+			t.Errorf("期望保留原始消息，实际为 %s", decodeErr.Payload)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("期望解码失败时调用错误回调")
+	}
+}
@@ -0,0 +1,469 @@
+package redis_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc64"
+	"io"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	redisops "github.com/yann0917/redis-usage/redis"
+)
+
+// rdbBuilder 按 RDB 格式手工编码测试文件，覆盖各种紧凑编码
+type rdbBuilder struct {
+	buf bytes.Buffer
+}
+
+func newRDBBuilder() *rdbBuilder {
+	b := &rdbBuilder{}
+	b.buf.WriteString("REDIS0011")
+	return b
+}
+
+func (b *rdbBuilder) byte(v ...byte) *rdbBuilder {
+	b.buf.Write(v)
+	return b
+}
+
+func (b *rdbBuilder) length(n int) *rdbBuilder {
+	switch {
+	case n < 1<<6:
+		b.buf.WriteByte(byte(n))
+	case n < 1<<14:
+		b.buf.Write([]byte{0x40 | byte(n>>8), byte(n)})
+	default:
+		b.buf.WriteByte(0x80)
+		b.buf.Write(binary.BigEndian.AppendUint32(nil, uint32(n)))
+	}
+	return b
+}
+
+func (b *rdbBuilder) str(s string) *rdbBuilder {
+	b.length(len(s))
+	b.buf.WriteString(s)
+	return b
+}
+
+// key 写入值类型与键名
+func (b *rdbBuilder) key(valueType byte, key string) *rdbBuilder {
+	return b.byte(valueType).str(key)
+}
+
+// expireAt 写入毫秒精度的过期时间
+func (b *rdbBuilder) expireAt(t time.Time) *rdbBuilder {
+	b.byte(0xFC)
+	b.buf.Write(binary.LittleEndian.AppendUint64(nil, uint64(t.UnixMilli())))
+	return b
+}
+
+// finish 写入结束标记与 CRC64 校验和
+func (b *rdbBuilder) finish() []byte {
+	b.byte(0xFF)
+	table := crc64.MakeTable(0x95ac9329ac4bc9b5)
+	sum := ^crc64.Update(^uint64(0), table, b.buf.Bytes())
+	b.buf.Write(binary.LittleEndian.AppendUint64(nil, sum))
+	return b.buf.Bytes()
+}
+
+// listpack 编码元素，整数值使用 7 位、13 位或 16 位整数编码
+func listpack(items ...any) string {
+	var body []byte
+	for _, item := range items {
+		var entry []byte
+		switch v := item.(type) {
+		case int:
+			switch {
+			case v >= 0 && v < 128:
+				entry = []byte{byte(v)}
+			case v >= -4096 && v < 4096:
+				entry = []byte{0xC0 | byte(v>>8)&0x1f, byte(v)}
+			default:
+				entry = append([]byte{0xF1}, binary.LittleEndian.AppendUint16(nil, uint16(v))...)
+			}
+		case string:
+			entry = append([]byte{0x80 | byte(len(v))}, v...)
+		}
+		body = append(body, entry...)
+		body = append(body, byte(len(entry)))
+	}
+	header := binary.LittleEndian.AppendUint32(nil, uint32(6+len(body)+1))
+	header = binary.LittleEndian.AppendUint16(header, uint16(len(items)))
+	return string(append(append(header, body...), 0xFF))
+}
+
+// ziplist 编码元素，整数值使用立即数或 16 位整数编码
+func ziplist(items ...any) string {
+	var body []byte
+	prev := 0
+	for _, item := range items {
+		entry := []byte{byte(prev)}
+		switch v := item.(type) {
+		case int:
+			if v >= 0 && v <= 12 {
+				entry = append(entry, 0xF1+byte(v))
+			} else {
+				entry = append(entry, 0xC0)
+				entry = binary.LittleEndian.AppendUint16(entry, uint16(v))
+			}
+		case string:
+			entry = append(append(entry, byte(len(v))), v...)
+		}
+		body = append(body, entry...)
+		prev = len(entry)
+	}
+	header := binary.LittleEndian.AppendUint32(nil, uint32(10+len(body)+1))
+	header = binary.LittleEndian.AppendUint32(header, uint32(10+len(body)-prev))
+	header = binary.LittleEndian.AppendUint16(header, uint16(len(items)))
+	return string(append(append(header, body...), 0xFF))
+}
+
+// intset 编码 16 位整数集合
+func intset(values ...int16) string {
+	data := binary.LittleEndian.AppendUint32(nil, 2)
+	data = binary.LittleEndian.AppendUint32(data, uint32(len(values)))
+	for _, v := range values {
+		data = binary.LittleEndian.AppendUint16(data, uint16(v))
+	}
+	return string(data)
+}
+
+// streamID 编码 16 字节大端序的消息 ID
+func streamID(ms, seq uint64) string {
+	return string(binary.BigEndian.AppendUint64(binary.BigEndian.AppendUint64(nil, ms), seq))
+}
+
+// buildTestRDB 生成包含各种类型与编码的 RDB 文件，键名均以 prefix 开头
+func buildTestRDB(prefix string) []byte {
+	b := newRDBBuilder()
+	b.byte(0xFA).str("redis-ver").str("7.2.4")
+	b.byte(0xFA).str("ctime").byte(0xC2).byte(binary.LittleEndian.AppendUint32(nil, 1700000000)...)
+	b.byte(0xF5).str("#!lua name=lib\nredis.register_function('f', function() return 1 end)")
+	b.byte(0xFE).length(0).byte(0xFB).length(12).length(1)
+
+	b.byte(0xF9, 200).expireAt(time.Now().Add(time.Hour)).key(0, prefix+"string").str("hello")
+	b.key(0, prefix+"int").byte(0xC0, 0xF6) // INT8 -10
+	b.key(0, prefix+"lzf").byte(0xC3).length(5).length(10).byte(0x00, 'a', 0xE0, 0x00, 0x00)
+	b.key(16, prefix+"hash").str(listpack("name", "alice", "age", 30))
+	b.key(13, prefix+"hash_ziplist").str(ziplist("f", 1000))
+	b.key(11, prefix+"intset").str(intset(-5, 3, 300))
+	b.key(20, prefix+"set").str(listpack("x", "y"))
+	b.key(17, prefix+"zset").str(listpack("a", 1, "b", "1.5"))
+	b.key(5, prefix+"zset2").length(2).str("y").byte(binary.LittleEndian.AppendUint64(nil, math.Float64bits(math.Inf(-1)))...).
+		str("x").byte(binary.LittleEndian.AppendUint64(nil, math.Float64bits(2.5))...)
+	b.key(18, prefix+"list").length(2).
+		length(2).str(listpack("a", -100, "b")).
+		length(1).str("plain")
+	b.key(10, prefix+"list_ziplist").str(ziplist("z", 7))
+	b.expireAt(time.Now().Add(-time.Minute)).key(0, prefix+"expired").str("gone")
+
+	// Stream：一个节点，第二条消息已删除；之后是元数据与一个消费者组
+	b.key(21, prefix+"stream").length(1).str(streamID(1000, 0)).str(listpack(
+		2, 1, 2, "a", "b", 0, // count、deleted、主字段、结束标记
+		2, 0, 0, "1", "2", 4, // 使用主字段
+		3, 1, 0, "x", "y", 4, // 已删除
+		0, 5, 1, 1, "c", "3", 6, // 独立字段
+	))
+	b.length(2).length(1005).length(1).length(1000).length(0).length(0).length(0).length(3)
+	b.length(1).str("group").length(1005).length(1).length(2)
+	b.length(1).byte([]byte(streamID(1000, 0))...).byte(make([]byte, 8)...).length(1)
+	b.length(1).str("consumer").byte(make([]byte, 16)...).length(1).byte([]byte(streamID(1000, 0))...)
+
+	b.byte(0xFE).length(1).key(0, prefix+"db1").str("other")
+	return b.finish()
+}
+
+func TestRDBReader_Encodings(t *testing.T) {
+	prefix := "rdb:"
+	rr, err := redisops.NewRDBReader(bytes.NewReader(buildTestRDB(prefix)))
+	if err != nil {
+		t.Fatalf("创建读取器失败: %v", err)
+	}
+	entries := make(map[string]*redisops.RDBEntry)
+	for {
+		entry, err := rr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("读取失败: %v", err)
+		}
+		entries[entry.Key[len(prefix):]] = entry
+	}
+	if rr.Version() != 11 || rr.Aux()["redis-ver"] != "7.2.4" || rr.Aux()["ctime"] != "1700000000" {
+		t.Errorf("文件头或辅助字段不正确: %d %v", rr.Version(), rr.Aux())
+	}
+
+	want := map[string]struct {
+		typ, encoding string
+		value         any
+	}{
+		"string":       {"string", "string", "hello"},
+		"int":          {"string", "string", "-10"},
+		"lzf":          {"string", "string", "aaaaaaaaaa"},
+		"hash":         {"hash", "listpack", []redisops.HashField{{Field: "name", Value: "alice"}, {Field: "age", Value: "30"}}},
+		"hash_ziplist": {"hash", "ziplist", []redisops.HashField{{Field: "f", Value: "1000"}}},
+		"intset":       {"set", "intset", []string{"-5", "3", "300"}},
+		"set":          {"set", "listpack", []string{"x", "y"}},
+		"zset":         {"zset", "listpack", []redis.Z{{Member: "a", Score: 1}, {Member: "b", Score: 1.5}}},
+		"zset2":        {"zset", "skiplist", []redis.Z{{Member: "y", Score: math.Inf(-1)}, {Member: "x", Score: 2.5}}},
+		"list":         {"list", "quicklist", []string{"a", "-100", "b", "plain"}},
+		"list_ziplist": {"list", "ziplist", []string{"z", "7"}},
+		"expired":      {"string", "string", "gone"},
+		"stream": {"stream", "listpacks", []redisops.JSONStreamEntry{
+			{ID: "1000-0", Values: []string{"a", "1", "b", "2"}},
+			{ID: "1005-1", Values: []string{"c", "3"}},
+		}},
+		"db1": {"string", "string", "other"},
+	}
+	if len(entries) != len(want) {
+		t.Errorf("期望读取 %d 个键，实际为 %d", len(want), len(entries))
+	}
+	for name, w := range want {
+		entry := entries[name]
+		if entry == nil {
+			t.Errorf("缺少键 %s", name)
+			continue
+		}
+		if entry.Type != w.typ || entry.Encoding != w.encoding || !reflect.DeepEqual(entry.Value, w.value) {
+			t.Errorf("键 %s 期望为 %s/%s %#v，实际为 %s/%s %#v", name, w.typ, w.encoding, w.value, entry.Type, entry.Encoding, entry.Value)
+		}
+		if entry.Size <= 0 {
+			t.Errorf("键 %s 的序列化大小应大于 0", name)
+		}
+	}
+	if e := entries["string"]; e.Freq != 200 || time.Until(e.Expiry) < 59*time.Minute || e.Elements != 5 {
+		t.Errorf("期望带有访问频率与过期时间，实际为 %+v", e)
+	}
+	if e := entries["int"]; e.Freq != 0 || !e.Expiry.IsZero() {
+		t.Errorf("访问频率与过期时间不应沿用到下一个键，实际为 %+v", e)
+	}
+	if entries["db1"].DB != 1 || entries["list"].DB != 0 || entries["stream"].Elements != 2 {
+		t.Error("数据库编号或元素数量不正确")
+	}
+}
+
+func TestRDBReader_Corrupted(t *testing.T) {
+	data := buildTestRDB("rdb:")
+	readAll := func(data []byte) error {
+		rr, err := redisops.NewRDBReader(bytes.NewReader(data))
+		if err != nil {
+			return err
+		}
+		for {
+			if _, err := rr.Next(); err != nil {
+				if errors.Is(err, io.EOF) {
+					return nil
+				}
+				return err
+			}
+		}
+	}
+
+	if err := readAll(data); err != nil {
+		t.Fatalf("完整文件应读取成功: %v", err)
+	}
+	if _, err := redisops.NewRDBReader(bytes.NewReader([]byte("RDSBAKUP0"))); !errors.Is(err, redisops.ErrRDBFormat) {
+		t.Errorf("期望文件标识错误，实际为 %v", err)
+	}
+
+	corrupted := bytes.Clone(data)
+	i := bytes.Index(corrupted, []byte("hello"))
+	corrupted[i] = 'j'
+	if err := readAll(corrupted); !errors.Is(err, redisops.ErrRDBChecksum) {
+		t.Errorf("期望校验和不匹配，实际为 %v", err)
+	}
+
+	// 校验和为 0 表示生成时关闭了校验
+	disabled := bytes.Clone(corrupted)
+	copy(disabled[len(disabled)-8:], make([]byte, 8))
+	if err := readAll(disabled); err != nil {
+		t.Errorf("关闭校验时不应校验，实际为 %v", err)
+	}
+
+	if err := readAll(data[:len(data)/2]); !errors.Is(err, redisops.ErrRDBFormat) {
+		t.Errorf("期望截断的文件返回格式错误，实际为 %v", err)
+	}
+
+	unsupported := newRDBBuilder().key(22, "hfe").str("v").finish()
+	if err := readAll(unsupported); !errors.Is(err, redisops.ErrRDBUnsupported) {
+		t.Errorf("期望不支持的类型返回错误，实际为 %v", err)
+	}
+}
+
+func TestAnalyzeRDB(t *testing.T) {
+	data := buildTestRDB("rdb:")
+	var progress int64
+	report, err := redisops.AnalyzeRDB(bytes.NewReader(data), &redisops.RDBOptions{
+		DBs:        []int{0},
+		Match:      "rdb:*",
+		Report:     &redisops.ReportOptions{TopN: 3},
+		OnProgress: func(n int64) { progress = n },
+	})
+	if err != nil {
+		t.Fatalf("分析失败: %v", err)
+	}
+	if report.Keys != 12 || report.MissingKeys != 1 || progress != 14 {
+		t.Errorf("期望分析 12 个键并跳过 1 个已过期的键，实际为 %d/%d，进度 %d", report.Keys, report.MissingKeys, progress)
+	}
+	if report.Types["set"].Keys != 2 || report.Types["zset"].Elements != 4 {
+		t.Errorf("类型统计不正确: %+v", report.Types)
+	}
+	if len(report.TopByMemory) != 3 || report.TopByMemory[0].Key != "rdb:stream" {
+		t.Errorf("期望 Stream 占用最多，实际为 %+v", report.TopByMemory)
+	}
+	if len(report.HotKeys) != 1 || report.HotKeys[0].Key != "rdb:string" || report.HotKeys[0].Freq != 200 {
+		t.Errorf("期望根据 FREQ 统计热键，实际为 %+v", report.HotKeys)
+	}
+
+	report, err = redisops.AnalyzeRDB(bytes.NewReader(data), &redisops.RDBOptions{Type: "list"})
+	if err != nil || report.Keys != 2 {
+		t.Errorf("期望按类型过滤出 2 个键，实际为 %+v, %v", report, err)
+	}
+}
+
+func TestExportRDBToJSON(t *testing.T) {
+	ctx, prefix := setupTest(t, "rdb_export_json")
+	data := buildTestRDB(prefix)
+
+	var buf bytes.Buffer
+	exported, err := redisops.ExportRDBToJSON(bytes.NewReader(data), &buf, &redisops.RDBOptions{DBs: []int{0}})
+	if err != nil {
+		t.Fatalf("导出失败: %v", err)
+	}
+	if exported.Exported != 12 || exported.Missing != 1 {
+		t.Errorf("期望导出 12 个键并跳过 1 个已过期的键，实际为 %+v", exported)
+	}
+
+	// 导出的数据可以直接用 ImportJSON 导入
+	imported, err := globalManager.ImportJSON(ctx, &buf, &redisops.ImportOptions{Replace: true})
+	if err != nil || imported.Restored != 12 {
+		t.Fatalf("导入失败: %+v, %v", imported, err)
+	}
+	client := globalManager.GetClient()
+	if v := client.Get(ctx, testKey(prefix, "lzf")).Val(); v != "aaaaaaaaaa" {
+		t.Errorf("字符串不正确: %q", v)
+	}
+	if ttl := client.PTTL(ctx, testKey(prefix, "string")).Val(); ttl <= 0 || ttl > time.Hour {
+		t.Errorf("期望保留剩余过期时间，实际为 %v", ttl)
+	}
+	if v := client.HGetAll(ctx, testKey(prefix, "hash")).Val(); !reflect.DeepEqual(v, map[string]string{"name": "alice", "age": "30"}) {
+		t.Errorf("哈希不正确: %v", v)
+	}
+	if v := client.LRange(ctx, testKey(prefix, "list"), 0, -1).Val(); !reflect.DeepEqual(v, []string{"a", "-100", "b", "plain"}) {
+		t.Errorf("列表不正确: %v", v)
+	}
+	if v := client.SMembers(ctx, testKey(prefix, "intset")).Val(); len(v) != 3 {
+		t.Errorf("集合不正确: %v", v)
+	}
+	if v := client.ZRangeWithScores(ctx, testKey(prefix, "zset2"), 0, -1).Val(); len(v) != 2 || !math.IsInf(v[0].Score, -1) {
+		t.Errorf("有序集合不正确: %v", v)
+	}
+	if v := client.XLen(ctx, testKey(prefix, "stream")).Val(); v != 2 {
+		t.Errorf("Stream 不正确: %d", v)
+	}
+}
+
+// TestRDBReader_RedisServer 解析本机 redis-server 生成的 RDB 文件，未安装时跳过
+func TestRDBReader_RedisServer(t *testing.T) {
+	bin, err := exec.LookPath("redis-server")
+	if err != nil {
+		t.Skip("未安装 redis-server，跳过 RDB 文件测试")
+	}
+	ctx := t.Context()
+	dir := t.TempDir()
+	port := freePort(t)
+	cmd := exec.Command(bin, "--port", strconv.Itoa(port), "--dir", dir, "--save", "", "--appendonly", "no")
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("启动 redis-server 失败: %v", err)
+	}
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+	manager := redisops.NewRedisManagerWithClient(redis.NewClient(&redis.Options{Addr: fmt.Sprintf("127.0.0.1:%d", port)}))
+	if !waitFor(t, 5*time.Second, func() bool { return manager.Ping(ctx) == nil }) {
+		t.Fatal("等待 redis-server 启动超时")
+	}
+	t.Cleanup(func() { manager.Close() })
+
+	prefix := "rdb:"
+	seedAllTypes(t, manager, prefix)
+	client := manager.GetClient()
+	client.RPush(ctx, testKey(prefix, "biglist"), bytes.Repeat([]byte("v"), 10000))
+	for i := range 200 {
+		client.HSet(ctx, testKey(prefix, "bighash"), fmt.Sprintf("field:%d", i), i)
+		client.SAdd(ctx, testKey(prefix, "intset"), i)
+	}
+	if err := client.Save(ctx).Err(); err != nil {
+		t.Fatalf("SAVE 失败: %v", err)
+	}
+
+	rr, closer, err := redisops.NewRDBFileReader(filepath.Join(dir, "dump.rdb"))
+	if err != nil {
+		t.Fatalf("打开 RDB 文件失败: %v", err)
+	}
+	defer closer.Close()
+	keys := make(map[string]*redisops.RDBEntry)
+	for {
+		entry, err := rr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("读取失败: %v", err)
+		}
+		keys[entry.Key] = entry
+	}
+	if len(keys) != 10 {
+		t.Errorf("期望读取 10 个键，实际为 %d", len(keys))
+	}
+	if e := keys[testKey(prefix, "bighash")]; e == nil || e.Elements != 200 || e.Encoding != "hashtable" {
+		t.Errorf("大哈希不正确: %+v", e)
+	}
+	if e := keys[testKey(prefix, "stream")]; e == nil || e.Elements != 2 {
+		t.Errorf("Stream 不正确: %+v", e)
+	}
+
+	// 从 RDB 导出的 JSON 与在线导出的结果一致
+	var online, offline bytes.Buffer
+	if _, err := manager.ExportJSON(ctx, &online, &redisops.ExportOptions{Match: prefix + "*"}); err != nil {
+		t.Fatalf("在线导出失败: %v", err)
+	}
+	f, err := os.Open(filepath.Join(dir, "dump.rdb"))
+	if err != nil {
+		t.Fatalf("打开 RDB 文件失败: %v", err)
+	}
+	defer f.Close()
+	if _, err := redisops.ExportRDBToJSON(f, &offline, nil); err != nil {
+		t.Fatalf("离线导出失败: %v", err)
+	}
+	if a, b := jsonLinesByKey(t, online.Bytes()), jsonLinesByKey(t, offline.Bytes()); !reflect.DeepEqual(a, b) {
+		t.Errorf("在线与离线导出结果不一致:\n%v\n%v", a, b)
+	}
+}
+
+// jsonLinesByKey 按键名索引 JSON Lines，忽略过期时间的差异
+func jsonLinesByKey(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+	records := make(map[string]string)
+	for _, line := range bytes.Split(bytes.TrimSpace(data), []byte("\n")) {
+		var rec redisops.JSONRecord
+		if err := json.Unmarshal(line, &rec); err != nil {
+			t.Fatalf("解析 JSON 失败: %v", err)
+		}
+		records[rec.Key] = fmt.Sprintf("%s %s %s ttl=%v", rec.Type, rec.Encoding, rec.Value, rec.TTL > 0)
+	}
+	return records
+}
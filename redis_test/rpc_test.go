@@ -0,0 +1,231 @@
+package redis_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	redisops "github.com/yann0917/redis-usage/redis"
+)
+
+// addParams 测试用的 RPC 参数
+type addParams struct {
+	A, B int
+}
+
+// startRPCServer 创建并启动注册了测试方法的 RPC 服务端
+func startRPCServer(t *testing.T, ctx context.Context, prefix string, opts *redisops.RPCServerOptions) *redisops.RPCServer {
+	t.Helper()
+	o := redisops.RPCServerOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	o.KeyPrefix = prefix + "rpc"
+	if o.ServerID == "" {
+		o.ServerID = "server-1"
+	}
+
+	server, err := redisops.NewRPCServer(globalManager, "calc", &o)
+	if err != nil {
+		t.Fatalf("创建 RPC 服务端失败: %v", err)
+	}
+	server.Handle("add", func(ctx context.Context, req *redisops.RPCRequest) (interface{}, error) {
+		var p addParams
+		if err := req.Bind(&p); err != nil {
+			return nil, err
+		}
+		return p.A + p.B, nil
+	})
+	server.Handle("fail", func(ctx context.Context, req *redisops.RPCRequest) (interface{}, error) {
+		return nil, errors.New("除数不能为零")
+	})
+	server.Handle("slow", func(ctx context.Context, req *redisops.RPCRequest) (interface{}, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	server.Handle("panic", func(ctx context.Context, req *redisops.RPCRequest) (interface{}, error) {
+		panic("处理失败")
+	})
+
+	if err := server.Start(ctx); err != nil {
+		t.Fatalf("启动 RPC 服务端失败: %v", err)
+	}
+	t.Cleanup(func() { server.Close(context.Background()) })
+	return server
+}
+
+// newRPCClient 创建测试用 RPC 客户端
+func newRPCClient(t *testing.T, prefix string) *redisops.RPCClient {
+	t.Helper()
+	client, err := redisops.NewRPCClient(globalManager, "calc", &redisops.RPCClientOptions{KeyPrefix: prefix + "rpc"})
+	if err != nil {
+		t.Fatalf("创建 RPC 客户端失败: %v", err)
+	}
+	t.Cleanup(func() { client.Close(context.Background()) })
+	return client
+}
+
+func TestRPC_ConcurrentCalls(t *testing.T) {
+	ctx, prefix := setupTest(t, "rpc_concurrent")
+	startRPCServer(t, ctx, prefix, &redisops.RPCServerOptions{Workers: 4})
+	client := newRPCClient(t, prefix)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			var sum int
+			if err := client.Call(ctx, "add", addParams{A: i, B: 100}, &sum); err != nil {
+				errs <- err
+				return
+			}
+			if sum != i+100 {
+				errs <- fmt.Errorf("add(%d, 100) 期望 %d，实际为 %d", i, i+100, sum)
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+func TestRPC_Errors(t *testing.T) {
+	ctx, prefix := setupTest(t, "rpc_errors")
+	startRPCServer(t, ctx, prefix, nil)
+	client := newRPCClient(t, prefix)
+
+	var rpcErr *redisops.RPCError
+	if err := client.Call(ctx, "fail", nil, nil); !errors.As(err, &rpcErr) || rpcErr.Message != "除数不能为零" {
+		t.Errorf("期望返回服务端错误，实际为 %v", err)
+	}
+	if err := client.Call(ctx, "unknown", nil, nil); !errors.As(err, &rpcErr) {
+		t.Errorf("期望未知方法返回 RPCError，实际为 %v", err)
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 200*time.Millisecond)
+	defer cancel()
+	if err := client.Call(timeoutCtx, "slow", nil, nil); !errors.Is(err, redisops.ErrRPCTimeout) {
+		t.Errorf("期望返回 ErrRPCTimeout，实际为 %v", err)
+	}
+
+	// 超时后的迟到响应不影响后续调用
+	var sum int
+	if err := client.Call(ctx, "add", addParams{A: 1, B: 2}, &sum); err != nil || sum != 3 {
+		t.Errorf("期望 add(1, 2) = 3，实际为 %d, %v", sum, err)
+	}
+}
+
+func TestRPC_PanicGoesToDeadLetter(t *testing.T) {
+	ctx, prefix := setupTest(t, "rpc_panic")
+	server := startRPCServer(t, ctx, prefix, nil)
+	client := newRPCClient(t, prefix)
+
+	var rpcErr *redisops.RPCError
+	if err := client.Call(ctx, "panic", nil, nil); !errors.As(err, &rpcErr) {
+		t.Fatalf("期望 panic 时返回 RPCError，实际为 %v", err)
+	}
+
+	letters, err := server.DeadLetters(ctx, 10)
+	if err != nil {
+		t.Fatalf("读取死信失败: %v", err)
+	}
+	if len(letters) != 1 {
+		t.Fatalf("期望 1 条死信，实际为 %d", len(letters))
+	}
+	var req redisops.RPCRequest
+	if err := json.Unmarshal([]byte(letters[0].Raw), &req); err != nil || req.Method != "panic" {
+		t.Errorf("死信内容不正确: %+v", letters[0])
+	}
+}
+
+func TestRPC_RecoverOrphanedRequests(t *testing.T) {
+	ctx, prefix := setupTest(t, "rpc_recover")
+
+	// 模拟上次运行的 worker 0 在处理中崩溃，遗留两个请求
+	processing := "{" + prefix + "rpc:calc}:processing:server-1:0"
+	retry, _ := json.Marshal(&redisops.RPCRequest{ID: "retry", Method: "count"})
+	exhausted, _ := json.Marshal(&redisops.RPCRequest{ID: "exhausted", Method: "count", Attempts: 2})
+	if err := globalManager.GetClient().LPush(ctx, processing, retry, exhausted).Err(); err != nil {
+		t.Fatalf("准备处理中列表失败: %v", err)
+	}
+
+	var handled atomic.Int32
+	server, err := redisops.NewRPCServer(globalManager, "calc", &redisops.RPCServerOptions{
+		KeyPrefix:   prefix + "rpc",
+		ServerID:    "server-1",
+		Workers:     1,
+		MaxAttempts: 3,
+	})
+	if err != nil {
+		t.Fatalf("创建 RPC 服务端失败: %v", err)
+	}
+	server.Handle("count", func(ctx context.Context, req *redisops.RPCRequest) (interface{}, error) {
+		if req.ID != "retry" || req.Attempts != 1 {
+			t.Errorf("期望重新投递 retry 请求且投递次数为 1，实际为 %+v", req)
+		}
+		handled.Add(1)
+		return nil, nil
+	})
+	if err := server.Start(ctx); err != nil {
+		t.Fatalf("启动 RPC 服务端失败: %v", err)
+	}
+	defer server.Close(ctx)
+
+	if !waitFor(t, 2*time.Second, func() bool { return handled.Load() == 1 }) {
+		t.Fatal("期望遗留请求被重新处理")
+	}
+	letters, err := server.DeadLetters(ctx, 10)
+	if err != nil {
+		t.Fatalf("读取死信失败: %v", err)
+	}
+	if len(letters) != 1 {
+		t.Fatalf("期望超过投递次数的请求进入死信，实际为 %+v", letters)
+	}
+	if n, _ := globalManager.GetClient().LLen(ctx, processing).Result(); n != 0 {
+		t.Errorf("期望处理中列表已清空，实际剩余 %d", n)
+	}
+}
+
+func TestClusterManager_RPC(t *testing.T) {
+	ctx, prefix := setupTest(t, "cluster_rpc")
+	manager := newTestClusterManager(t)
+	t.Cleanup(func() { manager.DeleteByPattern(context.Background(), "{"+prefix+"*", nil) })
+
+	// 同一服务的请求、处理中与回复列表位于同一槽位，BLMOVE 与写回响应的事务不会跨槽位
+	server, err := redisops.NewRPCServer(manager.RedisManager, "calc", &redisops.RPCServerOptions{KeyPrefix: prefix + "rpc", ServerID: "server-1"})
+	if err != nil {
+		t.Fatalf("创建 RPC 服务端失败: %v", err)
+	}
+	server.Handle("add", func(ctx context.Context, req *redisops.RPCRequest) (interface{}, error) {
+		var p addParams
+		if err := req.Bind(&p); err != nil {
+			return nil, err
+		}
+		return p.A + p.B, nil
+	})
+	if err := server.Start(ctx); err != nil {
+		t.Fatalf("启动 RPC 服务端失败: %v", err)
+	}
+	defer server.Close(context.Background())
+
+	client, err := redisops.NewRPCClient(manager.RedisManager, "calc", &redisops.RPCClientOptions{KeyPrefix: prefix + "rpc"})
+	if err != nil {
+		t.Fatalf("创建 RPC 客户端失败: %v", err)
+	}
+	defer client.Close(context.Background())
+	for i := range 5 {
+		var sum int
+		if err := client.Call(ctx, "add", addParams{A: i, B: 10}, &sum); err != nil || sum != i+10 {
+			t.Errorf("期望 add(%d, 10) = %d，实际为 %d, %v", i, i+10, sum, err)
+		}
+	}
+}
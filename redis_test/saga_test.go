@@ -0,0 +1,357 @@
+package redis_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	redisops "github.com/yann0917/redis-usage/redis"
+)
+
+// fakeInventory 进程内模拟的外部库存系统
+type fakeInventory struct {
+	mu       sync.Mutex
+	reserved map[string]int
+	calls    []string
+}
+
+func newFakeInventory() *fakeInventory {
+	return &fakeInventory{reserved: make(map[string]int)}
+}
+
+func (f *fakeInventory) record(call string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, call)
+}
+
+func (f *fakeInventory) adjust(sku string, delta int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.reserved[sku] += delta
+}
+
+func (f *fakeInventory) get(sku string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.reserved[sku]
+}
+
+// orderSaga 构造下单流程：预留库存 -> 扣减余额 -> 发货
+func orderSaga(inv *fakeInventory, payErr error, ship redisops.SagaFunc) redisops.SagaDefinition {
+	return redisops.SagaDefinition{
+		Name: "order",
+		Steps: []redisops.SagaStep{
+			{
+				Name: "reserve",
+				Action: func(ctx context.Context, exec *redisops.SagaExecution) error {
+					inv.record("reserve")
+					inv.adjust(exec.Data["sku"], 1)
+					exec.Data["reserved"] = "1"
+					return nil
+				},
+				Compensate: func(ctx context.Context, exec *redisops.SagaExecution) error {
+					inv.record("release")
+					inv.adjust(exec.Data["sku"], -1)
+					return nil
+				},
+			},
+			{
+				Name: "pay",
+				Action: func(ctx context.Context, exec *redisops.SagaExecution) error {
+					inv.record("pay")
+					return payErr
+				},
+				Compensate: func(ctx context.Context, exec *redisops.SagaExecution) error {
+					inv.record("refund")
+					return nil
+				},
+			},
+			{Name: "ship", Action: ship},
+		},
+	}
+}
+
+func TestSagaCoordinator_Completed(t *testing.T) {
+	ctx, prefix := setupTest(t, "saga_completed")
+	inv := newFakeInventory()
+
+	coordinator := redisops.NewSagaCoordinator(globalManager, &redisops.SagaOptions{KeyPrefix: prefix + "saga"})
+	ship := func(ctx context.Context, exec *redisops.SagaExecution) error {
+		inv.record("ship")
+		return nil
+	}
+	if err := coordinator.Register(orderSaga(inv, nil, ship)); err != nil {
+		t.Fatalf("注册流程失败: %v", err)
+	}
+
+	state, err := coordinator.Start(ctx, "order", "o-1", map[string]string{"sku": "A"})
+	if err != nil {
+		t.Fatalf("执行 Saga 失败: %v", err)
+	}
+	if state.Status != redisops.SagaStatusCompleted {
+		t.Errorf("期望状态 %s，实际为 %s", redisops.SagaStatusCompleted, state.Status)
+	}
+
+	// 重复 ID 应被拒绝
+	if _, err := coordinator.Start(ctx, "order", "o-1", nil); err == nil {
+		t.Error("期望重复的 Saga ID 返回错误")
+	}
+
+	loaded, err := coordinator.Load(ctx, "o-1")
+	if err != nil {
+		t.Fatalf("读取 Saga 状态失败: %v", err)
+	}
+	if loaded.Data["reserved"] != "1" {
+		t.Errorf("期望共享数据被持久化，实际为 %+v", loaded.Data)
+	}
+
+	logs, err := coordinator.Logs(ctx, "o-1")
+	if err != nil {
+		t.Fatalf("读取 Saga 流水失败: %v", err)
+	}
+	// 3 个步骤各有 started/completed，再加一条最终状态
+	if len(logs) != 7 {
+		t.Errorf("期望 7 条流水，实际为 %d", len(logs))
+	}
+}
+
+func TestSagaCoordinator_CompensateInReverse(t *testing.T) {
+	ctx, prefix := setupTest(t, "saga_compensate")
+	inv := newFakeInventory()
+	payErr := errors.New("余额不足")
+
+	coordinator := redisops.NewSagaCoordinator(globalManager, &redisops.SagaOptions{KeyPrefix: prefix + "saga"})
+	ship := func(ctx context.Context, exec *redisops.SagaExecution) error {
+		inv.record("ship")
+		return nil
+	}
+	if err := coordinator.Register(orderSaga(inv, payErr, ship)); err != nil {
+		t.Fatalf("注册流程失败: %v", err)
+	}
+
+	state, err := coordinator.Start(ctx, "order", "o-2", map[string]string{"sku": "B"})
+	var sagaErr *redisops.SagaError
+	if !errors.As(err, &sagaErr) {
+		t.Fatalf("期望返回 SagaError，实际为 %v", err)
+	}
+	if sagaErr.Step != "pay" {
+		t.Errorf("期望失败步骤为 pay，实际为 %s", sagaErr.Step)
+	}
+	if state.Status != redisops.SagaStatusCompensated {
+		t.Errorf("期望状态 %s，实际为 %s", redisops.SagaStatusCompensated, state.Status)
+	}
+
+	// 失败的 pay 步骤不需要补偿，只释放已预留的库存
+	expected := []string{"reserve", "pay", "release"}
+	if len(inv.calls) != len(expected) {
+		t.Fatalf("期望调用序列 %v，实际为 %v", expected, inv.calls)
+	}
+	for i := range expected {
+		if inv.calls[i] != expected[i] {
+			t.Errorf("期望调用序列 %v，实际为 %v", expected, inv.calls)
+			break
+		}
+	}
+	if inv.get("B") != 0 {
+		t.Errorf("期望库存已释放，实际预留 %d", inv.get("B"))
+	}
+}
+
+func TestSagaCoordinator_ResumeAfterCrash(t *testing.T) {
+	ctx, prefix := setupTest(t, "saga_resume")
+	inv := newFakeInventory()
+	keyPrefix := prefix + "saga"
+
+	// 第一个协调器在发货步骤中"崩溃"（上下文被取消）
+	crashCtx, crash := context.WithCancel(ctx)
+	crashing := redisops.NewSagaCoordinator(globalManager, &redisops.SagaOptions{KeyPrefix: keyPrefix})
+	crashShip := func(ctx context.Context, exec *redisops.SagaExecution) error {
+		crash()
+		return ctx.Err()
+	}
+	if err := crashing.Register(orderSaga(inv, nil, crashShip)); err != nil {
+		t.Fatalf("注册流程失败: %v", err)
+	}
+	if _, err := crashing.Start(crashCtx, "order", "o-3", map[string]string{"sku": "C"}); err == nil {
+		t.Fatal("期望中断的 Saga 返回错误")
+	}
+
+	state, err := crashing.Load(ctx, "o-3")
+	if err != nil {
+		t.Fatalf("读取 Saga 状态失败: %v", err)
+	}
+	if state.Status != redisops.SagaStatusRunning || state.Steps[2] != redisops.SagaStepStarted {
+		t.Fatalf("期望 Saga 停留在发货步骤，实际为 %+v", state)
+	}
+
+	// 新的协调器恢复执行
+	recovered := redisops.NewSagaCoordinator(globalManager, &redisops.SagaOptions{KeyPrefix: keyPrefix})
+	shipped := false
+	ship := func(ctx context.Context, exec *redisops.SagaExecution) error {
+		shipped = true
+		return nil
+	}
+	if err := recovered.Register(orderSaga(inv, nil, ship)); err != nil {
+		t.Fatalf("注册流程失败: %v", err)
+	}
+
+	states, err := recovered.Resume(ctx)
+	if err != nil {
+		t.Fatalf("恢复 Saga 失败: %v", err)
+	}
+	if len(states) != 1 || states[0].Status != redisops.SagaStatusCompleted {
+		t.Fatalf("期望恢复 1 个 Saga 并完成，实际为 %+v", states)
+	}
+	if !shipped {
+		t.Error("期望恢复后重新执行发货步骤")
+	}
+	if inv.get("C") != 1 {
+		t.Errorf("期望已完成步骤不被重复执行，实际预留 %d", inv.get("C"))
+	}
+
+	// 再次恢复不应有待处理的 Saga
+	states, err = recovered.Resume(ctx)
+	if err != nil {
+		t.Fatalf("再次恢复 Saga 失败: %v", err)
+	}
+	if len(states) != 0 {
+		t.Errorf("期望没有未完成的 Saga，实际为 %d", len(states))
+	}
+}
+
+func TestSagaCoordinator_ReservedIDs(t *testing.T) {
+	ctx, prefix := setupTest(t, "saga_reserved_ids")
+	inv := newFakeInventory()
+	coordinator := redisops.NewSagaCoordinator(globalManager, &redisops.SagaOptions{KeyPrefix: prefix + "saga"})
+	ship := func(ctx context.Context, exec *redisops.SagaExecution) error { return nil }
+	if err := coordinator.Register(orderSaga(inv, nil, ship)); err != nil {
+		t.Fatalf("注册流程失败: %v", err)
+	}
+
+	// 与键布局中的段名相同的 ID 不会与活跃集合或其他 Saga 的流水重名
+	for _, id := range []string{"active", "x", "x:log", "x:lock"} {
+		state, err := coordinator.Start(ctx, "order", id, map[string]string{"sku": "A"})
+		if err != nil || state.Status != redisops.SagaStatusCompleted {
+			t.Fatalf("期望 Saga %s 执行完成，实际为 %+v, %v", id, state, err)
+		}
+		if logs, err := coordinator.Logs(ctx, id); err != nil || len(logs) != 7 {
+			t.Errorf("期望 Saga %s 有 7 条流水，实际为 %d, %v", id, len(logs), err)
+		}
+	}
+	if loaded, err := coordinator.Load(ctx, "active"); err != nil || loaded.Status != redisops.SagaStatusCompleted {
+		t.Errorf("期望读取 ID 为 active 的 Saga，实际为 %+v, %v", loaded, err)
+	}
+	if states, err := coordinator.Resume(ctx); err != nil || len(states) != 0 {
+		t.Errorf("期望没有未完成的 Saga，实际为 %d, %v", len(states), err)
+	}
+}
+
+func TestSagaCoordinator_LeaseRenewal(t *testing.T) {
+	ctx, prefix := setupTest(t, "saga_lease")
+	keyPrefix := prefix + "saga"
+	lockKey := "{" + keyPrefix + "}:lock:l-1"
+	client := globalManager.GetClient()
+
+	coordinator := redisops.NewSagaCoordinator(globalManager, &redisops.SagaOptions{KeyPrefix: keyPrefix, LockTTL: time.Second})
+	var renewed time.Duration
+	err := coordinator.Register(redisops.SagaDefinition{Name: "slow", Steps: []redisops.SagaStep{
+		{Name: "first", Action: func(ctx context.Context, exec *redisops.SagaExecution) error {
+			// 模拟租约即将到期
+			return client.PExpire(ctx, lockKey, 50*time.Millisecond).Err()
+		}},
+		{Name: "second", Action: func(ctx context.Context, exec *redisops.SagaExecution) error {
+			renewed = client.PTTL(ctx, lockKey).Val()
+			return nil
+		}},
+	}})
+	if err != nil {
+		t.Fatalf("注册流程失败: %v", err)
+	}
+
+	state, err := coordinator.Start(ctx, "slow", "l-1", nil)
+	if err != nil || state.Status != redisops.SagaStatusCompleted {
+		t.Fatalf("期望 Saga 正常完成，实际为 %+v, %v", state, err)
+	}
+	if renewed < 500*time.Millisecond {
+		t.Errorf("期望下一个步骤开始前续期租约，实际剩余 %v", renewed)
+	}
+	if client.Exists(ctx, lockKey).Val() != 0 {
+		t.Error("期望结束后释放租约")
+	}
+}
+
+func TestSagaCoordinator_LeaseLost(t *testing.T) {
+	ctx, prefix := setupTest(t, "saga_lease_lost")
+	keyPrefix := prefix + "saga"
+	client := globalManager.GetClient()
+
+	coordinator := redisops.NewSagaCoordinator(globalManager, &redisops.SagaOptions{KeyPrefix: keyPrefix})
+	secondRan := false
+	err := coordinator.Register(redisops.SagaDefinition{Name: "stolen", Steps: []redisops.SagaStep{
+		{Name: "first", Action: func(ctx context.Context, exec *redisops.SagaExecution) error {
+			// 模拟租约过期后被其他进程接管
+			return client.Set(ctx, "{"+keyPrefix+"}:lock:s-1", "other", time.Minute).Err()
+		}},
+		{Name: "second", Action: func(ctx context.Context, exec *redisops.SagaExecution) error {
+			secondRan = true
+			return nil
+		}},
+	}})
+	if err != nil {
+		t.Fatalf("注册流程失败: %v", err)
+	}
+
+	if _, err := coordinator.Start(ctx, "stolen", "s-1", nil); !errors.Is(err, redisops.ErrSagaLeaseLost) {
+		t.Fatalf("期望返回 ErrSagaLeaseLost，实际为 %v", err)
+	}
+	if secondRan {
+		t.Error("期望租约失效后停止推进")
+	}
+	state, err := coordinator.Load(ctx, "s-1")
+	if err != nil || state.Steps[0] != redisops.SagaStepStarted {
+		t.Errorf("期望失去租约后不再写入状态，实际为 %+v, %v", state, err)
+	}
+	if got := client.Get(ctx, "{"+keyPrefix+"}:lock:s-1").Val(); got != "other" {
+		t.Errorf("期望不释放其他进程的租约，实际为 %q", got)
+	}
+	if _, err := coordinator.ResumeOne(ctx, "s-1"); !errors.Is(err, redisops.ErrSagaLocked) {
+		t.Errorf("期望租约被占用时恢复返回 ErrSagaLocked，实际为 %v", err)
+	}
+}
+
+func TestClusterManager_Saga(t *testing.T) {
+	ctx, prefix := setupTest(t, "cluster_saga")
+	manager := newTestClusterManager(t)
+	inv := newFakeInventory()
+	// 集群测试使用 0 号数据库，不会被 TestMain 清空
+	cleanup := func() {
+		manager.DeleteByPattern(context.Background(), prefix+"*", nil)
+		manager.DeleteByPattern(context.Background(), "{"+prefix+"*", nil)
+	}
+	cleanup()
+	t.Cleanup(cleanup)
+
+	// 同一协调器的所有键位于同一槽位，脚本与事务不会因跨槽位被拒绝
+	coordinator := redisops.NewSagaCoordinator(manager.RedisManager, &redisops.SagaOptions{KeyPrefix: prefix + "saga"})
+	ship := func(ctx context.Context, exec *redisops.SagaExecution) error { return nil }
+	if err := coordinator.Register(orderSaga(inv, errors.New("余额不足"), ship)); err != nil {
+		t.Fatalf("注册流程失败: %v", err)
+	}
+	state, err := coordinator.Start(ctx, "order", "c-1", map[string]string{"sku": "D"})
+	var sagaErr *redisops.SagaError
+	if !errors.As(err, &sagaErr) || state.Status != redisops.SagaStatusCompensated {
+		t.Fatalf("期望在集群上完成补偿，实际为 %+v, %v", state, err)
+	}
+	if inv.get("D") != 0 {
+		t.Errorf("期望库存已释放，实际为 %d", inv.get("D"))
+	}
+	if logs, err := coordinator.Logs(ctx, "c-1"); err != nil || len(logs) == 0 {
+		t.Errorf("期望读取到流水，实际为 %d 条, %v", len(logs), err)
+	}
+	if resumed, err := coordinator.Resume(ctx); err != nil || len(resumed) != 0 {
+		t.Errorf("期望没有未完成的 Saga，实际为 %d, %v", len(resumed), err)
+	}
+}
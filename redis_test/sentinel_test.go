@@ -0,0 +1,259 @@
+package redis_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/yann0917/redis-usage/internal"
+	redisops "github.com/yann0917/redis-usage/redis"
+)
+
+// localSentinel 本地 Sentinel 测试环境：一主一从加一个 Sentinel
+type localSentinel struct {
+	masterAddr   string
+	replicaAddr  string
+	sentinelAddr string
+}
+
+// startLocalSentinel 使用本机 redis-server 启动一主一从与一个 Sentinel，未安装时跳过测试
+func startLocalSentinel(t *testing.T) *localSentinel {
+	t.Helper()
+	bin, err := exec.LookPath("redis-server")
+	if err != nil {
+		t.Skip("未安装 redis-server，跳过 Sentinel 测试")
+	}
+
+	ctx := context.Background()
+	dir := t.TempDir()
+	start := func(args ...string) string {
+		port := freePort(t)
+		cmd := exec.Command(bin, append(args, "--port", strconv.Itoa(port), "--dir", dir, "--save", "", "--appendonly", "no")...)
+		if err := cmd.Start(); err != nil {
+			t.Fatalf("启动 redis-server 失败: %v", err)
+		}
+		t.Cleanup(func() {
+			cmd.Process.Kill()
+			cmd.Wait()
+		})
+
+		addr := fmt.Sprintf("127.0.0.1:%d", port)
+		client := redis.NewClient(&redis.Options{Addr: addr})
+		defer client.Close()
+		if !waitFor(t, 5*time.Second, func() bool { return client.Ping(ctx).Err() == nil }) {
+			t.Fatalf("等待 %s 启动超时", addr)
+		}
+		return addr
+	}
+
+	env := &localSentinel{}
+	env.masterAddr = start()
+	host, port, _ := strings.Cut(env.masterAddr, ":")
+	env.replicaAddr = start("--replicaof", host, port)
+
+	// Sentinel 会改写配置文件，必须使用可写的文件
+	conf := filepath.Join(dir, "sentinel.conf")
+	content := fmt.Sprintf("sentinel monitor mymaster %s %s 1\nsentinel down-after-milliseconds mymaster 1000\nsentinel failover-timeout mymaster 5000\n", host, port)
+	if err := os.WriteFile(conf, []byte(content), 0o644); err != nil {
+		t.Fatalf("写入 Sentinel 配置失败: %v", err)
+	}
+	env.sentinelAddr = start(conf, "--sentinel")
+
+	sentinel := redis.NewSentinelClient(&redis.Options{Addr: env.sentinelAddr})
+	defer sentinel.Close()
+	ready := waitFor(t, 10*time.Second, func() bool {
+		replicas, err := sentinel.Replicas(ctx, "mymaster").Result()
+		return err == nil && len(replicas) == 1
+	})
+	if !ready {
+		t.Fatal("等待 Sentinel 发现从节点超时")
+	}
+	return env
+}
+
+// config 返回通过 Sentinel 连接的配置
+func (e *localSentinel) config(readFromReplicas bool) *internal.RedisConfig {
+	config := internal.DefaultRedisConfig()
+	config.Addr = ""
+	config.SentinelMasterName = "mymaster"
+	config.SentinelAddrs = []string{"127.0.0.1:1", e.sentinelAddr} // 第一个 Sentinel 不可用
+	config.ReadFromReplicas = readFromReplicas
+	return config
+}
+
+func TestNewRedisManager_SentinelConfigValidation(t *testing.T) {
+	config := internal.DefaultRedisConfig()
+	config.SentinelMasterName = "mymaster"
+	if _, err := redisops.NewRedisManager(config); err == nil {
+		t.Error("期望未配置 Sentinel 地址时返回错误")
+	}
+
+	config.SentinelAddrs = []string{"127.0.0.1:1"}
+	config.DialTimeout = 200 * time.Millisecond
+	if _, err := redisops.NewRedisManager(config); err == nil {
+		t.Error("期望 Sentinel 不可用时返回错误")
+	}
+}
+
+func TestRedisManager_Sentinel(t *testing.T) {
+	ctx, prefix := setupTest(t, "sentinel")
+	env := startLocalSentinel(t)
+
+	manager, err := redisops.NewRedisManager(env.config(false))
+	if err != nil {
+		t.Fatalf("通过 Sentinel 创建管理器失败: %v", err)
+	}
+	defer manager.Close()
+
+	if manager.GetClient() == nil {
+		t.Error("期望 Sentinel 模式下可以获取底层客户端")
+	}
+	key := testKey(prefix, "key")
+	if err := manager.Set(ctx, key, "value", time.Minute); err != nil {
+		t.Fatalf("写入失败: %v", err)
+	}
+	if got, err := manager.Get(ctx, key); err != nil || got != "value" {
+		t.Errorf("期望读取到 value，实际为 %s, %v", got, err)
+	}
+
+	// 故障转移后写入自动切换到新的主节点
+	watcher, err := redisops.NewFailoverWatcher(redisops.FailoverWatcherOptions{
+		SentinelAddrs: []string{env.sentinelAddr},
+		MasterName:    "mymaster",
+	})
+	if err != nil {
+		t.Fatalf("创建故障转移监听器失败: %v", err)
+	}
+	if err := watcher.Start(ctx); err != nil {
+		t.Fatalf("启动故障转移监听器失败: %v", err)
+	}
+	defer watcher.Close()
+
+	sentinel := redis.NewSentinelClient(&redis.Options{Addr: env.sentinelAddr})
+	defer sentinel.Close()
+	if err := sentinel.Failover(ctx, "mymaster").Err(); err != nil {
+		t.Fatalf("触发故障转移失败: %v", err)
+	}
+
+	var switched redisops.FailoverEvent
+	timeout := time.After(15 * time.Second)
+	for switched.Type != redisops.FailoverMasterChanged {
+		select {
+		case switched = <-watcher.Events():
+		case <-timeout:
+			t.Fatal("等待 +switch-master 事件超时")
+		}
+	}
+	if switched.OldAddr != env.masterAddr || switched.NewAddr != env.replicaAddr {
+		t.Errorf("期望主节点从 %s 切换到 %s，实际为 %+v", env.masterAddr, env.replicaAddr, switched)
+	}
+
+	written := waitFor(t, 10*time.Second, func() bool {
+		return manager.Set(ctx, key, "after", time.Minute) == nil
+	})
+	if !written {
+		t.Fatal("故障转移后写入失败")
+	}
+	if got, err := manager.Get(ctx, key); err != nil || got != "after" {
+		t.Errorf("期望故障转移后读取到 after，实际为 %s, %v", got, err)
+	}
+}
+
+func TestRedisManager_SentinelReadFromReplicas(t *testing.T) {
+	ctx, prefix := setupTest(t, "sentinel_replicas")
+	env := startLocalSentinel(t)
+
+	manager, err := redisops.NewRedisManager(env.config(true))
+	if err != nil {
+		t.Fatalf("通过 Sentinel 创建管理器失败: %v", err)
+	}
+	defer manager.Close()
+
+	key := testKey(prefix, "key")
+	if err := manager.Set(ctx, key, "value", time.Minute); err != nil {
+		t.Fatalf("写入失败: %v", err)
+	}
+
+	// 多键命令与事务不受跨槽位限制，事务由主节点客户端执行
+	if _, err := manager.MGet(ctx, key, testKey(prefix, "other")); err != nil {
+		t.Errorf("期望 Sentinel 模式下多键命令不做槽位校验，实际为 %v", err)
+	}
+	_, err = manager.GetClient().TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, testKey(prefix, "tx:a"), "1", time.Minute)
+		pipe.Set(ctx, testKey(prefix, "tx:b"), "2", time.Minute)
+		return nil
+	})
+	if err != nil {
+		t.Errorf("期望读写分离模式下可以执行跨键事务，实际为 %v", err)
+	}
+
+	// Saga 与 RPC 依赖事务与阻塞命令，在读写分离模式下同样可用
+	saga := redisops.NewSagaCoordinator(manager, &redisops.SagaOptions{KeyPrefix: prefix + "saga"})
+	saga.Register(redisops.SagaDefinition{Name: "noop", Steps: []redisops.SagaStep{
+		{Name: "step", Action: func(ctx context.Context, exec *redisops.SagaExecution) error { return nil }},
+	}})
+	if state, err := saga.Start(ctx, "noop", "s-1", nil); err != nil || state.Status != redisops.SagaStatusCompleted {
+		t.Errorf("期望 Saga 在读写分离模式下正常完成，实际为 %+v, %v", state, err)
+	}
+	server, err := redisops.NewRPCServer(manager, "echo", &redisops.RPCServerOptions{KeyPrefix: prefix + "rpc"})
+	if err != nil {
+		t.Fatalf("创建 RPC 服务端失败: %v", err)
+	}
+	server.Handle("echo", func(ctx context.Context, req *redisops.RPCRequest) (interface{}, error) { return "ok", nil })
+	if err := server.Start(ctx); err != nil {
+		t.Fatalf("启动 RPC 服务端失败: %v", err)
+	}
+	defer server.Close(context.Background())
+	rpcClient, err := redisops.NewRPCClient(manager, "echo", &redisops.RPCClientOptions{KeyPrefix: prefix + "rpc"})
+	if err != nil {
+		t.Fatalf("创建 RPC 客户端失败: %v", err)
+	}
+	defer rpcClient.Close(context.Background())
+	var reply string
+	if err := rpcClient.Call(ctx, "echo", nil, &reply); err != nil || reply != "ok" {
+		t.Errorf("期望 RPC 在读写分离模式下正常调用，实际为 %q, %v", reply, err)
+	}
+
+	replica := redis.NewClient(&redis.Options{Addr: env.replicaAddr})
+	defer replica.Close()
+	getCalls := func() int {
+		stats, _ := replica.Info(ctx, "commandstats").Result()
+		for _, line := range strings.Split(stats, "\n") {
+			if rest, ok := strings.CutPrefix(line, "cmdstat_get:calls="); ok {
+				calls, _ := strconv.Atoi(rest[:strings.Index(rest, ",")])
+				return calls
+			}
+		}
+		return 0
+	}
+
+	before := getCalls()
+	replicated := waitFor(t, 5*time.Second, func() bool {
+		got, err := manager.Get(ctx, key)
+		return err == nil && got == "value"
+	})
+	if !replicated {
+		t.Fatal("等待从节点读取到写入的数据超时")
+	}
+	if getCalls() <= before {
+		t.Error("期望只读命令由从节点处理")
+	}
+
+	// 自动批处理客户端的只读命令同样发往从节点
+	batch := redisops.NewAutoBatchClient(manager, nil)
+	defer batch.Close()
+	before = getCalls()
+	if got, err := batch.Get(ctx, key); err != nil || got != "value" {
+		t.Errorf("期望通过自动批处理读取到 value，实际为 %q, %v", got, err)
+	}
+	if getCalls() <= before {
+		t.Error("期望自动批处理的只读命令由从节点处理")
+	}
+}
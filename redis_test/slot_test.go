@@ -0,0 +1,226 @@
+package redis_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	redisops "github.com/yann0917/redis-usage/redis"
+)
+
+func TestKeySlot(t *testing.T) {
+	// 期望值来自 CLUSTER KEYSLOT
+	tests := map[string]int{
+		"foo":                  12182,
+		"bar":                  5061,
+		"hello":                866,
+		"123456789":            12739,
+		"{user1000}.following": 3443,
+		"{user1000}.followers": 3443,
+		"foo{}{bar}":           8363, // 空 hash tag 时对整个键计算
+		"foo{{bar}}zap":        4015, // 只取第一个 { 与其后第一个 } 之间的内容 "{bar"
+		"foo{bar}{zap}":        5061, // 只使用第一个 hash tag
+	}
+	for key, want := range tests {
+		if got := redisops.KeySlot(key); got != want {
+			t.Errorf("KeySlot(%q) = %d，期望 %d", key, got, want)
+		}
+	}
+}
+
+func TestHashTag(t *testing.T) {
+	tests := map[string]string{
+		"user:1":            "user:1",
+		"{user:1}:profile":  "user:1",
+		"order:{42}:items":  "42",
+		"{}:empty":          "{}:empty",
+		"no-close{tag":      "no-close{tag",
+		"a{b}c{d}":          "b",
+		"{user:1}:settings": "user:1",
+	}
+	for key, want := range tests {
+		if got := redisops.HashTag(key); got != want {
+			t.Errorf("HashTag(%q) = %q，期望 %q", key, got, want)
+		}
+	}
+
+	key := redisops.HashTagKey("user:1", "profile")
+	if key != "{user:1}:profile" {
+		t.Errorf("期望构造的键为 {user:1}:profile，实际为 %s", key)
+	}
+	if redisops.KeySlot(key) != redisops.KeySlot(redisops.HashTagKey("user:1", "settings", "v2")) {
+		t.Error("期望相同 tag 构造的键位于同一槽位")
+	}
+}
+
+func TestGroupKeysBySlot(t *testing.T) {
+	keys := []string{"{a}:1", "b", "{a}:2", "{a}:3"}
+	groups := redisops.GroupKeysBySlot(keys)
+	if len(groups) != 2 {
+		t.Fatalf("期望分为 2 组，实际为 %v", groups)
+	}
+	got := groups[redisops.KeySlot("{a}:1")]
+	if strings.Join(got, ",") != "{a}:1,{a}:2,{a}:3" {
+		t.Errorf("期望组内保持原始顺序，实际为 %v", got)
+	}
+}
+
+func TestValidateSameSlot(t *testing.T) {
+	if err := redisops.ValidateSameSlot("{user:1}:profile", "{user:1}:settings"); err != nil {
+		t.Errorf("期望同一 hash tag 的键校验通过，实际为 %v", err)
+	}
+	if err := redisops.ValidateSameSlot("single"); err != nil {
+		t.Errorf("期望单个键校验通过，实际为 %v", err)
+	}
+
+	err := redisops.ValidateSameSlot("foo", "bar", "{bar}:copy")
+	var crossErr *redisops.CrossSlotError
+	if !errors.As(err, &crossErr) {
+		t.Fatalf("期望返回 CrossSlotError，实际为 %v", err)
+	}
+	if len(crossErr.Slots) != 2 || len(crossErr.Slots[5061]) != 2 {
+		t.Errorf("期望按槽位列出键，实际为 %v", crossErr.Slots)
+	}
+	for _, key := range []string{"foo", "bar", "{bar}:copy"} {
+		if !strings.Contains(err.Error(), key) {
+			t.Errorf("期望错误信息包含键 %s，实际为 %s", key, err)
+		}
+	}
+}
+
+// commandRecorder 记录客户端实际发出的命令名
+type commandRecorder struct {
+	mu        sync.Mutex
+	commands  []string
+	crossSlot []string // 参数全部为键且键跨槽位的多键命令
+}
+
+func (h *commandRecorder) record(cmds ...redis.Cmder) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, cmd := range cmds {
+		h.commands = append(h.commands, cmd.Name())
+		switch cmd.Name() {
+		case "del", "exists", "mget":
+			var keys []string
+			for _, arg := range cmd.Args()[1:] {
+				keys = append(keys, fmt.Sprint(arg))
+			}
+			if len(redisops.GroupKeysBySlot(keys)) > 1 {
+				h.crossSlot = append(h.crossSlot, cmd.Name())
+			}
+		}
+	}
+}
+
+func (h *commandRecorder) sent(names ...string) []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var found []string
+	for _, command := range h.commands {
+		for _, name := range names {
+			if command == name {
+				found = append(found, command)
+			}
+		}
+	}
+	return found
+}
+
+func (h *commandRecorder) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return next(ctx, network, addr)
+	}
+}
+
+func (h *commandRecorder) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		h.record(cmd)
+		return next(ctx, cmd)
+	}
+}
+
+func (h *commandRecorder) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		h.record(cmds...)
+		return next(ctx, cmds)
+	}
+}
+
+func TestClusterManager_CrossSlotRejectedLocally(t *testing.T) {
+	ctx, prefix := setupTest(t, "cluster_crossslot")
+	addrs := os.Getenv("REDIS_CLUSTER_ADDRS")
+	if addrs == "" {
+		t.Skip("未设置 REDIS_CLUSTER_ADDRS，跳过集群测试")
+	}
+	recorder := &commandRecorder{}
+	client := redis.NewClusterClient(&redis.ClusterOptions{Addrs: strings.Split(addrs, ",")})
+	client.AddHook(recorder)
+	manager := redisops.NewRedisManagerWithClient(client)
+	defer manager.Close()
+
+	// 脚本调用：键不在同一槽位时直接返回 CrossSlotError
+	fm, err := redisops.NewFunctionManager(manager, redisops.FunctionLibrary{
+		Name:     "test_counter",
+		Code:     counterLibraryV1,
+		Fallback: counterFallback,
+	})
+	if err != nil {
+		t.Fatalf("创建函数库管理器失败: %v", err)
+	}
+	var crossErr *redisops.CrossSlotError
+	if err := fm.FCall(ctx, "counter_incr", []string{"foo", "bar"}, 1).Err(); !errors.As(err, &crossErr) {
+		t.Errorf("期望脚本调用返回 CrossSlotError，实际为 %v", err)
+	}
+
+	// 事务：回复列表与处理中列表不在同一槽位时，完成请求的事务不会发出
+	keyPrefix := prefix + "rpc"
+	cleanup := func() { manager.DeleteByPattern(context.Background(), "{"+keyPrefix+"*", nil) }
+	cleanup()
+	t.Cleanup(cleanup)
+
+	reported := make(chan error, 1)
+	server, err := redisops.NewRPCServer(manager, "calc", &redisops.RPCServerOptions{
+		KeyPrefix: keyPrefix,
+		ServerID:  "server-1",
+		Workers:   1,
+		OnError: func(err error) {
+			select {
+			case reported <- err:
+			default:
+			}
+		},
+	})
+	if err != nil {
+		t.Fatalf("创建 RPC 服务端失败: %v", err)
+	}
+	server.Handle("echo", func(ctx context.Context, req *redisops.RPCRequest) (interface{}, error) { return "ok", nil })
+	request, _ := json.Marshal(&redisops.RPCRequest{ID: "1", Method: "echo", ReplyTo: prefix + "reply"})
+	if err := client.LPush(ctx, "{"+keyPrefix+":calc}:requests", request).Err(); err != nil {
+		t.Fatalf("写入请求失败: %v", err)
+	}
+	if err := server.Start(ctx); err != nil {
+		t.Fatalf("启动 RPC 服务端失败: %v", err)
+	}
+	defer server.Close(context.Background())
+
+	select {
+	case err := <-reported:
+		if !errors.As(err, &crossErr) {
+			t.Errorf("期望上报 CrossSlotError，实际为 %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("等待跨槽位错误超时")
+	}
+	if sent := recorder.sent("evalsha", "eval", "fcall", "multi", "exec", "lrem"); len(sent) != 0 {
+		t.Errorf("期望跨槽位时不发出命令，实际发出 %v", sent)
+	}
+}